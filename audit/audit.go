@@ -0,0 +1,22 @@
+// Package audit carries the actor of a request through its context, so that
+// services can attribute audit events without an explicit parameter.
+package audit
+
+import (
+	"context"
+
+	"go-boilerplate/models"
+)
+
+type ctxKey struct{}
+
+// WithActor returns a context attributing actions to actor.
+func WithActor(ctx context.Context, actor models.Actor) context.Context {
+	return context.WithValue(ctx, ctxKey{}, actor)
+}
+
+// ActorFrom returns the actor set on ctx, or the zero actor (the system).
+func ActorFrom(ctx context.Context) models.Actor {
+	actor, _ := ctx.Value(ctxKey{}).(models.Actor)
+	return actor
+}
@@ -0,0 +1,472 @@
+// Package cache implements typed cache-aside reads on top of Redis.
+//
+// A Cache[T] stores each value in an envelope that records until when it is
+// fresh. Concurrent misses for a key are coalesced in-process with
+// singleflight and across instances with a short Redis lock, so a cold key
+// costs one load instead of one per request. Values are kept for StaleTTL
+// past their freshness so they can be served while a background refresh
+// runs, and loads that report "not found" can be cached as negative entries.
+//
+// Entries can be tagged. Each tag is a Redis set of the keys cached under it,
+// and invalidating a tag deletes all of them in one Lua script, which suits
+// list and query results that any change to the underlying rows affects.
+//
+// A cache can also keep decoded entries in an in-process LRU in front of
+// Redis. The Manager keeps those local tiers coherent across instances.
+//
+// While the Redis circuit breaker is open, reads skip Redis and loads are
+// kept only in the local tier, so the cache keeps coalescing loads in memory
+// until Redis is back.
+package cache
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"sync/atomic"
+	"time"
+
+	"go-boilerplate/circuit"
+	"go-boilerplate/logger"
+	"go-boilerplate/utilities"
+
+	"github.com/redis/go-redis/v9"
+	"golang.org/x/sync/singleflight"
+)
+
+// lockPoll is how often a caller waiting for another instance's load checks
+// whether the value has been cached.
+const lockPoll = 50 * time.Millisecond
+
+// unlockScript deletes the lock KEYS[1] only if it still holds the token
+// ARGV[1], so a load that outlived its lock leaves another instance's alone.
+var unlockScript = redis.NewScript(`
+if redis.call('GET', KEYS[1]) == ARGV[1] then
+	return redis.call('DEL', KEYS[1])
+end
+return 0
+`)
+
+// Store is the subset of RedisService the cache needs. A missing key must be
+// reported as redis.Nil by GetJSON.
+type Store interface {
+	GetJSON(ctx context.Context, key string, dest interface{}) error
+	SetJSON(ctx context.Context, key string, value interface{}, expiration time.Duration) error
+	SetJSONTagged(ctx context.Context, key string, value interface{}, expiration time.Duration, tags []string) error
+	InvalidateTags(ctx context.Context, tags ...string) (int64, error)
+	Delete(ctx context.Context, key string) error
+	SetNX(ctx context.Context, key string, value string, expiration time.Duration) (bool, error)
+	RunScript(ctx context.Context, script *redis.Script, keys []string, args ...any) *redis.Cmd
+}
+
+// Options configures a Cache.
+type Options struct {
+	// Name identifies the cache in logs, invalidations and statistics.
+	Name string
+	// TTL is how long a loaded value is fresh.
+	TTL time.Duration
+	// Jitter spreads TTLs by up to this fraction in either direction, e.g. 0.1
+	// for ±10%.
+	Jitter float64
+	// NotFound is the error a loader returns for a missing entity. When
+	// NegativeTTL is positive, such results are cached and Get returns
+	// NotFound without calling the loader until the entry expires.
+	NotFound    error
+	NegativeTTL time.Duration
+	// StaleTTL is how long past its TTL a value is still served while it is
+	// refreshed in the background (0 disables stale reads).
+	StaleTTL time.Duration
+	// LockTTL bounds the Redis lock held while loading a key (0 disables the
+	// lock). Callers that find the lock taken wait up to LockWait for the
+	// holder to cache the value, then load it themselves.
+	LockTTL  time.Duration
+	LockWait time.Duration
+	// LocalSize bounds the in-process tier to this many entries (0 disables
+	// it). Local entries live for at most LocalTTL, which also bounds how long
+	// an invalidation missed during a reconnect can go unnoticed.
+	LocalSize int
+	LocalTTL  time.Duration
+	// Manager, if set, relays invalidations of the local tier to the other
+	// instances and reports the cache's statistics under Name.
+	Manager *Manager
+}
+
+// Stats counts the outcomes of lookups in each tier since startup.
+type Stats struct {
+	Local TierStats `json:"local"`
+	Redis TierStats `json:"redis"`
+}
+
+// TierStats counts lookups in one tier. Evictions are entries removed before
+// they expired: for the local tier to stay within LocalSize or because they
+// were invalidated, for Redis because they were deleted.
+type TierStats struct {
+	Hits      uint64 `json:"hits"`
+	Misses    uint64 `json:"misses"`
+	Evictions uint64 `json:"evictions"`
+}
+
+type tierCounters struct {
+	hits, misses, evictions atomic.Uint64
+}
+
+func (t *tierCounters) snapshot() TierStats {
+	return TierStats{Hits: t.hits.Load(), Misses: t.misses.Load(), Evictions: t.evictions.Load()}
+}
+
+// Loader loads the value for a key from the source of truth.
+type Loader[T any] func(ctx context.Context) (T, error)
+
+// Cache reads and writes values of type T with cache-aside semantics.
+type Cache[T any] struct {
+	store Store
+	opts  Options
+	group singleflight.Group
+	// refreshes coalesces background refreshes apart from loads on a miss,
+	// which must not share a refresh's errLocked.
+	refreshes singleflight.Group
+	local     *lru[*entry[T]]
+
+	localStats, redisStats tierCounters
+}
+
+// entry is the envelope stored in Redis.
+type entry[T any] struct {
+	Value T `json:"v"`
+	// Missing marks a negative entry.
+	Missing bool `json:"m,omitempty"`
+	// FreshUntil is when the entry becomes stale.
+	FreshUntil time.Time `json:"f"`
+}
+
+func New[T any](store Store, opts Options) *Cache[T] {
+	c := &Cache[T]{store: store, opts: opts}
+	if opts.LocalSize > 0 && opts.LocalTTL > 0 {
+		c.local = newLRU[*entry[T]](opts.LocalSize)
+	}
+	if opts.Manager != nil {
+		opts.Manager.register(opts.Name, c)
+	}
+	return c
+}
+
+// Get returns the cached value for key, calling load on a miss. A stale value
+// is returned as is and refreshed in the background. Redis failures are
+// logged and fall back to load, so the cache never makes a read fail.
+func (c *Cache[T]) Get(ctx context.Context, key string, load Loader[T]) (T, error) {
+	return c.GetTagged(ctx, key, nil, load)
+}
+
+// GetTagged is Get for entries that are cached under tags, which are the
+// names of the Redis sets tracking them.
+func (c *Cache[T]) GetTagged(ctx context.Context, key string, tags []string, load Loader[T]) (T, error) {
+	if e, ok := c.getLocal(key); ok {
+		logger.Debug(ctx, "Cache.Get local hit", map[string]any{"cache": c.opts.Name, "key": key})
+		return c.result(e)
+	}
+
+	e, err := c.read(ctx, key)
+	switch {
+	case err == nil:
+		c.redisStats.hits.Add(1)
+		if time.Now().After(e.FreshUntil) {
+			logger.Debug(ctx, "Cache.Get stale", map[string]any{"cache": c.opts.Name, "key": key})
+			c.refresh(ctx, key, tags, load)
+		} else {
+			logger.Debug(ctx, "Cache.Get hit", map[string]any{"cache": c.opts.Name, "key": key})
+			c.setLocal(key, e)
+		}
+		return c.result(e)
+	case errors.Is(err, redis.Nil):
+		c.redisStats.misses.Add(1)
+		logger.Debug(ctx, "Cache.Get miss", map[string]any{"cache": c.opts.Name, "key": key})
+	case errors.Is(err, circuit.ErrOpen):
+		logger.Debug(ctx, "Cache.Get: Redis unavailable, loading into local tier", map[string]any{"cache": c.opts.Name, "key": key})
+		return c.loadLocal(ctx, key, load)
+	default:
+		logger.Warn(ctx, "Cache.Get: read failed, loading directly", map[string]any{"cache": c.opts.Name, "key": key, "error": err.Error()})
+		return load(ctx)
+	}
+
+	v, err, shared := c.group.Do(key, func() (any, error) {
+		// Detach from the first caller's cancellation, which would otherwise
+		// fail every caller sharing the load.
+		return c.load(context.WithoutCancel(ctx), key, tags, load, true)
+	})
+	if shared {
+		logger.Debug(ctx, "Cache.Get: load shared", map[string]any{"cache": c.opts.Name, "key": key})
+	}
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+	return c.result(v.(*entry[T]))
+}
+
+// Set caches value under key, replacing any entry, and has the other
+// instances drop their local copies. If they cannot be told, the key is
+// deleted once Redis is back.
+func (c *Cache[T]) Set(ctx context.Context, key string, value T) error {
+	e := &entry[T]{Value: value}
+	if err := c.write(ctx, key, nil, e); err != nil {
+		c.evictLocal(key)
+		c.retry(pending{Key: key})
+		return err
+	}
+	c.setLocal(key, e)
+	if err := c.publish(ctx, key); err != nil {
+		c.retry(pending{Key: key})
+	}
+	return nil
+}
+
+// Delete drops key from the cache in every tier and on every instance. A
+// deletion that fails is retried once Redis is back.
+func (c *Cache[T]) Delete(ctx context.Context, key string) error {
+	// Redis goes first so that no instance can reload the old value into its
+	// local tier after evicting it.
+	err := c.store.Delete(ctx, key)
+	if err == nil {
+		c.redisStats.evictions.Add(1)
+	}
+	c.evictLocal(key)
+	if perr := c.publish(ctx, key); err != nil || perr != nil {
+		c.retry(pending{Key: key})
+	}
+	return err
+}
+
+// InvalidateTags deletes every entry cached under the given tags. Entries are
+// not tracked by tag in the local tier, so it is cleared on every instance.
+// An invalidation that fails is retried once Redis is back.
+func (c *Cache[T]) InvalidateTags(ctx context.Context, tags ...string) error {
+	n, err := c.store.InvalidateTags(ctx, tags...)
+	if err == nil {
+		c.redisStats.evictions.Add(uint64(n))
+	}
+	c.flushLocal()
+	if perr := c.publish(ctx, ""); err != nil || perr != nil {
+		for _, tag := range tags {
+			c.retry(pending{Tag: tag})
+		}
+	}
+	return err
+}
+
+// refresh reloads a stale key in the background. Only one refresh per key
+// runs in this process, and none when another instance holds the lock.
+func (c *Cache[T]) refresh(ctx context.Context, key string, tags []string, load Loader[T]) {
+	ctx = context.WithoutCancel(ctx)
+	go func() {
+		_, err, _ := c.refreshes.Do(key, func() (any, error) {
+			return c.load(ctx, key, tags, load, false)
+		})
+		if err != nil && !errors.Is(err, errLocked) {
+			logger.Warn(ctx, "Cache.refresh failed", map[string]any{"cache": c.opts.Name, "key": key, "error": err.Error()})
+		}
+	}()
+}
+
+// errLocked reports that a background refresh was skipped because another
+// instance is loading the key.
+var errLocked = errors.New("cache: key is being loaded elsewhere")
+
+// load takes the key's lock, calls the loader and caches the result. When the
+// lock is taken and wait is set, it waits for the holder's result instead.
+func (c *Cache[T]) load(ctx context.Context, key string, tags []string, load Loader[T], wait bool) (*entry[T], error) {
+	if c.opts.LockTTL > 0 {
+		lockKey, token := key+":lock", utilities.NewRandomID()
+		acquired, err := c.store.SetNX(ctx, lockKey, token, c.opts.LockTTL)
+		switch {
+		case err != nil:
+			logger.Warn(ctx, "Cache.load: lock failed, loading without it", map[string]any{"cache": c.opts.Name, "key": key, "error": err.Error()})
+		case acquired:
+			// The lock only throttles loads; if it expires early and another
+			// instance takes it, both load and the last write wins.
+			defer func() {
+				if err := c.store.RunScript(ctx, unlockScript, []string{lockKey}, token).Err(); err != nil {
+					logger.Warn(ctx, "Cache.load: unlock failed", map[string]any{"cache": c.opts.Name, "key": key, "error": err.Error()})
+				}
+			}()
+		case !wait:
+			return nil, errLocked
+		default:
+			if e, ok := c.await(ctx, key); ok {
+				return e, nil
+			}
+			logger.Warn(ctx, "Cache.load: lock wait timed out, loading", map[string]any{"cache": c.opts.Name, "key": key})
+		}
+	}
+
+	value, err := load(ctx)
+	if err != nil {
+		if c.opts.NotFound == nil || c.opts.NegativeTTL <= 0 || !errors.Is(err, c.opts.NotFound) {
+			return nil, err
+		}
+		e := &entry[T]{Missing: true}
+		if err := c.write(ctx, key, tags, e); err != nil {
+			logger.Warn(ctx, "Cache.load: negative entry write failed", map[string]any{"cache": c.opts.Name, "key": key, "error": err.Error()})
+			return e, nil
+		}
+		c.setLocal(key, e)
+		return e, nil
+	}
+
+	e := &entry[T]{Value: value}
+	if err := c.write(ctx, key, tags, e); err != nil {
+		logger.Warn(ctx, "Cache.load: write failed", map[string]any{"cache": c.opts.Name, "key": key, "error": err.Error()})
+		return e, nil
+	}
+	c.setLocal(key, e)
+	return e, nil
+}
+
+// loadLocal is the degraded path used while Redis is unavailable: loads are
+// still coalesced in-process and their results kept in the local tier only.
+func (c *Cache[T]) loadLocal(ctx context.Context, key string, load Loader[T]) (T, error) {
+	v, err, _ := c.group.Do(key, func() (any, error) {
+		value, err := load(context.WithoutCancel(ctx))
+		if err != nil {
+			return nil, err
+		}
+		e := &entry[T]{Value: value, FreshUntil: time.Now().Add(c.jitter(c.opts.TTL))}
+		c.setLocal(key, e)
+		return e, nil
+	})
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+	return c.result(v.(*entry[T]))
+}
+
+// await polls for a fresh entry written by the instance holding the lock.
+func (c *Cache[T]) await(ctx context.Context, key string) (*entry[T], bool) {
+	deadline := time.Now().Add(c.opts.LockWait)
+	for time.Now().Before(deadline) {
+		time.Sleep(lockPoll)
+		if e, err := c.read(ctx, key); err == nil && time.Now().Before(e.FreshUntil) {
+			return e, true
+		}
+	}
+	return nil, false
+}
+
+func (c *Cache[T]) read(ctx context.Context, key string) (*entry[T], error) {
+	var e entry[T]
+	if err := c.store.GetJSON(ctx, key, &e); err != nil {
+		return nil, err
+	}
+	return &e, nil
+}
+
+// write stamps the entry's freshness and stores it. Positive entries are kept
+// for StaleTTL past their TTL; negative entries are never served stale.
+func (c *Cache[T]) write(ctx context.Context, key string, tags []string, e *entry[T]) error {
+	ttl, keep := c.jitter(c.opts.TTL), c.opts.StaleTTL
+	if e.Missing {
+		ttl, keep = c.jitter(c.opts.NegativeTTL), 0
+	}
+	e.FreshUntil = time.Now().Add(ttl)
+	if len(tags) > 0 {
+		return c.store.SetJSONTagged(ctx, key, e, ttl+keep, tags)
+	}
+	return c.store.SetJSON(ctx, key, e, ttl+keep)
+}
+
+func (c *Cache[T]) jitter(ttl time.Duration) time.Duration {
+	if c.opts.Jitter <= 0 {
+		return ttl
+	}
+	return ttl + time.Duration(float64(ttl)*c.opts.Jitter*(2*rand.Float64()-1))
+}
+
+func (c *Cache[T]) result(e *entry[T]) (T, error) {
+	if e.Missing {
+		var zero T
+		return zero, c.opts.NotFound
+	}
+	return e.Value, nil
+}
+
+// getLocal looks key up in the local tier. Entries there are always fresh.
+func (c *Cache[T]) getLocal(key string) (*entry[T], bool) {
+	if c.local == nil {
+		return nil, false
+	}
+	e, ok := c.local.get(key)
+	if ok {
+		c.localStats.hits.Add(1)
+	} else {
+		c.localStats.misses.Add(1)
+	}
+	return e, ok
+}
+
+// setLocal keeps a fresh entry in the local tier until LocalTTL or until it
+// turns stale, whichever comes first.
+func (c *Cache[T]) setLocal(key string, e *entry[T]) {
+	if c.local == nil {
+		return
+	}
+	ttl := min(c.opts.LocalTTL, time.Until(e.FreshUntil))
+	if ttl <= 0 {
+		return
+	}
+	if n := c.local.set(key, e, ttl); n > 0 {
+		c.localStats.evictions.Add(uint64(n))
+	}
+}
+
+func (c *Cache[T]) evictLocal(key string) {
+	if c.local != nil && c.local.remove(key) {
+		c.localStats.evictions.Add(1)
+	}
+}
+
+func (c *Cache[T]) flushLocal() {
+	if c.local != nil {
+		c.localStats.evictions.Add(uint64(c.local.clear()))
+	}
+}
+
+// publish relays an invalidation to the other instances; an empty key clears
+// their local tier. Without a local tier there is nothing for them to evict.
+func (c *Cache[T]) publish(ctx context.Context, key string) error {
+	if c.local != nil && c.opts.Manager != nil {
+		return c.opts.Manager.publish(ctx, c.opts.Name, key)
+	}
+	return nil
+}
+
+// retry has the manager replay p later. Without a manager a failed
+// invalidation is only bounded by the entry's TTL.
+func (c *Cache[T]) retry(p pending) {
+	if c.opts.Manager != nil {
+		p.Cache = c.opts.Name
+		c.opts.Manager.retry(p)
+	}
+}
+
+// replay repeats a failed invalidation in Redis and on the other instances.
+func (c *Cache[T]) replay(ctx context.Context, p pending) error {
+	if p.Tag != "" {
+		n, err := c.store.InvalidateTags(ctx, p.Tag)
+		if err != nil {
+			return err
+		}
+		c.redisStats.evictions.Add(uint64(n))
+		c.flushLocal()
+		return c.publish(ctx, "")
+	}
+	if err := c.store.Delete(ctx, p.Key); err != nil {
+		return err
+	}
+	c.redisStats.evictions.Add(1)
+	c.evictLocal(p.Key)
+	return c.publish(ctx, p.Key)
+}
+
+func (c *Cache[T]) stats() Stats {
+	return Stats{Local: c.localStats.snapshot(), Redis: c.redisStats.snapshot()}
+}
@@ -0,0 +1,90 @@
+package cache
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+// lru is a size-bounded, concurrency-safe LRU map whose items also expire.
+type lru[V any] struct {
+	mu    sync.Mutex
+	size  int
+	order *list.List // front is the most recently used
+	items map[string]*list.Element
+}
+
+type lruItem[V any] struct {
+	key     string
+	value   V
+	expires time.Time
+}
+
+func newLRU[V any](size int) *lru[V] {
+	return &lru[V]{size: size, order: list.New(), items: make(map[string]*list.Element, size)}
+}
+
+// get returns the value for key if present and not expired.
+func (l *lru[V]) get(key string) (V, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	el, ok := l.items[key]
+	if !ok {
+		var zero V
+		return zero, false
+	}
+	item := el.Value.(*lruItem[V])
+	if time.Now().After(item.expires) {
+		l.order.Remove(el)
+		delete(l.items, key)
+		var zero V
+		return zero, false
+	}
+	l.order.MoveToFront(el)
+	return item.value, true
+}
+
+// set stores value for ttl and reports how many items were evicted to make room.
+func (l *lru[V]) set(key string, value V, ttl time.Duration) int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	expires := time.Now().Add(ttl)
+	if el, ok := l.items[key]; ok {
+		item := el.Value.(*lruItem[V])
+		item.value, item.expires = value, expires
+		l.order.MoveToFront(el)
+		return 0
+	}
+	l.items[key] = l.order.PushFront(&lruItem[V]{key: key, value: value, expires: expires})
+
+	evicted := 0
+	for l.order.Len() > l.size {
+		oldest := l.order.Back()
+		l.order.Remove(oldest)
+		delete(l.items, oldest.Value.(*lruItem[V]).key)
+		evicted++
+	}
+	return evicted
+}
+
+// remove deletes key and reports whether it was present.
+func (l *lru[V]) remove(key string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	el, ok := l.items[key]
+	if ok {
+		l.order.Remove(el)
+		delete(l.items, key)
+	}
+	return ok
+}
+
+// clear removes all items and returns how many there were.
+func (l *lru[V]) clear() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	n := l.order.Len()
+	l.order.Init()
+	l.items = make(map[string]*list.Element, l.size)
+	return n
+}
@@ -0,0 +1,185 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+
+	"go-boilerplate/logger"
+	"go-boilerplate/utilities"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Manager keeps the local tiers of an instance's caches coherent with the
+// other instances. Writes and deletes publish the key on a Redis pub/sub
+// channel and every other instance evicts it from its local tier. The
+// manager also reports the statistics of the caches registered with it.
+//
+// Invalidations that fail, in Redis or on the channel, are kept and retried
+// by Replay, so that an entry deleted while Redis was unavailable is not
+// served again once it is back.
+type Manager struct {
+	pubsub  PubSub
+	channel string
+	// origin identifies this instance so that it ignores its own messages.
+	origin string
+
+	mu     sync.RWMutex
+	caches map[string]member
+
+	pendingMu sync.Mutex
+	pending   map[pending]struct{}
+}
+
+// PubSub is the subset of RedisService the manager needs.
+type PubSub interface {
+	Publish(ctx context.Context, channel, message string) (int64, error)
+	Subscribe(ctx context.Context, fn func(context.Context, *redis.Message), channels ...string) error
+}
+
+// member is the part of a Cache the manager works with.
+type member interface {
+	evictLocal(key string)
+	flushLocal()
+	replay(ctx context.Context, p pending) error
+	stats() Stats
+}
+
+// pending is an invalidation that has yet to succeed: the deletion of Key,
+// or of every entry cached under Tag, from the named cache.
+type pending struct {
+	Cache string
+	Key   string
+	Tag   string
+}
+
+// invalidation is the message published on the channel. An empty Key
+// clears the cache's whole local tier.
+type invalidation struct {
+	Origin string `json:"origin"`
+	Cache  string `json:"cache"`
+	Key    string `json:"key"`
+}
+
+// NewManager publishes and subscribes through pubsub. Publishing through the
+// Redis circuit breaker fails fast rather than waiting on timeouts while
+// Redis is down, and the invalidation is kept for Replay.
+func NewManager(pubsub PubSub, channel string) *Manager {
+	return &Manager{
+		pubsub:  pubsub,
+		channel: channel,
+		origin:  utilities.NewRandomID(),
+		caches:  make(map[string]member),
+		pending: make(map[pending]struct{}),
+	}
+}
+
+// Run subscribes to the invalidation channel and evicts the keys other
+// instances publish until ctx is cancelled. It returns an error if the
+// subscription fails or is closed.
+func (m *Manager) Run(ctx context.Context) error {
+	// Invalidations published while this instance was not subscribed were
+	// missed, so nothing cached locally before now can be trusted.
+	m.flush()
+	return m.pubsub.Subscribe(ctx, func(ctx context.Context, msg *redis.Message) {
+		m.handle(ctx, msg.Payload)
+	}, m.channel)
+}
+
+// Replay retries the invalidations that have failed so far. It stops at the
+// first one that fails again, keeping it and the rest for the next call, and
+// returns its error.
+func (m *Manager) Replay(ctx context.Context) error {
+	m.pendingMu.Lock()
+	items := m.pending
+	m.pending = make(map[pending]struct{})
+	m.pendingMu.Unlock()
+	if len(items) == 0 {
+		return nil
+	}
+
+	replayed := 0
+	for p := range items {
+		m.mu.RLock()
+		c, ok := m.caches[p.Cache]
+		m.mu.RUnlock()
+		if ok {
+			if err := c.replay(ctx, p); err != nil {
+				for p := range items {
+					m.retry(p)
+				}
+				logger.Debug(ctx, "Cache.Replay: Redis still failing", map[string]any{"replayed": replayed, "pending": len(items), "error": err.Error()})
+				return err
+			}
+		}
+		delete(items, p)
+		replayed++
+	}
+	logger.Info(ctx, "Cache.Replay: invalidations replayed", map[string]any{"replayed": replayed})
+	return nil
+}
+
+// Stats returns the statistics of every registered cache by name.
+func (m *Manager) Stats() map[string]Stats {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	stats := make(map[string]Stats, len(m.caches))
+	for name, c := range m.caches {
+		stats[name] = c.stats()
+	}
+	return stats
+}
+
+func (m *Manager) register(name string, c member) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.caches[name] = c
+}
+
+// retry keeps p for Replay.
+func (m *Manager) retry(p pending) {
+	m.pendingMu.Lock()
+	defer m.pendingMu.Unlock()
+	m.pending[p] = struct{}{}
+}
+
+// publish tells the other instances to evict key from the named cache.
+func (m *Manager) publish(ctx context.Context, name, key string) error {
+	b, _ := json.Marshal(invalidation{Origin: m.origin, Cache: name, Key: key})
+	if _, err := m.pubsub.Publish(ctx, m.channel, string(b)); err != nil {
+		logger.Warn(ctx, "Cache.publish failed", map[string]any{"cache": name, "key": key, "error": err.Error()})
+		return err
+	}
+	return nil
+}
+
+func (m *Manager) handle(ctx context.Context, payload string) {
+	var msg invalidation
+	if err := json.Unmarshal([]byte(payload), &msg); err != nil {
+		logger.Warn(ctx, "Cache invalidation: malformed message", map[string]any{"payload": payload, "error": err.Error()})
+		return
+	}
+	if msg.Origin == m.origin {
+		return
+	}
+	m.mu.RLock()
+	c, ok := m.caches[msg.Cache]
+	m.mu.RUnlock()
+	if !ok {
+		return
+	}
+	if msg.Key == "" {
+		c.flushLocal()
+		return
+	}
+	c.evictLocal(msg.Key)
+}
+
+func (m *Manager) flush() {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	for _, c := range m.caches {
+		c.flushLocal()
+	}
+}
@@ -0,0 +1,159 @@
+// Package circuit implements a circuit breaker that stops calling a failing
+// dependency and lets a few probe calls through to detect its recovery.
+//
+// A breaker starts closed. After FailureThreshold consecutive failures it
+// opens and rejects calls with ErrOpen for OpenTimeout. It then turns
+// half-open and allows up to HalfOpenProbes concurrent calls: as many
+// successes in a row close it again, and any failure opens it for another
+// OpenTimeout.
+package circuit
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"go-boilerplate/logger"
+)
+
+// ErrOpen is returned instead of calling the dependency while the breaker is open.
+var ErrOpen = errors.New("circuit breaker is open")
+
+// State is the state of a Breaker.
+type State int
+
+const (
+	Closed State = iota
+	Open
+	HalfOpen
+)
+
+func (s State) String() string {
+	switch s {
+	case Closed:
+		return "closed"
+	case Open:
+		return "open"
+	default:
+		return "half_open"
+	}
+}
+
+// Config configures a Breaker.
+type Config struct {
+	// Name identifies the breaker in logs.
+	Name string
+	// FailureThreshold is the number of consecutive failures that opens the breaker.
+	FailureThreshold int
+	// OpenTimeout is how long the breaker rejects calls before probing.
+	OpenTimeout time.Duration
+	// HalfOpenProbes is the number of probe calls allowed at once while
+	// half-open, and the number of successes that close the breaker.
+	HalfOpenProbes int
+}
+
+type Breaker struct {
+	cfg Config
+
+	mu        sync.Mutex
+	state     State
+	failures  int
+	openedAt  time.Time
+	probes    int
+	successes int
+	// generation changes with every state change, so that outcomes of calls
+	// allowed in an earlier state are ignored.
+	generation uint64
+}
+
+func New(cfg Config) *Breaker {
+	if cfg.FailureThreshold < 1 {
+		cfg.FailureThreshold = 5
+	}
+	if cfg.HalfOpenProbes < 1 {
+		cfg.HalfOpenProbes = 1
+	}
+	return &Breaker{cfg: cfg}
+}
+
+// Allow reports whether a call may go ahead. If it may, done must be called
+// with the call's outcome.
+func (b *Breaker) Allow() (done func(failed bool), err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state == Open {
+		if time.Since(b.openedAt) < b.cfg.OpenTimeout {
+			return nil, ErrOpen
+		}
+		b.setState(HalfOpen)
+	}
+	if b.state == HalfOpen {
+		if b.probes >= b.cfg.HalfOpenProbes {
+			return nil, ErrOpen
+		}
+		b.probes++
+	}
+
+	generation := b.generation
+	return func(failed bool) { b.done(generation, failed) }, nil
+}
+
+// State returns the breaker's current state.
+func (b *Breaker) State() State {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.state
+}
+
+func (b *Breaker) done(generation uint64, failed bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if generation != b.generation {
+		return
+	}
+
+	switch b.state {
+	case Closed:
+		if !failed {
+			b.failures = 0
+			return
+		}
+		b.failures++
+		if b.failures >= b.cfg.FailureThreshold {
+			b.setState(Open)
+		}
+	case HalfOpen:
+		b.probes--
+		if failed {
+			b.setState(Open)
+			return
+		}
+		b.successes++
+		if b.successes >= b.cfg.HalfOpenProbes {
+			b.setState(Closed)
+		}
+	}
+}
+
+// setState moves to state and resets the counters. b.mu must be held.
+func (b *Breaker) setState(state State) {
+	previous := b.state
+	b.state = state
+	b.generation++
+	b.failures, b.probes, b.successes = 0, 0, 0
+	if state == Open {
+		b.openedAt = time.Now()
+	}
+
+	fields := map[string]any{"breaker": b.cfg.Name, "from": previous.String(), "to": state.String()}
+	switch state {
+	case Open:
+		logger.Warn(context.Background(), "Circuit breaker opened", fields)
+	case Closed:
+		logger.Info(context.Background(), "Circuit breaker closed", fields)
+	default:
+		logger.Debug(context.Background(), "Circuit breaker half-open", fields)
+	}
+}
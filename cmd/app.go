@@ -2,14 +2,28 @@ package cmd
 
 import (
 	"context"
+	"os"
+	"time"
 
+	"go-boilerplate/cache"
+	"go-boilerplate/circuit"
 	"go-boilerplate/config"
 	"go-boilerplate/database"
 	"go-boilerplate/handlers"
+	"go-boilerplate/idempotency"
+	"go-boilerplate/jobs"
+	"go-boilerplate/lock"
 	"go-boilerplate/logger"
+	"go-boilerplate/models"
+	"go-boilerplate/ratelimit"
 	"go-boilerplate/repository"
+	repoInterfaces "go-boilerplate/repository/interfaces"
 	"go-boilerplate/routes"
 	"go-boilerplate/services"
+	serviceInterfaces "go-boilerplate/services/interfaces"
+	"go-boilerplate/storage"
+	"go-boilerplate/streams"
+	"go-boilerplate/tenant"
 
 	"github.com/gin-gonic/gin"
 )
@@ -20,36 +34,174 @@ func initializeAppManual() (*gin.Engine, error) {
 	// Load configuration
 	cfg := config.Load()
 
-	// Database connections
-	db, err := database.NewConnection(cfg)
+	// Database connections. Redis comes first because migrations take a
+	// distributed lock.
+	rdb, err := database.NewRedisConnection(cfg)
 	if err != nil {
 		return nil, err
 	}
+	redisBreaker := newRedisBreaker(cfg)
+	redisRepo := repository.NewBreakerRedisRepository(repository.NewRedisRepository(rdb), redisBreaker)
+	locker := newLocker(redisRepo, cfg)
 
-	rdb, err := database.NewRedisConnection(cfg)
+	db, err := database.NewConnection(cfg, locker)
+	if err != nil {
+		return nil, err
+	}
+
+	// Blob storage
+	blobStore, err := storage.NewBlobStore(cfg)
 	if err != nil {
 		return nil, err
 	}
 
 	// Repositories
 	userRepo := repository.NewUserRepository(db)
-	redisRepo := repository.NewRedisRepository(rdb)
+	auditRepo := repository.NewAuditRepository(db)
+	settingsRepo := repository.NewSettingsRepository(db)
+	orgRepo := repository.NewOrganizationRepository(db)
+	teamRepo := repository.NewTeamRepository(db)
+	invitationRepo := repository.NewInvitationRepository(db)
+	outboxRepo := repository.NewOutboxRepository(db)
+	transactor := repository.NewTransactor(db)
+	webhookRepo := repository.NewWebhookRepository(db)
+	loginEventRepo := repository.NewLoginEventRepository(db)
 
 	// Services
 	authService := services.NewAuthService(cfg)
-	redisService := services.NewRedisService(redisRepo)
-	userService := services.NewUserService(userRepo, authService, redisService)
+	redisService := services.NewRedisService(redisRepo, cfg)
+	cacheManager := cache.NewManager(redisService, cfg.Cache.InvalidationChannel)
+	mailService := services.NewMailService(cfg)
+	auditService := services.NewAuditService(auditRepo)
+	settingsService := services.NewSettingsService(settingsRepo, transactor, redisService)
+	userCache := services.NewUserCache(redisService, cacheManager, cfg)
+	userListCache := services.NewUserListCache(redisService, cacheManager, cfg)
+	userService := services.NewAuditedUserService(services.NewUserService(userRepo, orgRepo, loginEventRepo, outboxRepo, transactor, authService, redisService, userCache, userListCache, mailService, blobStore, cfg), userRepo, transactor, authService, auditService, cfg)
+	importService := services.NewUserImportService(userRepo, outboxRepo, transactor, redisService, userListCache, cfg)
+	exportService := services.NewUserExportService(userRepo)
+	orgService := services.NewOrganizationService(orgRepo, authService, redisService)
+	teamService := services.NewTeamService(teamRepo, orgRepo)
+	invitationService := services.NewInvitationService(invitationRepo, orgRepo, teamRepo, userRepo, userService, transactor, authService, redisService, mailService, cfg)
+	uploadService := services.NewUploadService(blobStore, cfg)
+	webhookService := services.NewWebhookService(webhookRepo, orgRepo, cfg)
+	outboxService := services.NewOutboxService(outboxRepo, streams.NewPublisher(redisService, cfg.Outbox.StreamMaxLen), cfg)
+	gdprService := services.NewGDPRService(userRepo, orgRepo, loginEventRepo, outboxRepo, webhookRepo, transactor, auditService, settingsService, redisService, userCache, userListCache, authService, mailService, blobStore, cfg)
+	// A nil completion hook only logs finished uploads.
+	tusService, err := services.NewTusService(redisRepo, cfg, nil)
+	if err != nil {
+		return nil, err
+	}
 
 	// Handlers
 	userHandler := handlers.NewUserHandler(userService)
 	authHandler := handlers.NewAuthHandler(userService)
-	healthHandler := handlers.NewHealthHandler()
+	importHandler := handlers.NewUserImportHandler(importService, cfg)
+	exportHandler := handlers.NewUserExportHandler(exportService)
+	uploadHandler := handlers.NewUploadHandler(uploadService, cfg.Storage.MaxUploadBytes)
+	tusHandler := handlers.NewTusHandler(tusService, cfg)
+	gdprHandler := handlers.NewGDPRHandler(gdprService)
+	settingsHandler := handlers.NewSettingsHandler(settingsService)
+	orgHandler := handlers.NewOrganizationHandler(orgService)
+	teamHandler := handlers.NewTeamHandler(teamService)
+	invitationHandler := handlers.NewInvitationHandler(invitationService)
+	auditHandler := handlers.NewAuditHandler(auditService)
+	webhookHandler := handlers.NewWebhookHandler(webhookService)
+	cacheHandler := handlers.NewCacheHandler(cacheManager)
+	healthHandler := handlers.NewHealthHandler(redisBreaker)
 
 	// Setup routes
-	router := routes.SetupRoutes(userHandler, authHandler, healthHandler, authService)
-	// Attach tracing middleware
-	router.Use(logger.GinMiddleware())
+	router := routes.SetupRoutes(userHandler, authHandler, importHandler, exportHandler, uploadHandler, tusHandler, gdprHandler, settingsHandler, orgHandler, teamHandler, invitationHandler, auditHandler, webhookHandler, cacheHandler, healthHandler, authService, userService, orgService, cfg.Tenancy.Header, ratelimit.NewLimiter(redisRepo), cfg.RateLimit, newIdempotencyStore(redisRepo, cfg), cfg.Idempotency, cfg.TrustedProxies)
+
+	// Background jobs
+	eventConsumer := streams.NewConsumer(redisService, webhookConsumerConfig(cfg))
+	startJobs(ctx, cfg, userService, tusService, gdprService, outboxService, webhookService, eventConsumer, cacheManager, locker)
 
 	logger.Info(ctx, "Application initialized successfully", nil)
 	return router, nil
 }
+
+// startJobs schedules the application's background jobs.
+func startJobs(ctx context.Context, cfg *config.Config, userService serviceInterfaces.UserService, tusService serviceInterfaces.TusService, gdprService serviceInterfaces.GDPRService, outboxService serviceInterfaces.OutboxService, webhookService serviceInterfaces.WebhookService, eventConsumer *streams.Consumer, cacheManager *cache.Manager, locker *lock.Locker) {
+	// Jobs work across all organizations.
+	ctx = tenant.WithoutScope(ctx)
+	// Jobs over shared data run on one instance at a time. Tus uploads live
+	// on each instance's disk, the outbox relay takes a database lock and
+	// webhook deliveries are claimed row by row, so those run everywhere.
+	jobs.Every(ctx, "purge_deleted_users", cfg.Trash.PurgeInterval, jobs.Exclusive(locker, "purge_deleted_users", func(ctx context.Context) error {
+		_, err := userService.PurgeDeletedUsers(ctx, cfg.Trash.Retention)
+		return err
+	}))
+	jobs.Every(ctx, "purge_login_history", cfg.Activity.CleanupInterval, jobs.Exclusive(locker, "purge_login_history", func(ctx context.Context) error {
+		_, err := userService.PurgeLoginHistory(ctx, cfg.Activity.LoginHistoryRetention)
+		return err
+	}))
+	jobs.Every(ctx, "cleanup_tus_uploads", cfg.Tus.CleanupInterval, func(ctx context.Context) error {
+		_, err := tusService.CleanupExpired(ctx)
+		return err
+	})
+	jobs.Every(ctx, "process_erasures", cfg.GDPR.ErasureInterval, jobs.Exclusive(locker, "process_erasures", func(ctx context.Context) error {
+		_, err := gdprService.ProcessDueErasures(ctx)
+		return err
+	}))
+	jobs.Every(ctx, "outbox_relay", cfg.Outbox.RelayInterval, func(ctx context.Context) error {
+		_, err := outboxService.Relay(ctx)
+		return err
+	})
+	jobs.Every(ctx, "outbox_cleanup", cfg.Outbox.CleanupInterval, jobs.Exclusive(locker, "outbox_cleanup", func(ctx context.Context) error {
+		_, err := outboxService.PurgePublished(ctx, cfg.Outbox.Retention)
+		return err
+	}))
+	jobs.Forever(ctx, "webhook_events", 5*time.Second, func(ctx context.Context) error {
+		return eventConsumer.Run(ctx, webhookService.HandleEvent)
+	})
+	jobs.Forever(ctx, "cache_invalidation", 5*time.Second, cacheManager.Run)
+	jobs.Every(ctx, "cache_replay", 5*time.Second, cacheManager.Replay)
+	jobs.Every(ctx, "webhook_delivery", cfg.Webhook.PollInterval, func(ctx context.Context) error {
+		_, err := webhookService.DeliverDue(ctx)
+		return err
+	})
+	jobs.Every(ctx, "webhook_cleanup", cfg.Webhook.CleanupInterval, jobs.Exclusive(locker, "webhook_cleanup", func(ctx context.Context) error {
+		_, err := webhookService.PurgeDeliveries(ctx, cfg.Webhook.Retention)
+		return err
+	}))
+}
+
+// newRedisBreaker builds the circuit breaker shared by every Redis call that
+// serves a request.
+func newRedisBreaker(cfg *config.Config) *circuit.Breaker {
+	return circuit.New(circuit.Config{
+		Name:             "redis",
+		FailureThreshold: cfg.Redis.Breaker.Failures,
+		OpenTimeout:      cfg.Redis.Breaker.OpenTimeout,
+		HalfOpenProbes:   cfg.Redis.Breaker.HalfOpenProbes,
+	})
+}
+
+// newLocker builds the distributed locker used for migrations and jobs.
+func newLocker(redisRepo repoInterfaces.RedisRepository, cfg *config.Config) *lock.Locker {
+	return lock.NewLocker(redisRepo, lock.Options{
+		TTL:      cfg.Lock.TTL,
+		RetryMin: cfg.Lock.RetryMin,
+		RetryMax: cfg.Lock.RetryMax,
+	})
+}
+
+// newIdempotencyStore builds the store that records responses to requests
+// with an Idempotency-Key.
+func newIdempotencyStore(redisRepo repoInterfaces.RedisRepository, cfg *config.Config) *idempotency.Store {
+	return idempotency.NewStore(redisRepo, cfg.Idempotency.TTL, cfg.Idempotency.LockTTL)
+}
+
+// webhookConsumerConfig reads user events as the "webhooks" consumer group.
+// Instances are told apart by host name, which stays the same across restarts.
+func webhookConsumerConfig(cfg *config.Config) streams.ConsumerConfig {
+	hostname, err := os.Hostname()
+	if err != nil || hostname == "" {
+		hostname = "app"
+	}
+	return streams.ConsumerConfig{
+		Stream:   cfg.Outbox.StreamPrefix + models.AggregateUser,
+		Group:    "webhooks",
+		Consumer: hostname,
+	}
+}
@@ -3,17 +3,25 @@
 package cmd
 
 import (
+	"context"
+
 	"github.com/google/wire"
 
+	"go-boilerplate/cache"
+	"go-boilerplate/circuit"
 	"go-boilerplate/config"
 	"go-boilerplate/database"
 	"go-boilerplate/handlers"
-	"go-boilerplate/logger"
+	"go-boilerplate/idempotency"
+	"go-boilerplate/lock"
+	"go-boilerplate/ratelimit"
 	"go-boilerplate/repository"
 	repoInterfaces "go-boilerplate/repository/interfaces"
 	"go-boilerplate/routes"
 	"go-boilerplate/services"
 	serviceInterfaces "go-boilerplate/services/interfaces"
+	"go-boilerplate/storage"
+	"go-boilerplate/streams"
 
 	"github.com/gin-gonic/gin"
 	"github.com/redis/go-redis/v9"
@@ -21,27 +29,125 @@ import (
 )
 
 // Provider functions used by Wire
-func provideConfig() *config.Config                          { return config.Load() }
-func provideDB(cfg *config.Config) (*gorm.DB, error)         { return database.NewConnection(cfg) }
-func provideRedis(cfg *config.Config) (*redis.Client, error) { return database.NewRedisConnection(cfg) }
+func provideConfig() *config.Config { return config.Load() }
+func provideDB(cfg *config.Config, locker *lock.Locker) (*gorm.DB, error) {
+	return database.NewConnection(cfg, locker)
+}
+func provideRedis(cfg *config.Config) (redis.UniversalClient, error) {
+	return database.NewRedisConnection(cfg)
+}
+func provideRedisBreaker(cfg *config.Config) *circuit.Breaker { return newRedisBreaker(cfg) }
+func provideBlobStore(cfg *config.Config) (storage.BlobStore, error) {
+	return storage.NewBlobStore(cfg)
+}
 
 // Repositories
 func provideUserRepository(db *gorm.DB) repoInterfaces.UserRepository {
 	return repository.NewUserRepository(db)
 }
-func provideRedisRepository(rdb *redis.Client) repoInterfaces.RedisRepository {
-	return repository.NewRedisRepository(rdb)
+func provideAuditRepository(db *gorm.DB) repoInterfaces.AuditRepository {
+	return repository.NewAuditRepository(db)
+}
+func provideSettingsRepository(db *gorm.DB) repoInterfaces.SettingsRepository {
+	return repository.NewSettingsRepository(db)
+}
+func provideOrganizationRepository(db *gorm.DB) repoInterfaces.OrganizationRepository {
+	return repository.NewOrganizationRepository(db)
+}
+func provideTeamRepository(db *gorm.DB) repoInterfaces.TeamRepository {
+	return repository.NewTeamRepository(db)
+}
+func provideInvitationRepository(db *gorm.DB) repoInterfaces.InvitationRepository {
+	return repository.NewInvitationRepository(db)
+}
+func provideOutboxRepository(db *gorm.DB) repoInterfaces.OutboxRepository {
+	return repository.NewOutboxRepository(db)
+}
+func provideTransactor(db *gorm.DB) repoInterfaces.Transactor {
+	return repository.NewTransactor(db)
+}
+func provideWebhookRepository(db *gorm.DB) repoInterfaces.WebhookRepository {
+	return repository.NewWebhookRepository(db)
+}
+func provideLoginEventRepository(db *gorm.DB) repoInterfaces.LoginEventRepository {
+	return repository.NewLoginEventRepository(db)
+}
+func provideRedisRepository(rdb redis.UniversalClient, breaker *circuit.Breaker) repoInterfaces.RedisRepository {
+	return repository.NewBreakerRedisRepository(repository.NewRedisRepository(rdb), breaker)
+}
+
+func provideLocker(redisRepo repoInterfaces.RedisRepository, cfg *config.Config) *lock.Locker {
+	return newLocker(redisRepo, cfg)
+}
+
+func provideRateLimiter(redisRepo repoInterfaces.RedisRepository) *ratelimit.Limiter {
+	return ratelimit.NewLimiter(redisRepo)
+}
+
+func provideIdempotencyStore(redisRepo repoInterfaces.RedisRepository, cfg *config.Config) *idempotency.Store {
+	return newIdempotencyStore(redisRepo, cfg)
+}
+
+// Caches
+func provideCacheManager(redis serviceInterfaces.RedisService, cfg *config.Config) *cache.Manager {
+	return cache.NewManager(redis, cfg.Cache.InvalidationChannel)
+}
+func provideUserCache(redis serviceInterfaces.RedisService, manager *cache.Manager, cfg *config.Config) *services.UserCache {
+	return services.NewUserCache(redis, manager, cfg)
+}
+
+func provideUserListCache(redis serviceInterfaces.RedisService, manager *cache.Manager, cfg *config.Config) *services.UserListCache {
+	return services.NewUserListCache(redis, manager, cfg)
 }
 
 // Services
 func provideAuthService(cfg *config.Config) serviceInterfaces.AuthService {
 	return services.NewAuthService(cfg)
 }
-func provideRedisService(redisRepo repoInterfaces.RedisRepository) serviceInterfaces.RedisService {
-	return services.NewRedisService(redisRepo)
+func provideRedisService(redisRepo repoInterfaces.RedisRepository, cfg *config.Config) serviceInterfaces.RedisService {
+	return services.NewRedisService(redisRepo, cfg)
+}
+func provideMailService(cfg *config.Config) serviceInterfaces.MailService {
+	return services.NewMailService(cfg)
+}
+func provideAuditService(auditRepo repoInterfaces.AuditRepository) serviceInterfaces.AuditService {
+	return services.NewAuditService(auditRepo)
+}
+func provideSettingsService(settingsRepo repoInterfaces.SettingsRepository, tx repoInterfaces.Transactor, redis serviceInterfaces.RedisService) serviceInterfaces.SettingsService {
+	return services.NewSettingsService(settingsRepo, tx, redis)
 }
-func provideUserService(userRepo repoInterfaces.UserRepository, auth serviceInterfaces.AuthService, redis serviceInterfaces.RedisService) serviceInterfaces.UserService {
-	return services.NewUserService(userRepo, auth, redis)
+func provideUserService(userRepo repoInterfaces.UserRepository, orgRepo repoInterfaces.OrganizationRepository, loginEventRepo repoInterfaces.LoginEventRepository, outboxRepo repoInterfaces.OutboxRepository, tx repoInterfaces.Transactor, auth serviceInterfaces.AuthService, redis serviceInterfaces.RedisService, users *services.UserCache, lists *services.UserListCache, mail serviceInterfaces.MailService, audit serviceInterfaces.AuditService, store storage.BlobStore, cfg *config.Config) serviceInterfaces.UserService {
+	return services.NewAuditedUserService(services.NewUserService(userRepo, orgRepo, loginEventRepo, outboxRepo, tx, auth, redis, users, lists, mail, store, cfg), userRepo, tx, auth, audit, cfg)
+}
+func provideUserImportService(userRepo repoInterfaces.UserRepository, outboxRepo repoInterfaces.OutboxRepository, tx repoInterfaces.Transactor, redis serviceInterfaces.RedisService, lists *services.UserListCache, cfg *config.Config) serviceInterfaces.UserImportService {
+	return services.NewUserImportService(userRepo, outboxRepo, tx, redis, lists, cfg)
+}
+func provideUserExportService(userRepo repoInterfaces.UserRepository) serviceInterfaces.UserExportService {
+	return services.NewUserExportService(userRepo)
+}
+func provideOrganizationService(orgRepo repoInterfaces.OrganizationRepository, auth serviceInterfaces.AuthService, redis serviceInterfaces.RedisService) serviceInterfaces.OrganizationService {
+	return services.NewOrganizationService(orgRepo, auth, redis)
+}
+func provideTeamService(teamRepo repoInterfaces.TeamRepository, orgRepo repoInterfaces.OrganizationRepository) serviceInterfaces.TeamService {
+	return services.NewTeamService(teamRepo, orgRepo)
+}
+func provideInvitationService(invitationRepo repoInterfaces.InvitationRepository, orgRepo repoInterfaces.OrganizationRepository, teamRepo repoInterfaces.TeamRepository, userRepo repoInterfaces.UserRepository, users serviceInterfaces.UserService, tx repoInterfaces.Transactor, auth serviceInterfaces.AuthService, redis serviceInterfaces.RedisService, mail serviceInterfaces.MailService, cfg *config.Config) serviceInterfaces.InvitationService {
+	return services.NewInvitationService(invitationRepo, orgRepo, teamRepo, userRepo, users, tx, auth, redis, mail, cfg)
+}
+func provideUploadService(store storage.BlobStore, cfg *config.Config) serviceInterfaces.UploadService {
+	return services.NewUploadService(store, cfg)
+}
+func provideTusService(redisRepo repoInterfaces.RedisRepository, cfg *config.Config) (serviceInterfaces.TusService, error) {
+	return services.NewTusService(redisRepo, cfg, nil)
+}
+func provideGDPRService(userRepo repoInterfaces.UserRepository, orgRepo repoInterfaces.OrganizationRepository, loginEventRepo repoInterfaces.LoginEventRepository, outboxRepo repoInterfaces.OutboxRepository, webhookRepo repoInterfaces.WebhookRepository, tx repoInterfaces.Transactor, audit serviceInterfaces.AuditService, settings serviceInterfaces.SettingsService, redis serviceInterfaces.RedisService, users *services.UserCache, lists *services.UserListCache, auth serviceInterfaces.AuthService, mail serviceInterfaces.MailService, store storage.BlobStore, cfg *config.Config) serviceInterfaces.GDPRService {
+	return services.NewGDPRService(userRepo, orgRepo, loginEventRepo, outboxRepo, webhookRepo, tx, audit, settings, redis, users, lists, auth, mail, store, cfg)
+}
+func provideOutboxService(outboxRepo repoInterfaces.OutboxRepository, redis serviceInterfaces.RedisService, cfg *config.Config) serviceInterfaces.OutboxService {
+	return services.NewOutboxService(outboxRepo, streams.NewPublisher(redis, cfg.Outbox.StreamMaxLen), cfg)
+}
+func provideWebhookService(webhookRepo repoInterfaces.WebhookRepository, orgRepo repoInterfaces.OrganizationRepository, cfg *config.Config) serviceInterfaces.WebhookService {
+	return services.NewWebhookService(webhookRepo, orgRepo, cfg)
 }
 
 // Handlers
@@ -51,13 +157,57 @@ func provideUserHandler(svc serviceInterfaces.UserService) *handlers.UserHandler
 func provideAuthHandler(svc serviceInterfaces.UserService) *handlers.AuthHandler {
 	return handlers.NewAuthHandler(svc)
 }
-func provideHealthHandler() *handlers.HealthHandler { return handlers.NewHealthHandler() }
+func provideUserImportHandler(svc serviceInterfaces.UserImportService, cfg *config.Config) *handlers.UserImportHandler {
+	return handlers.NewUserImportHandler(svc, cfg)
+}
+func provideUserExportHandler(svc serviceInterfaces.UserExportService) *handlers.UserExportHandler {
+	return handlers.NewUserExportHandler(svc)
+}
+func provideUploadHandler(svc serviceInterfaces.UploadService, cfg *config.Config) *handlers.UploadHandler {
+	return handlers.NewUploadHandler(svc, cfg.Storage.MaxUploadBytes)
+}
+func provideTusHandler(svc serviceInterfaces.TusService, cfg *config.Config) *handlers.TusHandler {
+	return handlers.NewTusHandler(svc, cfg)
+}
+func provideGDPRHandler(svc serviceInterfaces.GDPRService) *handlers.GDPRHandler {
+	return handlers.NewGDPRHandler(svc)
+}
+func provideSettingsHandler(svc serviceInterfaces.SettingsService) *handlers.SettingsHandler {
+	return handlers.NewSettingsHandler(svc)
+}
+func provideOrganizationHandler(svc serviceInterfaces.OrganizationService) *handlers.OrganizationHandler {
+	return handlers.NewOrganizationHandler(svc)
+}
+func provideTeamHandler(svc serviceInterfaces.TeamService) *handlers.TeamHandler {
+	return handlers.NewTeamHandler(svc)
+}
+func provideInvitationHandler(svc serviceInterfaces.InvitationService) *handlers.InvitationHandler {
+	return handlers.NewInvitationHandler(svc)
+}
+func provideAuditHandler(svc serviceInterfaces.AuditService) *handlers.AuditHandler {
+	return handlers.NewAuditHandler(svc)
+}
+func provideWebhookHandler(svc serviceInterfaces.WebhookService) *handlers.WebhookHandler {
+	return handlers.NewWebhookHandler(svc)
+}
+func provideCacheHandler(manager *cache.Manager) *handlers.CacheHandler {
+	return handlers.NewCacheHandler(manager)
+}
+func provideHealthHandler(breaker *circuit.Breaker) *handlers.HealthHandler {
+	return handlers.NewHealthHandler(breaker)
+}
+
+// Background jobs. The marker type lets the router depend on jobs being started.
+type backgroundJobs struct{}
+
+func provideJobs(cfg *config.Config, svc serviceInterfaces.UserService, tus serviceInterfaces.TusService, gdpr serviceInterfaces.GDPRService, outbox serviceInterfaces.OutboxService, webhooks serviceInterfaces.WebhookService, redis serviceInterfaces.RedisService, caches *cache.Manager, locker *lock.Locker) backgroundJobs {
+	startJobs(context.Background(), cfg, svc, tus, gdpr, outbox, webhooks, streams.NewConsumer(redis, webhookConsumerConfig(cfg)), caches, locker)
+	return backgroundJobs{}
+}
 
 // Router
-func provideRouter(uh *handlers.UserHandler, ah *handlers.AuthHandler, hh *handlers.HealthHandler, auth serviceInterfaces.AuthService) *gin.Engine {
-	r := routes.SetupRoutes(uh, ah, hh, auth)
-	r.Use(logger.GinMiddleware())
-	return r
+func provideRouter(uh *handlers.UserHandler, ah *handlers.AuthHandler, ih *handlers.UserImportHandler, eh *handlers.UserExportHandler, uph *handlers.UploadHandler, th *handlers.TusHandler, gh *handlers.GDPRHandler, sh *handlers.SettingsHandler, oh *handlers.OrganizationHandler, tmh *handlers.TeamHandler, ivh *handlers.InvitationHandler, adh *handlers.AuditHandler, wh *handlers.WebhookHandler, ch *handlers.CacheHandler, hh *handlers.HealthHandler, auth serviceInterfaces.AuthService, svc serviceInterfaces.UserService, orgs serviceInterfaces.OrganizationService, limiter *ratelimit.Limiter, idem *idempotency.Store, cfg *config.Config, _ backgroundJobs) *gin.Engine {
+	return routes.SetupRoutes(uh, ah, ih, eh, uph, th, gh, sh, oh, tmh, ivh, adh, wh, ch, hh, auth, svc, orgs, cfg.Tenancy.Header, limiter, cfg.RateLimit, idem, cfg.Idempotency, cfg.TrustedProxies)
 }
 
 // InitializeApp is the Wire injector. The actual implementation is generated into wire_gen.go.
@@ -66,14 +216,57 @@ func InitializeApp() (*gin.Engine, error) {
 		provideConfig,
 		provideDB,
 		provideRedis,
+		provideRedisBreaker,
+		provideBlobStore,
 		provideUserRepository,
 		provideRedisRepository,
+		provideLocker,
+		provideRateLimiter,
+		provideIdempotencyStore,
+		provideAuditRepository,
+		provideSettingsRepository,
+		provideOrganizationRepository,
+		provideTeamRepository,
+		provideInvitationRepository,
+		provideOutboxRepository,
+		provideTransactor,
+		provideWebhookRepository,
+		provideLoginEventRepository,
+		provideCacheManager,
+		provideUserCache,
+		provideUserListCache,
 		provideAuthService,
 		provideRedisService,
+		provideMailService,
+		provideAuditService,
+		provideSettingsService,
 		provideUserService,
+		provideUserImportService,
+		provideUserExportService,
+		provideOrganizationService,
+		provideTeamService,
+		provideInvitationService,
+		provideUploadService,
+		provideTusService,
+		provideGDPRService,
+		provideOutboxService,
+		provideWebhookService,
 		provideUserHandler,
 		provideAuthHandler,
+		provideUserImportHandler,
+		provideUserExportHandler,
+		provideUploadHandler,
+		provideTusHandler,
+		provideGDPRHandler,
+		provideSettingsHandler,
+		provideOrganizationHandler,
+		provideTeamHandler,
+		provideInvitationHandler,
+		provideAuditHandler,
+		provideWebhookHandler,
+		provideCacheHandler,
 		provideHealthHandler,
+		provideJobs,
 		provideRouter,
 	)
 	return nil, nil
@@ -3,16 +3,41 @@ package config
 import (
 	"fmt"
 	"log"
+	"net/netip"
+	"slices"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/spf13/viper"
 )
 
 type Config struct {
-	Port     string
-	Database DatabaseConfig
-	Redis    RedisConfig
-	JWT      JWTConfig
+	Port        string
+	BaseURL     string
+	Database    DatabaseConfig
+	Redis       RedisConfig
+	JWT         JWTConfig
+	Trash       TrashConfig
+	Mail        MailConfig
+	Import      ImportConfig
+	Storage     StorageConfig
+	Avatar      AvatarConfig
+	Tus         TusConfig
+	GDPR        GDPRConfig
+	Tenancy     TenancyConfig
+	Outbox      OutboxConfig
+	Webhook     WebhookConfig
+	Activity    ActivityConfig
+	Cache       CacheConfig
+	Lock        LockConfig
+	RateLimit   RateLimitConfig
+	Idempotency IdempotencyConfig
+
+	// TrustedProxies are the IPs and CIDRs whose X-Forwarded-For and
+	// X-Real-IP headers are believed. Empty trusts none, so the client IP is
+	// the address of the connection.
+	TrustedProxies []string
 }
 
 type DatabaseConfig struct {
@@ -26,15 +51,312 @@ type DatabaseConfig struct {
 	TimeZone string
 }
 
+// Redis deployment modes.
+const (
+	RedisModeSingle   = "single"
+	RedisModeSentinel = "sentinel"
+	RedisModeCluster  = "cluster"
+)
+
+// Policies for features that need Redis while it is unavailable.
+const (
+	// RedisFailOpen carries on without Redis, e.g. logs in without
+	// recording the session.
+	RedisFailOpen = "fail_open"
+	// RedisFailClosed rejects the request with models.ErrUnavailable.
+	RedisFailClosed = "fail_closed"
+)
+
 type RedisConfig struct {
+	// Mode is RedisModeSingle, RedisModeSentinel or RedisModeCluster.
+	Mode string
+	// Host and Port address the server in single mode.
+	Host string
+	Port string
+	// Addrs lists the sentinels in sentinel mode and the seed nodes in
+	// cluster mode.
+	Addrs []string
+	// MasterName is the name of the master monitored by the sentinels.
+	MasterName       string
+	Username         string
+	Password         string
+	SentinelPassword string
+	// DB selects the database; cluster mode only has database 0.
+	DB int
+	// TLS enables TLS for every connection. TLSInsecureSkipVerify disables
+	// certificate verification and is meant for development only.
+	TLS                   bool
+	TLSInsecureSkipVerify bool
+	// PoolSize is the number of connections per node (0 uses 10 per CPU).
+	PoolSize     int
+	MinIdleConns int
+	PoolTimeout  time.Duration
+	DialTimeout  time.Duration
+	ReadTimeout  time.Duration
+	WriteTimeout time.Duration
+	// StartupRequired makes startup fail when Redis cannot be reached;
+	// otherwise the app starts degraded and reconnects in the background.
+	StartupRequired bool
+	// Breaker configures the circuit breaker around Redis calls.
+	Breaker RedisBreakerConfig
+	// SessionPolicy is RedisFailOpen or RedisFailClosed and applies to
+	// recording and revoking sessions.
+	SessionPolicy string
+}
+
+// RedisBreakerConfig configures the Redis circuit breaker. After Failures
+// consecutive failed calls it rejects calls for OpenTimeout, then lets
+// HalfOpenProbes calls through to check whether Redis is back.
+type RedisBreakerConfig struct {
+	Failures       int
+	OpenTimeout    time.Duration
+	HalfOpenProbes int
+}
+
+type JWTConfig struct {
+	Secret string
+	// EmailChangeTTL is how long an email change confirmation link stays valid.
+	EmailChangeTTL time.Duration
+	// InvitationTTL is how long an organization invitation link stays valid.
+	InvitationTTL time.Duration
+}
+
+// MailConfig configures outgoing SMTP. An empty Host logs emails instead of sending them.
+type MailConfig struct {
 	Host     string
 	Port     string
+	Username string
 	Password string
-	DB       int
+	From     string
 }
 
-type JWTConfig struct {
-	Secret string
+// ImportConfig tunes bulk user imports.
+type ImportConfig struct {
+	// BatchSize is the number of rows inserted per transaction.
+	BatchSize int
+	// AsyncThresholdBytes makes uploads larger than this run as background jobs.
+	AsyncThresholdBytes int64
+	// MaxReportedErrors caps the per-row error list kept in a report.
+	MaxReportedErrors int
+	// JobTTL is how long job status is kept in Redis for polling.
+	JobTTL time.Duration
+}
+
+// StorageConfig selects and configures the blob store used for uploads.
+type StorageConfig struct {
+	// Backend is "local" or "s3".
+	Backend   string
+	LocalRoot string
+	S3        S3Config
+	// SigningSecret signs expiring download URLs.
+	SigningSecret  string
+	URLTTL         time.Duration
+	MaxUploadBytes int64
+	// AllowedTypes lists the sniffed MIME types accepted for uploads.
+	AllowedTypes []string
+}
+
+// AvatarConfig controls profile picture processing.
+type AvatarConfig struct {
+	// Sizes lists the edge lengths (px) of the square thumbnails generated per avatar.
+	Sizes    []int
+	MaxBytes int64
+}
+
+// TusConfig configures resumable uploads (tus 1.0).
+type TusConfig struct {
+	// Dir holds the partially (and fully) uploaded files.
+	Dir     string
+	MaxSize int64
+	// Expiration is how long an upload is kept after its last chunk.
+	Expiration time.Duration
+	// CleanupInterval is how often files of expired uploads are removed (0 disables it).
+	CleanupInterval time.Duration
+}
+
+// GDPRConfig controls right-to-erasure processing.
+type GDPRConfig struct {
+	// ErasureGracePeriod is how long an erasure request can be cancelled before it is carried out.
+	ErasureGracePeriod time.Duration
+	// ErasureInterval is how often due erasure requests are processed (0 disables it).
+	ErasureInterval time.Duration
+	// AuditPIIKey keys the hashes that stand in for email addresses in the
+	// audit log, which cannot be erased.
+	AuditPIIKey string
+}
+
+// TenancyConfig controls how requests are scoped to organizations.
+type TenancyConfig struct {
+	// Header names the request header that selects the organization.
+	Header string
+	// Required rejects tenant-scoped queries that run without an organization.
+	Required bool
+}
+
+// OutboxConfig controls the relay that publishes outbox events to Redis Streams.
+type OutboxConfig struct {
+	// RelayInterval is how often pending events are published (0 disables the relay).
+	RelayInterval time.Duration
+	// BatchSize is the number of events published per database transaction.
+	BatchSize int
+	// StreamPrefix is prepended to the aggregate type to name the stream, e.g. "events:user".
+	StreamPrefix string
+	// StreamMaxLen trims each stream to about this many entries (0 keeps all).
+	StreamMaxLen int64
+	// Retention is how long published events are kept in the outbox table.
+	Retention time.Duration
+	// CleanupInterval is how often old published events are removed (0 disables it).
+	CleanupInterval time.Duration
+}
+
+// WebhookConfig controls the delivery of webhooks.
+type WebhookConfig struct {
+	// Workers is the number of deliveries sent concurrently.
+	Workers int
+	// PollInterval is how often due deliveries are sent (0 disables delivery).
+	PollInterval time.Duration
+	// BatchSize is the number of deliveries claimed at a time.
+	BatchSize int
+	// Timeout bounds a single delivery request.
+	Timeout time.Duration
+	// MaxAttempts is the number of failed attempts after which a delivery is dead.
+	MaxAttempts int
+	// RetryBase is the delay after the first failure; it doubles with every
+	// further failure up to RetryMax, with jitter.
+	RetryBase time.Duration
+	RetryMax  time.Duration
+	// Retention is how long finished deliveries stay in the delivery log.
+	Retention time.Duration
+	// CleanupInterval is how often old deliveries are removed (0 disables it).
+	CleanupInterval time.Duration
+	// AllowPrivateNetworks lets subscriptions target private, loopback and
+	// link-local addresses, e.g. for local development.
+	AllowPrivateNetworks bool
+}
+
+// ActivityConfig controls login history and last-seen tracking.
+type ActivityConfig struct {
+	// LastSeenInterval is the minimum time between two last_seen_at writes for a user.
+	LastSeenInterval time.Duration
+	// LoginHistoryRetention is how long login events are kept.
+	LoginHistoryRetention time.Duration
+	// CleanupInterval is how often old login events are removed (0 disables it).
+	CleanupInterval time.Duration
+}
+
+// CacheConfig controls the cache-aside caching of entities in Redis.
+type CacheConfig struct {
+	// UserTTL is how long a cached user is fresh.
+	UserTTL time.Duration
+	// UserListTTL is how long a cached page of the user list is fresh. Pages
+	// are dropped on every user change, but activity timestamps such as
+	// last_seen_at may lag by up to this long.
+	UserListTTL time.Duration
+	// Jitter spreads expiries by up to this fraction of the TTL in either
+	// direction so entries cached together do not expire together.
+	Jitter float64
+	// NegativeTTL is how long a missing entity is remembered (0 disables it).
+	NegativeTTL time.Duration
+	// StaleTTL is how long after expiry a stale entry is still served while
+	// it is reloaded in the background (0 disables it).
+	StaleTTL time.Duration
+	// LockTTL bounds the lock that lets one instance load a missing entry.
+	LockTTL time.Duration
+	// LockWait is how long other instances wait for that load before loading
+	// the entry themselves.
+	LockWait time.Duration
+	// LocalSize bounds the in-process tier in front of Redis (0 disables it).
+	LocalSize int
+	// LocalTTL is the longest an entry is served from the in-process tier.
+	LocalTTL time.Duration
+	// InvalidationChannel is the pub/sub channel on which instances tell each
+	// other to evict keys from their in-process tier.
+	InvalidationChannel string
+}
+
+// LockConfig controls the distributed locks that keep scheduled jobs and
+// migrations to one instance at a time.
+type LockConfig struct {
+	// TTL is how long a lock outlives a holder that stopped extending it.
+	TTL time.Duration
+	// RetryMin and RetryMax bound the backoff between acquisition attempts.
+	RetryMin time.Duration
+	RetryMax time.Duration
+	// MigrationWait is how long an instance waits for another one to finish
+	// migrating before it gives up starting.
+	MigrationWait time.Duration
+}
+
+// What rate limits are counted by. Each falls back to the client IP when the
+// request has no user or API key.
+const (
+	RateLimitByIP     = "ip"
+	RateLimitByUser   = "user"
+	RateLimitByAPIKey = "api_key"
+	// RateLimitByRoute shares one limit among all clients of a route.
+	RateLimitByRoute = "route"
+)
+
+// RateLimitConfig controls the rate limits of the API.
+type RateLimitConfig struct {
+	Enabled bool
+	// Default applies to routes without a policy of their own; nil leaves
+	// them unlimited.
+	Default *RateLimitPolicy
+	// Routes maps "<METHOD> <route pattern>", e.g. "POST /api/v1/auth/login",
+	// to the policy for that route.
+	Routes map[string]RateLimitPolicy
+	// FailurePolicy is RedisFailOpen or RedisFailClosed and decides what
+	// happens to requests while limits cannot be checked.
+	FailurePolicy string
+	// APIKeyHeader is the request header RateLimitByAPIKey reads.
+	APIKeyHeader string
+	// APIKeys are the keys RateLimitByAPIKey counts by. Requests with any
+	// other key are counted as if they had none.
+	APIKeys []string
+}
+
+// RateLimitPolicy allows Limit requests per Window, counted per KeyBy.
+// Algorithm is "sliding_window" or "token_bucket".
+type RateLimitPolicy struct {
+	Algorithm string
+	Limit     int
+	Window    time.Duration
+	KeyBy     string
+}
+
+// IdempotencyConfig controls how requests with an Idempotency-Key header are
+// recorded and replayed.
+type IdempotencyConfig struct {
+	// TTL is how long a response is replayed to retries.
+	TTL time.Duration
+	// LockTTL bounds how long a request holds its key while it runs; a retry
+	// after that runs the request again.
+	LockTTL time.Duration
+	// MaxBodyBytes is the largest request body accepted with a key.
+	MaxBodyBytes int64
+	// MaxResponseBytes is the largest response recorded; larger ones are not
+	// replayed.
+	MaxResponseBytes int
+	// FailurePolicy is RedisFailOpen or RedisFailClosed and decides what
+	// happens to requests with a key while records cannot be read.
+	FailurePolicy string
+}
+
+// S3Config configures an S3-compatible backend accessed with path-style URLs.
+type S3Config struct {
+	Endpoint     string
+	Region       string
+	Bucket       string
+	AccessKey    string
+	SecretKey    string
+	CreateBucket bool
+}
+
+// TrashConfig controls how long soft-deleted users are kept before being purged.
+type TrashConfig struct {
+	Retention     time.Duration
+	PurgeInterval time.Duration
 }
 
 func Load() *Config {
@@ -42,6 +364,7 @@ func Load() *Config {
 
 	// Defaults
 	v.SetDefault("PORT", "8080")
+	v.SetDefault("APP_BASE_URL", "http://localhost:8080")
 	v.SetDefault("DB_DRIVER", "postgres")
 	v.SetDefault("DB_HOST", "localhost")
 	v.SetDefault("DB_PORT", "5432")
@@ -51,12 +374,123 @@ func Load() *Config {
 	v.SetDefault("DB_SSLMODE", "disable")
 	v.SetDefault("DB_TIMEZONE", "UTC")
 
+	v.SetDefault("REDIS_MODE", RedisModeSingle)
 	v.SetDefault("REDIS_HOST", "localhost")
 	v.SetDefault("REDIS_PORT", "6379")
+	v.SetDefault("REDIS_ADDRS", "")
+	v.SetDefault("REDIS_MASTER_NAME", "")
+	v.SetDefault("REDIS_USERNAME", "")
 	v.SetDefault("REDIS_PASSWORD", "")
+	v.SetDefault("REDIS_SENTINEL_PASSWORD", "")
 	v.SetDefault("REDIS_DB", 0)
+	v.SetDefault("REDIS_TLS", false)
+	v.SetDefault("REDIS_TLS_INSECURE_SKIP_VERIFY", false)
+	v.SetDefault("REDIS_POOL_SIZE", 0)
+	v.SetDefault("REDIS_MIN_IDLE_CONNS", 0)
+	v.SetDefault("REDIS_POOL_TIMEOUT", "4s")
+	v.SetDefault("REDIS_DIAL_TIMEOUT", "5s")
+	v.SetDefault("REDIS_READ_TIMEOUT", "3s")
+	v.SetDefault("REDIS_WRITE_TIMEOUT", "3s")
+	v.SetDefault("REDIS_STARTUP_REQUIRED", false)
+	v.SetDefault("REDIS_BREAKER_FAILURES", 5)
+	v.SetDefault("REDIS_BREAKER_OPEN_TIMEOUT", "10s")
+	v.SetDefault("REDIS_BREAKER_HALF_OPEN_PROBES", 1)
+	v.SetDefault("REDIS_SESSION_POLICY", RedisFailOpen)
 
 	v.SetDefault("JWT_SECRET", "your-secret-key")
+	v.SetDefault("EMAIL_CHANGE_TTL", "24h")
+	v.SetDefault("INVITATION_TTL", "168h")
+
+	v.SetDefault("SMTP_HOST", "")
+	v.SetDefault("SMTP_PORT", "587")
+	v.SetDefault("SMTP_USERNAME", "")
+	v.SetDefault("SMTP_PASSWORD", "")
+	v.SetDefault("MAIL_FROM", "no-reply@localhost")
+
+	v.SetDefault("IMPORT_BATCH_SIZE", 500)
+	v.SetDefault("IMPORT_ASYNC_THRESHOLD_BYTES", 1<<20)
+	v.SetDefault("IMPORT_MAX_REPORTED_ERRORS", 1000)
+	v.SetDefault("IMPORT_JOB_TTL", "24h")
+
+	v.SetDefault("STORAGE_BACKEND", "local")
+	v.SetDefault("STORAGE_LOCAL_ROOT", "storage/uploads")
+	v.SetDefault("S3_ENDPOINT", "http://localhost:9000")
+	v.SetDefault("S3_REGION", "us-east-1")
+	v.SetDefault("S3_BUCKET", "uploads")
+	v.SetDefault("S3_ACCESS_KEY", "")
+	v.SetDefault("S3_SECRET_KEY", "")
+	v.SetDefault("S3_CREATE_BUCKET", false)
+	v.SetDefault("STORAGE_SIGNING_SECRET", "")
+	v.SetDefault("STORAGE_URL_TTL", "15m")
+	v.SetDefault("UPLOAD_MAX_BYTES", 10<<20)
+	v.SetDefault("UPLOAD_ALLOWED_TYPES", "image/jpeg,image/png,image/gif,image/webp,application/pdf,text/plain")
+
+	v.SetDefault("AVATAR_SIZES", "64,128,256,512")
+	v.SetDefault("AVATAR_MAX_BYTES", 5<<20)
+
+	v.SetDefault("TUS_UPLOAD_DIR", "storage/uploads/tus")
+	v.SetDefault("TUS_MAX_SIZE", 1<<30)
+	v.SetDefault("TUS_EXPIRATION", "24h")
+	v.SetDefault("TUS_CLEANUP_INTERVAL", "1h")
+
+	v.SetDefault("ERASURE_GRACE_PERIOD", "720h")
+	v.SetDefault("ERASURE_INTERVAL", "1h")
+
+	v.SetDefault("TENANT_HEADER", "X-Tenant-ID")
+	v.SetDefault("TENANT_REQUIRED", false)
+
+	v.SetDefault("OUTBOX_RELAY_INTERVAL", "1s")
+	v.SetDefault("OUTBOX_BATCH_SIZE", 100)
+	v.SetDefault("OUTBOX_STREAM_PREFIX", "events:")
+	v.SetDefault("OUTBOX_STREAM_MAXLEN", 100000)
+	v.SetDefault("OUTBOX_RETENTION", "168h")
+	v.SetDefault("OUTBOX_CLEANUP_INTERVAL", "1h")
+
+	v.SetDefault("WEBHOOK_WORKERS", 8)
+	v.SetDefault("WEBHOOK_POLL_INTERVAL", "1s")
+	v.SetDefault("WEBHOOK_BATCH_SIZE", 50)
+	v.SetDefault("WEBHOOK_TIMEOUT", "10s")
+	v.SetDefault("WEBHOOK_MAX_ATTEMPTS", 8)
+	v.SetDefault("WEBHOOK_RETRY_BASE", "30s")
+	v.SetDefault("WEBHOOK_RETRY_MAX", "6h")
+	v.SetDefault("WEBHOOK_DELIVERY_RETENTION", "720h")
+	v.SetDefault("WEBHOOK_CLEANUP_INTERVAL", "1h")
+	v.SetDefault("WEBHOOK_ALLOW_PRIVATE_NETWORKS", false)
+
+	v.SetDefault("LAST_SEEN_INTERVAL", "5m")
+	v.SetDefault("LOGIN_HISTORY_RETENTION", "2160h")
+	v.SetDefault("LOGIN_HISTORY_CLEANUP_INTERVAL", "24h")
+
+	v.SetDefault("CACHE_USER_TTL", "30m")
+	v.SetDefault("CACHE_USER_LIST_TTL", "1m")
+	v.SetDefault("CACHE_TTL_JITTER", 0.1)
+	v.SetDefault("CACHE_NEGATIVE_TTL", "1m")
+	v.SetDefault("CACHE_STALE_TTL", "5m")
+	v.SetDefault("CACHE_LOCK_TTL", "5s")
+	v.SetDefault("CACHE_LOCK_WAIT", "2s")
+	v.SetDefault("CACHE_LOCAL_SIZE", 10000)
+	v.SetDefault("CACHE_LOCAL_TTL", "1m")
+	v.SetDefault("CACHE_INVALIDATION_CHANNEL", "cache:invalidate")
+
+	v.SetDefault("LOCK_TTL", "30s")
+	v.SetDefault("LOCK_RETRY_MIN", "50ms")
+	v.SetDefault("LOCK_RETRY_MAX", "1s")
+	v.SetDefault("LOCK_MIGRATION_WAIT", "2m")
+
+	v.SetDefault("RATE_LIMIT_ENABLED", true)
+	v.SetDefault("RATE_LIMIT_DEFAULT", "token_bucket:300/1m:user")
+	v.SetDefault("RATE_LIMIT_ROUTES", "POST /api/v1/auth/login=sliding_window:5/1m:ip,POST /api/v1/auth/register=sliding_window:3/1h:ip")
+	v.SetDefault("RATE_LIMIT_FAILURE_POLICY", RedisFailOpen)
+	v.SetDefault("RATE_LIMIT_API_KEY_HEADER", "X-API-Key")
+
+	v.SetDefault("IDEMPOTENCY_TTL", "24h")
+	v.SetDefault("IDEMPOTENCY_LOCK_TTL", "1m")
+	v.SetDefault("IDEMPOTENCY_MAX_BODY_BYTES", 1<<20)
+	v.SetDefault("IDEMPOTENCY_MAX_RESPONSE_BYTES", 1<<20)
+	v.SetDefault("IDEMPOTENCY_FAILURE_POLICY", RedisFailOpen)
+
+	v.SetDefault("TRASH_RETENTION", "720h")
+	v.SetDefault("TRASH_PURGE_INTERVAL", "1h")
 
 	// .env file support (if present)
 	v.SetConfigFile(".env")
@@ -72,7 +506,9 @@ func Load() *Config {
 	v.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
 
 	cfg := &Config{
-		Port: v.GetString("PORT"),
+		Port:           v.GetString("PORT"),
+		BaseURL:        strings.TrimRight(v.GetString("APP_BASE_URL"), "/"),
+		TrustedProxies: parseTrustedProxies(v.GetString("TRUSTED_PROXIES")),
 		Database: DatabaseConfig{
 			Driver:   v.GetString("DB_DRIVER"),
 			Host:     v.GetString("DB_HOST"),
@@ -84,19 +520,261 @@ func Load() *Config {
 			TimeZone: v.GetString("DB_TIMEZONE"),
 		},
 		Redis: RedisConfig{
-			Host:     v.GetString("REDIS_HOST"),
-			Port:     v.GetString("REDIS_PORT"),
-			Password: v.GetString("REDIS_PASSWORD"),
-			DB:       v.GetInt("REDIS_DB"),
+			Mode:                  strings.ToLower(v.GetString("REDIS_MODE")),
+			Host:                  v.GetString("REDIS_HOST"),
+			Port:                  v.GetString("REDIS_PORT"),
+			Addrs:                 splitList(v.GetString("REDIS_ADDRS")),
+			MasterName:            v.GetString("REDIS_MASTER_NAME"),
+			Username:              v.GetString("REDIS_USERNAME"),
+			Password:              v.GetString("REDIS_PASSWORD"),
+			SentinelPassword:      v.GetString("REDIS_SENTINEL_PASSWORD"),
+			DB:                    v.GetInt("REDIS_DB"),
+			TLS:                   v.GetBool("REDIS_TLS"),
+			TLSInsecureSkipVerify: v.GetBool("REDIS_TLS_INSECURE_SKIP_VERIFY"),
+			PoolSize:              v.GetInt("REDIS_POOL_SIZE"),
+			MinIdleConns:          v.GetInt("REDIS_MIN_IDLE_CONNS"),
+			PoolTimeout:           v.GetDuration("REDIS_POOL_TIMEOUT"),
+			DialTimeout:           v.GetDuration("REDIS_DIAL_TIMEOUT"),
+			ReadTimeout:           v.GetDuration("REDIS_READ_TIMEOUT"),
+			WriteTimeout:          v.GetDuration("REDIS_WRITE_TIMEOUT"),
+			StartupRequired:       v.GetBool("REDIS_STARTUP_REQUIRED"),
+			Breaker: RedisBreakerConfig{
+				Failures:       v.GetInt("REDIS_BREAKER_FAILURES"),
+				OpenTimeout:    v.GetDuration("REDIS_BREAKER_OPEN_TIMEOUT"),
+				HalfOpenProbes: v.GetInt("REDIS_BREAKER_HALF_OPEN_PROBES"),
+			},
+			SessionPolicy: strings.ToLower(v.GetString("REDIS_SESSION_POLICY")),
 		},
 		JWT: JWTConfig{
-			Secret: v.GetString("JWT_SECRET"),
+			Secret:         v.GetString("JWT_SECRET"),
+			EmailChangeTTL: v.GetDuration("EMAIL_CHANGE_TTL"),
+			InvitationTTL:  v.GetDuration("INVITATION_TTL"),
+		},
+		Trash: TrashConfig{
+			Retention:     v.GetDuration("TRASH_RETENTION"),
+			PurgeInterval: v.GetDuration("TRASH_PURGE_INTERVAL"),
+		},
+		Import: ImportConfig{
+			BatchSize:           v.GetInt("IMPORT_BATCH_SIZE"),
+			AsyncThresholdBytes: v.GetInt64("IMPORT_ASYNC_THRESHOLD_BYTES"),
+			MaxReportedErrors:   v.GetInt("IMPORT_MAX_REPORTED_ERRORS"),
+			JobTTL:              v.GetDuration("IMPORT_JOB_TTL"),
+		},
+		Storage: StorageConfig{
+			Backend:   v.GetString("STORAGE_BACKEND"),
+			LocalRoot: v.GetString("STORAGE_LOCAL_ROOT"),
+			S3: S3Config{
+				Endpoint:     v.GetString("S3_ENDPOINT"),
+				Region:       v.GetString("S3_REGION"),
+				Bucket:       v.GetString("S3_BUCKET"),
+				AccessKey:    v.GetString("S3_ACCESS_KEY"),
+				SecretKey:    v.GetString("S3_SECRET_KEY"),
+				CreateBucket: v.GetBool("S3_CREATE_BUCKET"),
+			},
+			SigningSecret:  v.GetString("STORAGE_SIGNING_SECRET"),
+			URLTTL:         v.GetDuration("STORAGE_URL_TTL"),
+			MaxUploadBytes: v.GetInt64("UPLOAD_MAX_BYTES"),
+			AllowedTypes:   splitList(v.GetString("UPLOAD_ALLOWED_TYPES")),
+		},
+		Avatar: AvatarConfig{
+			Sizes:    splitSizes(v.GetString("AVATAR_SIZES")),
+			MaxBytes: v.GetInt64("AVATAR_MAX_BYTES"),
+		},
+		Tus: TusConfig{
+			Dir:             v.GetString("TUS_UPLOAD_DIR"),
+			MaxSize:         v.GetInt64("TUS_MAX_SIZE"),
+			Expiration:      v.GetDuration("TUS_EXPIRATION"),
+			CleanupInterval: v.GetDuration("TUS_CLEANUP_INTERVAL"),
+		},
+		GDPR: GDPRConfig{
+			ErasureGracePeriod: v.GetDuration("ERASURE_GRACE_PERIOD"),
+			ErasureInterval:    v.GetDuration("ERASURE_INTERVAL"),
+			AuditPIIKey:        v.GetString("AUDIT_PII_KEY"),
+		},
+		Tenancy: TenancyConfig{
+			Header:   v.GetString("TENANT_HEADER"),
+			Required: v.GetBool("TENANT_REQUIRED"),
+		},
+		Outbox: OutboxConfig{
+			RelayInterval:   v.GetDuration("OUTBOX_RELAY_INTERVAL"),
+			BatchSize:       v.GetInt("OUTBOX_BATCH_SIZE"),
+			StreamPrefix:    v.GetString("OUTBOX_STREAM_PREFIX"),
+			StreamMaxLen:    v.GetInt64("OUTBOX_STREAM_MAXLEN"),
+			Retention:       v.GetDuration("OUTBOX_RETENTION"),
+			CleanupInterval: v.GetDuration("OUTBOX_CLEANUP_INTERVAL"),
+		},
+		Webhook: WebhookConfig{
+			Workers:              v.GetInt("WEBHOOK_WORKERS"),
+			PollInterval:         v.GetDuration("WEBHOOK_POLL_INTERVAL"),
+			BatchSize:            v.GetInt("WEBHOOK_BATCH_SIZE"),
+			Timeout:              v.GetDuration("WEBHOOK_TIMEOUT"),
+			MaxAttempts:          v.GetInt("WEBHOOK_MAX_ATTEMPTS"),
+			RetryBase:            v.GetDuration("WEBHOOK_RETRY_BASE"),
+			RetryMax:             v.GetDuration("WEBHOOK_RETRY_MAX"),
+			Retention:            v.GetDuration("WEBHOOK_DELIVERY_RETENTION"),
+			CleanupInterval:      v.GetDuration("WEBHOOK_CLEANUP_INTERVAL"),
+			AllowPrivateNetworks: v.GetBool("WEBHOOK_ALLOW_PRIVATE_NETWORKS"),
+		},
+		Activity: ActivityConfig{
+			LastSeenInterval:      v.GetDuration("LAST_SEEN_INTERVAL"),
+			LoginHistoryRetention: v.GetDuration("LOGIN_HISTORY_RETENTION"),
+			CleanupInterval:       v.GetDuration("LOGIN_HISTORY_CLEANUP_INTERVAL"),
+		},
+		Cache: CacheConfig{
+			UserTTL:             v.GetDuration("CACHE_USER_TTL"),
+			UserListTTL:         v.GetDuration("CACHE_USER_LIST_TTL"),
+			Jitter:              v.GetFloat64("CACHE_TTL_JITTER"),
+			NegativeTTL:         v.GetDuration("CACHE_NEGATIVE_TTL"),
+			StaleTTL:            v.GetDuration("CACHE_STALE_TTL"),
+			LockTTL:             v.GetDuration("CACHE_LOCK_TTL"),
+			LockWait:            v.GetDuration("CACHE_LOCK_WAIT"),
+			LocalSize:           v.GetInt("CACHE_LOCAL_SIZE"),
+			LocalTTL:            v.GetDuration("CACHE_LOCAL_TTL"),
+			InvalidationChannel: v.GetString("CACHE_INVALIDATION_CHANNEL"),
+		},
+		Lock: LockConfig{
+			TTL:           v.GetDuration("LOCK_TTL"),
+			RetryMin:      v.GetDuration("LOCK_RETRY_MIN"),
+			RetryMax:      v.GetDuration("LOCK_RETRY_MAX"),
+			MigrationWait: v.GetDuration("LOCK_MIGRATION_WAIT"),
+		},
+		RateLimit: RateLimitConfig{
+			Enabled:       v.GetBool("RATE_LIMIT_ENABLED"),
+			Default:       parseRateLimitDefault(v.GetString("RATE_LIMIT_DEFAULT")),
+			Routes:        parseRateLimitRoutes(v.GetString("RATE_LIMIT_ROUTES")),
+			FailurePolicy: strings.ToLower(v.GetString("RATE_LIMIT_FAILURE_POLICY")),
+			APIKeyHeader:  v.GetString("RATE_LIMIT_API_KEY_HEADER"),
+			APIKeys:       splitList(v.GetString("RATE_LIMIT_API_KEYS")),
+		},
+		Idempotency: IdempotencyConfig{
+			TTL:              v.GetDuration("IDEMPOTENCY_TTL"),
+			LockTTL:          v.GetDuration("IDEMPOTENCY_LOCK_TTL"),
+			MaxBodyBytes:     v.GetInt64("IDEMPOTENCY_MAX_BODY_BYTES"),
+			MaxResponseBytes: v.GetInt("IDEMPOTENCY_MAX_RESPONSE_BYTES"),
+			FailurePolicy:    strings.ToLower(v.GetString("IDEMPOTENCY_FAILURE_POLICY")),
+		},
+		Mail: MailConfig{
+			Host:     v.GetString("SMTP_HOST"),
+			Port:     v.GetString("SMTP_PORT"),
+			Username: v.GetString("SMTP_USERNAME"),
+			Password: v.GetString("SMTP_PASSWORD"),
+			From:     v.GetString("MAIL_FROM"),
 		},
 	}
 
+	// Fall back to the JWT secret so signed URLs work out of the box
+	if cfg.Storage.SigningSecret == "" {
+		cfg.Storage.SigningSecret = cfg.JWT.Secret
+	}
+	if cfg.GDPR.AuditPIIKey == "" {
+		cfg.GDPR.AuditPIIKey = cfg.JWT.Secret
+	}
+
 	return cfg
 }
 
+// splitList parses a comma-separated list, dropping empty entries.
+func splitList(s string) []string {
+	var out []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			out = append(out, item)
+		}
+	}
+	return out
+}
+
+// parseTrustedProxies parses a comma-separated list of IPs and CIDRs,
+// dropping invalid entries with a warning.
+func parseTrustedProxies(s string) []string {
+	var proxies []string
+	for _, item := range splitList(s) {
+		if _, err := netip.ParsePrefix(item); err != nil {
+			if _, err := netip.ParseAddr(item); err != nil {
+				log.Printf("config: ignoring TRUSTED_PROXIES entry %q: not an IP or CIDR", item)
+				continue
+			}
+		}
+		proxies = append(proxies, item)
+	}
+	return proxies
+}
+
+// parseRateLimitDefault parses the default policy; an empty spec disables it.
+func parseRateLimitDefault(spec string) *RateLimitPolicy {
+	if strings.TrimSpace(spec) == "" {
+		return nil
+	}
+	policy, err := parseRateLimitPolicy(spec)
+	if err != nil {
+		log.Printf("config: ignoring RATE_LIMIT_DEFAULT: %v", err)
+		return nil
+	}
+	return &policy
+}
+
+// parseRateLimitRoutes parses a comma-separated list of
+// "<METHOD> <route>=<policy>" entries, dropping invalid ones with a warning.
+func parseRateLimitRoutes(s string) map[string]RateLimitPolicy {
+	routes := make(map[string]RateLimitPolicy)
+	for _, item := range splitList(s) {
+		route, spec, ok := strings.Cut(item, "=")
+		method, path, hasPath := strings.Cut(strings.TrimSpace(route), " ")
+		if !ok || !hasPath {
+			log.Printf("config: ignoring RATE_LIMIT_ROUTES entry %q: want \"<METHOD> <route>=<policy>\"", item)
+			continue
+		}
+		policy, err := parseRateLimitPolicy(spec)
+		if err != nil {
+			log.Printf("config: ignoring RATE_LIMIT_ROUTES entry %q: %v", item, err)
+			continue
+		}
+		routes[strings.ToUpper(method)+" "+strings.TrimSpace(path)] = policy
+	}
+	return routes
+}
+
+// parseRateLimitPolicy parses "<algorithm>:<limit>/<window>:<key by>", e.g.
+// "sliding_window:5/1m:ip".
+func parseRateLimitPolicy(spec string) (RateLimitPolicy, error) {
+	parts := strings.Split(strings.TrimSpace(spec), ":")
+	if len(parts) != 3 {
+		return RateLimitPolicy{}, fmt.Errorf("policy %q: want <algorithm>:<limit>/<window>:<key by>", spec)
+	}
+	algorithm, rate, keyBy := strings.ToLower(parts[0]), parts[1], strings.ToLower(parts[2])
+	if algorithm != "sliding_window" && algorithm != "token_bucket" {
+		return RateLimitPolicy{}, fmt.Errorf("policy %q: unknown algorithm %q", spec, algorithm)
+	}
+	limit, window, ok := strings.Cut(rate, "/")
+	n, err := strconv.Atoi(limit)
+	if !ok || err != nil || n <= 0 {
+		return RateLimitPolicy{}, fmt.Errorf("policy %q: invalid limit %q", spec, limit)
+	}
+	d, err := time.ParseDuration(window)
+	if err != nil || d < time.Millisecond {
+		return RateLimitPolicy{}, fmt.Errorf("policy %q: invalid window %q", spec, window)
+	}
+	switch keyBy {
+	case RateLimitByIP, RateLimitByUser, RateLimitByAPIKey, RateLimitByRoute:
+	default:
+		return RateLimitPolicy{}, fmt.Errorf("policy %q: unknown key %q", spec, keyBy)
+	}
+	return RateLimitPolicy{Algorithm: algorithm, Limit: n, Window: d, KeyBy: keyBy}, nil
+}
+
+// splitSizes parses a comma-separated list of positive integers in ascending
+// order, dropping invalid entries and duplicates.
+func splitSizes(s string) []int {
+	var out []int
+	for _, item := range splitList(s) {
+		if n, err := strconv.Atoi(item); err == nil && n > 0 && !slices.Contains(out, n) {
+			out = append(out, n)
+		}
+	}
+	slices.Sort(out)
+	return out
+}
+
 func (d *DatabaseConfig) DSN() string {
 	return fmt.Sprintf("host=%s user=%s password=%s dbname=%s port=%s sslmode=%s TimeZone=%s",
 		d.Host, d.User, d.Password, d.DBName, d.Port, d.SSLMode, d.TimeZone)
@@ -2,9 +2,12 @@ package database
 
 import (
 	"context"
+	"crypto/tls"
+	"errors"
 	"fmt"
 
 	"go-boilerplate/config"
+	"go-boilerplate/lock"
 	"go-boilerplate/logger"
 	"go-boilerplate/models"
 
@@ -13,40 +16,173 @@ import (
 	"gorm.io/gorm"
 )
 
-func NewConnection(cfg *config.Config) (*gorm.DB, error) {
+// NewConnection opens the database and migrates it. Migrations run under the
+// distributed lock "migrations", so instances starting together migrate one
+// after the other; those that follow find the schema up to date.
+func NewConnection(cfg *config.Config, locker *lock.Locker) (*gorm.DB, error) {
 	dsn := cfg.Database.DSN()
 
-	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{})
+	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{
+		// Map driver errors such as unique violations to gorm.ErrDuplicatedKey
+		TranslateError: true,
+	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to connect to database: %w", err)
 	}
 
-	// Auto migrate tables
-	err = db.AutoMigrate(
+	ctx := context.Background()
+	migrated := false
+	err = locker.Do(ctx, "migrations", lock.Options{Wait: cfg.Lock.MigrationWait}, func(ctx context.Context, _ int64) error {
+		migrated = true
+		return migrate(db)
+	})
+	switch {
+	case errors.Is(err, lock.ErrNotAcquired):
+		return nil, fmt.Errorf("failed to migrate database: another instance is still migrating after %s", cfg.Lock.MigrationWait)
+	case err != nil && !migrated:
+		// Without Redis there is no lock to take; migrating unguarded is
+		// better than not starting at all.
+		logger.Warn(ctx, "Migration lock unavailable, migrating without it", map[string]any{"error": err.Error()})
+		if err := migrate(db); err != nil {
+			return nil, err
+		}
+	case errors.Is(err, lock.ErrLost):
+		logger.Warn(ctx, "Migration lock was lost while migrating", nil)
+	case err != nil:
+		return nil, err
+	}
+
+	// Registered after migrating so that schema inspection is never scoped.
+	if err := registerTenantScope(db, cfg.Tenancy.Required); err != nil {
+		return nil, fmt.Errorf("failed to register tenant scope: %w", err)
+	}
+
+	logger.Info(ctx, "Database connected and migrated successfully", nil)
+	return db, nil
+}
+
+func migrate(db *gorm.DB) error {
+	err := db.AutoMigrate(
 		&models.User{},
+		&models.AuditEvent{},
+		&models.UserSettings{},
+		&models.Organization{},
+		&models.Membership{},
+		&models.Team{},
+		&models.TeamMember{},
+		&models.Invitation{},
+		&models.OutboxEvent{},
+		&models.WebhookSubscription{},
+		&models.WebhookDelivery{},
+		&models.LoginEvent{},
 	)
 	if err != nil {
-		return nil, fmt.Errorf("failed to migrate database: %w", err)
+		return fmt.Errorf("failed to migrate database: %w", err)
 	}
 
-	logger.Info(context.Background(), "Database connected and migrated successfully", nil)
-	return db, nil
+	if err := dropLegacyUserEmailIndex(db); err != nil {
+		return fmt.Errorf("failed to migrate database: %w", err)
+	}
+
+	if err := protectAuditEvents(db); err != nil {
+		return fmt.Errorf("failed to migrate database: %w", err)
+	}
+	return nil
 }
 
-func NewRedisConnection(cfg *config.Config) (*redis.Client, error) {
-	rdb := redis.NewClient(&redis.Options{
-		Addr:     cfg.Redis.Address(),
-		Password: cfg.Redis.Password,
-		DB:       cfg.Redis.DB,
-	})
+// dropLegacyUserEmailIndex removes the original full unique index on users.email.
+// It has been replaced by the partial idx_users_email_active declared on models.User,
+// which ignores soft-deleted rows.
+func dropLegacyUserEmailIndex(db *gorm.DB) error {
+	migrator := db.Migrator()
+	if migrator.HasIndex(&models.User{}, "idx_users_email") {
+		return migrator.DropIndex(&models.User{}, "idx_users_email")
+	}
+	return nil
+}
+
+// protectAuditEvents installs triggers that reject UPDATE, DELETE and TRUNCATE
+// on audit_events, so the table is append-only for the application's role.
+// The hash chain still detects changes made by anyone able to drop them.
+func protectAuditEvents(db *gorm.DB) error {
+	return db.Exec(`
+CREATE OR REPLACE FUNCTION audit_events_append_only() RETURNS trigger AS $$
+BEGIN
+	RAISE EXCEPTION 'audit_events is append-only';
+END;
+$$ LANGUAGE plpgsql;
+DROP TRIGGER IF EXISTS audit_events_no_modify ON audit_events;
+CREATE TRIGGER audit_events_no_modify BEFORE UPDATE OR DELETE ON audit_events
+	FOR EACH ROW EXECUTE FUNCTION audit_events_append_only();
+DROP TRIGGER IF EXISTS audit_events_no_truncate ON audit_events;
+CREATE TRIGGER audit_events_no_truncate BEFORE TRUNCATE ON audit_events
+	FOR EACH STATEMENT EXECUTE FUNCTION audit_events_append_only();
+`).Error
+}
+
+// NewRedisConnection connects to a single Redis server, a Sentinel-managed
+// master or a Redis Cluster, depending on cfg.Redis.Mode.
+func NewRedisConnection(cfg *config.Config) (redis.UniversalClient, error) {
+	rc := cfg.Redis
+	opts := &redis.UniversalOptions{
+		Addrs:            rc.Addrs,
+		MasterName:       rc.MasterName,
+		Username:         rc.Username,
+		Password:         rc.Password,
+		SentinelPassword: rc.SentinelPassword,
+		DB:               rc.DB,
+		PoolSize:         rc.PoolSize,
+		MinIdleConns:     rc.MinIdleConns,
+		PoolTimeout:      rc.PoolTimeout,
+		DialTimeout:      rc.DialTimeout,
+		ReadTimeout:      rc.ReadTimeout,
+		WriteTimeout:     rc.WriteTimeout,
+	}
+	if rc.TLS {
+		opts.TLSConfig = &tls.Config{
+			MinVersion:         tls.VersionTLS12,
+			InsecureSkipVerify: rc.TLSInsecureSkipVerify,
+		}
+	}
+
+	// The mode is chosen explicitly rather than by redis.NewUniversalClient,
+	// which would take a cluster with a single seed node for a plain server.
+	var rdb redis.UniversalClient
+	switch rc.Mode {
+	case config.RedisModeSingle, "":
+		opts.Addrs = []string{rc.Address()}
+		rdb = redis.NewClient(opts.Simple())
+	case config.RedisModeSentinel:
+		if rc.MasterName == "" || len(rc.Addrs) == 0 {
+			return nil, fmt.Errorf("redis sentinel mode requires REDIS_MASTER_NAME and REDIS_ADDRS")
+		}
+		rdb = redis.NewFailoverClient(opts.Failover())
+	case config.RedisModeCluster:
+		if len(rc.Addrs) == 0 {
+			return nil, fmt.Errorf("redis cluster mode requires REDIS_ADDRS")
+		}
+		if rc.DB != 0 {
+			return nil, fmt.Errorf("redis cluster mode only supports database 0")
+		}
+		rdb = redis.NewClusterClient(opts.Cluster())
+	default:
+		return nil, fmt.Errorf("unknown REDIS_MODE %q", rc.Mode)
+	}
 
-	// Test connection
+	// Test connection. Unless Redis is required at startup the app starts
+	// degraded; the client keeps dialling and the circuit breaker around it
+	// fails calls fast until Redis answers.
 	ctx := context.Background()
 	_, err := rdb.Ping(ctx).Result()
 	if err != nil {
-		return nil, fmt.Errorf("failed to connect to Redis: %w", err)
+		if rc.StartupRequired {
+			rdb.Close()
+			return nil, fmt.Errorf("failed to connect to Redis: %w", err)
+		}
+		logger.Warn(ctx, "Redis unavailable, starting degraded", map[string]any{"mode": rc.Mode, "error": err.Error()})
+		return rdb, nil
 	}
 
-	logger.Info(context.Background(), "Redis connected successfully", nil)
+	logger.Info(context.Background(), "Redis connected successfully", map[string]any{"mode": rc.Mode})
 	return rdb, nil
 }
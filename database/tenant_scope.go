@@ -0,0 +1,54 @@
+package database
+
+import (
+	"reflect"
+
+	"go-boilerplate/models"
+	"go-boilerplate/tenant"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// registerTenantScope adds callbacks that restrict every query, row scan,
+// update and delete on a models.TenantScoped model to the tenant in the
+// statement's context (set with db.WithContext). With required set, such
+// statements fail with tenant.ErrRequired unless the context carries a tenant
+// or is marked with tenant.WithoutScope.
+//
+// Inserts and raw SQL are never rewritten: repositories attach new rows to
+// the tenant themselves (see repository.addTenantMemberships), and raw SQL on
+// scoped tables must filter by tenant explicitly.
+func registerTenantScope(db *gorm.DB, required bool) error {
+	scope := func(tx *gorm.DB) {
+		if tx.Error != nil || tx.Statement.Schema == nil {
+			return
+		}
+		scoped, ok := reflect.New(tx.Statement.Schema.ModelType).Interface().(models.TenantScoped)
+		if !ok {
+			return
+		}
+		ctx := tx.Statement.Context
+		if id, ok := tenant.ID(ctx); ok {
+			tx.Statement.AddClause(clause.Where{Exprs: []clause.Expression{
+				clause.Expr{SQL: scoped.TenantCondition(), Vars: []any{id}},
+			}})
+			return
+		}
+		if required && !tenant.IsUnscoped(ctx) {
+			_ = tx.AddError(tenant.ErrRequired)
+		}
+	}
+
+	callbacks := db.Callback()
+	if err := callbacks.Query().Before("gorm:query").Register("tenant:scope", scope); err != nil {
+		return err
+	}
+	if err := callbacks.Row().Before("gorm:row").Register("tenant:scope", scope); err != nil {
+		return err
+	}
+	if err := callbacks.Update().Before("gorm:update").Register("tenant:scope", scope); err != nil {
+		return err
+	}
+	return callbacks.Delete().Before("gorm:delete").Register("tenant:scope", scope)
+}
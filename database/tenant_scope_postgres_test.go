@@ -0,0 +1,102 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+
+	"go-boilerplate/models"
+	"go-boilerplate/repository"
+	"go-boilerplate/tenant"
+	"go-boilerplate/utilities"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// TestTenantIsolationPostgres checks on a real database that users of one
+// organization are invisible to another. It needs an empty scratch database
+// in TEST_DATABASE_DSN, which it migrates, e.g.
+//
+//	TEST_DATABASE_DSN="host=localhost user=postgres password=postgres dbname=app_test sslmode=disable" go test ./database
+func TestTenantIsolationPostgres(t *testing.T) {
+	dsn := os.Getenv("TEST_DATABASE_DSN")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_DSN not set")
+	}
+	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{Logger: logger.Discard, TranslateError: true})
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	if err := migrate(db); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	if err := registerTenantScope(db, true); err != nil {
+		t.Fatalf("register tenant scope: %v", err)
+	}
+
+	unscoped := tenant.WithoutScope(context.Background())
+	suffix := utilities.NewRandomID()[:8]
+	orgA := &models.Organization{Name: "A", Slug: "a-" + suffix}
+	orgB := &models.Organization{Name: "B", Slug: "b-" + suffix}
+	if err := db.WithContext(unscoped).Create([]*models.Organization{orgA, orgB}).Error; err != nil {
+		t.Fatalf("create organizations: %v", err)
+	}
+	ctxA := tenant.WithID(context.Background(), orgA.ID)
+	ctxB := tenant.WithID(context.Background(), orgB.ID)
+
+	users := repository.NewUserRepository(db)
+	alice := &models.User{Name: "Alice", Email: "alice-" + suffix + "@example.com", Password: "x"}
+	bob := &models.User{Name: "Bob", Email: "bob-" + suffix + "@example.com", Password: "x"}
+	if err := users.Create(ctxA, alice); err != nil {
+		t.Fatalf("create alice: %v", err)
+	}
+	if err := users.Create(ctxB, bob); err != nil {
+		t.Fatalf("create bob: %v", err)
+	}
+	t.Cleanup(func() {
+		db.WithContext(unscoped).Unscoped().Delete(&models.User{}, []uint{alice.ID, bob.ID})
+		db.WithContext(unscoped).Unscoped().Delete(&models.Organization{}, []uint{orgA.ID, orgB.ID})
+	})
+
+	if _, err := users.GetByID(ctxA, bob.ID); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Errorf("tenant A found tenant B's user by id: err = %v", err)
+	}
+	if _, err := users.GetByEmail(ctxA, bob.Email); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Errorf("tenant A found tenant B's user by email: err = %v", err)
+	}
+	list, _, err := users.GetAll(ctxA, 0, 1000, nil)
+	if err != nil {
+		t.Fatalf("list: %v", err)
+	}
+	for _, u := range list {
+		if u.ID == bob.ID {
+			t.Errorf("tenant A listed tenant B's user")
+		}
+	}
+
+	stolen := *bob
+	stolen.Name = "Mallory"
+	if err := users.Update(ctxA, &stolen); !errors.Is(err, models.ErrVersionConflict) {
+		t.Errorf("tenant A updated tenant B's user: err = %v", err)
+	}
+	if err := users.Delete(ctxA, bob.ID, bob.Version); !errors.Is(err, models.ErrVersionConflict) {
+		t.Errorf("tenant A deleted tenant B's user: err = %v", err)
+	}
+	current, err := users.GetByID(ctxB, bob.ID)
+	if err != nil {
+		t.Fatalf("tenant B lost its user: %v", err)
+	}
+	if current.Name != "Bob" || current.Version != bob.Version {
+		t.Errorf("tenant B's user was modified: %+v", current)
+	}
+
+	if _, err := users.GetByID(ctxA, alice.ID); err != nil {
+		t.Errorf("tenant A cannot find its own user: %v", err)
+	}
+	if _, err := users.GetByID(context.Background(), alice.ID); !errors.Is(err, tenant.ErrRequired) {
+		t.Errorf("query without tenant: err = %v, want %v", err, tenant.ErrRequired)
+	}
+}
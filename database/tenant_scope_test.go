@@ -0,0 +1,272 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"go-boilerplate/models"
+	"go-boilerplate/repository"
+	"go-boilerplate/tenant"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// These tests run GORM against a driver that records the SQL it is sent and
+// answers every query with no rows and every statement with no affected
+// rows, which is what the database does when the tenant condition excludes
+// the row. tenant_scope_postgres_test.go runs the same checks on PostgreSQL.
+
+const (
+	tenantA = uint(7)
+	userOfB = uint(42)
+)
+
+func TestTenantScopeRestrictsUserRepository(t *testing.T) {
+	ctxA := tenant.WithID(context.Background(), tenantA)
+
+	tests := []struct {
+		name    string
+		run     func(ctx context.Context, db *gorm.DB) error
+		wantErr error
+	}{
+		{
+			name: "find by id",
+			run: func(ctx context.Context, db *gorm.DB) error {
+				_, err := repository.NewUserRepository(db).GetByID(ctx, userOfB)
+				return err
+			},
+			wantErr: gorm.ErrRecordNotFound,
+		},
+		{
+			name: "find by email",
+			run: func(ctx context.Context, db *gorm.DB) error {
+				_, err := repository.NewUserRepository(db).GetByEmail(ctx, "b@example.com")
+				return err
+			},
+			wantErr: gorm.ErrRecordNotFound,
+		},
+		{
+			name: "list",
+			run: func(ctx context.Context, db *gorm.DB) error {
+				_, _, err := repository.NewUserRepository(db).GetAll(ctx, 0, 10, nil)
+				return err
+			},
+		},
+		{
+			name: "update",
+			run: func(ctx context.Context, db *gorm.DB) error {
+				user := &models.User{BaseModel: models.BaseModel{ID: userOfB, Version: 1}, Name: "Mallory"}
+				return repository.NewUserRepository(db).Update(ctx, user)
+			},
+			wantErr: models.ErrVersionConflict,
+		},
+		{
+			name: "delete",
+			run: func(ctx context.Context, db *gorm.DB) error {
+				return repository.NewUserRepository(db).Delete(ctx, userOfB, 1)
+			},
+			wantErr: models.ErrVersionConflict,
+		},
+		{
+			name: "activity",
+			run: func(ctx context.Context, db *gorm.DB) error {
+				return repository.NewUserRepository(db).SetLastSeen(ctx, userOfB, db.NowFunc())
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, rec := newRecordingDB(t, false)
+			if err := tt.run(ctxA, db); !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			queries := rec.statements()
+			if len(queries) == 0 {
+				t.Fatal("no statement was sent")
+			}
+			for _, q := range queries {
+				assertScopedTo(t, q, tenantA)
+			}
+		})
+	}
+}
+
+func TestTenantScopeRequired(t *testing.T) {
+	db, rec := newRecordingDB(t, true)
+	users := repository.NewUserRepository(db)
+
+	_, err := users.GetByID(context.Background(), userOfB)
+	if !errors.Is(err, tenant.ErrRequired) {
+		t.Fatalf("query without tenant: err = %v, want %v", err, tenant.ErrRequired)
+	}
+	if err := users.Delete(context.Background(), userOfB, 1); !errors.Is(err, tenant.ErrRequired) {
+		t.Fatalf("delete without tenant: err = %v, want %v", err, tenant.ErrRequired)
+	}
+	if n := len(rec.statements()); n != 0 {
+		t.Fatalf("%d statements reached the database without a tenant", n)
+	}
+
+	// Deliberately unscoped work, such as login by email, still runs.
+	_, err = users.GetByEmail(tenant.WithoutScope(context.Background()), "b@example.com")
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Fatalf("unscoped query: err = %v, want %v", err, gorm.ErrRecordNotFound)
+	}
+	queries := rec.statements()
+	if len(queries) != 1 || strings.Contains(queries[0].sql, "memberships") {
+		t.Fatalf("unscoped query was scoped: %v", queries)
+	}
+}
+
+// Inserts and raw SQL are not rewritten by the scope: new rows are attached
+// to the tenant by the repository (see addTenantMemberships), and raw SQL
+// must filter by tenant itself.
+func TestTenantScopeLeavesCreateAndRawSQL(t *testing.T) {
+	ctxA := tenant.WithID(context.Background(), tenantA)
+
+	t.Run("create", func(t *testing.T) {
+		db, rec := newRecordingDB(t, true)
+		user := &models.User{Name: "Alice", Email: "a@example.com", Password: "x"}
+		if err := db.WithContext(ctxA).Create(user).Error; err != nil {
+			t.Fatalf("create: %v", err)
+		}
+		for _, q := range rec.statements() {
+			if strings.Contains(q.sql, "memberships") {
+				t.Fatalf("insert was rewritten: %s", q.sql)
+			}
+		}
+	})
+
+	t.Run("raw", func(t *testing.T) {
+		db, rec := newRecordingDB(t, true)
+		var ids []uint
+		if err := db.WithContext(ctxA).Raw("SELECT id FROM users").Scan(&ids).Error; err != nil {
+			t.Fatalf("raw: %v", err)
+		}
+		queries := rec.statements()
+		if len(queries) != 1 || queries[0].sql != "SELECT id FROM users" {
+			t.Fatalf("raw SQL was rewritten: %v", queries)
+		}
+	})
+}
+
+// placeholderAfter matches the parameter that follows the tenant condition's
+// organization_id comparison.
+var placeholderAfter = regexp.MustCompile(`organization_id = \$(\d+)`)
+
+// assertScopedTo checks that q carries the users tenant condition bound to id.
+func assertScopedTo(t *testing.T, q recordedStatement, id uint) {
+	t.Helper()
+	condition := strings.Split(models.User{}.TenantCondition(), " = ?")[0]
+	if !strings.Contains(q.sql, condition) {
+		t.Fatalf("statement is not scoped to a tenant: %s", q.sql)
+	}
+	m := placeholderAfter.FindStringSubmatch(q.sql)
+	if m == nil {
+		t.Fatalf("tenant condition has no parameter: %s", q.sql)
+	}
+	n, _ := strconv.Atoi(m[1])
+	if n < 1 || n > len(q.args) {
+		t.Fatalf("parameter $%d missing from %v", n, q.args)
+	}
+	if got := q.args[n-1].Value; got != id {
+		t.Fatalf("statement scoped to tenant %v, want %d: %s", got, id, q.sql)
+	}
+}
+
+func newRecordingDB(t *testing.T, required bool) (*gorm.DB, *statementRecorder) {
+	t.Helper()
+	rec := &statementRecorder{}
+	db, err := gorm.Open(postgres.New(postgres.Config{Conn: sql.OpenDB(recordingConnector{rec: rec})}), &gorm.Config{
+		Logger:               logger.Discard,
+		DisableAutomaticPing: true,
+	})
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	if err := registerTenantScope(db, required); err != nil {
+		t.Fatalf("register tenant scope: %v", err)
+	}
+	return db, rec
+}
+
+type recordedStatement struct {
+	sql  string
+	args []driver.NamedValue
+}
+
+type statementRecorder struct {
+	mu       sync.Mutex
+	recorded []recordedStatement
+}
+
+func (r *statementRecorder) record(query string, args []driver.NamedValue) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.recorded = append(r.recorded, recordedStatement{sql: query, args: args})
+}
+
+func (r *statementRecorder) statements() []recordedStatement {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]recordedStatement(nil), r.recorded...)
+}
+
+type recordingConnector struct{ rec *statementRecorder }
+
+func (c recordingConnector) Connect(context.Context) (driver.Conn, error) {
+	return &recordingConn{rec: c.rec}, nil
+}
+
+func (c recordingConnector) Driver() driver.Driver { return recordingDriver{} }
+
+type recordingDriver struct{}
+
+func (recordingDriver) Open(string) (driver.Conn, error) {
+	return nil, errors.New("recording driver: open through the connector")
+}
+
+// recordingConn implements the context-aware interfaces, so database/sql
+// never prepares statements.
+type recordingConn struct{ rec *statementRecorder }
+
+func (c *recordingConn) Prepare(string) (driver.Stmt, error) {
+	return nil, errors.New("recording driver: prepare not supported")
+}
+
+func (c *recordingConn) Close() error { return nil }
+
+func (c *recordingConn) Begin() (driver.Tx, error) { return recordingTx{}, nil }
+
+func (c *recordingConn) CheckNamedValue(*driver.NamedValue) error { return nil }
+
+func (c *recordingConn) QueryContext(_ context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	c.rec.record(query, args)
+	return emptyRows{}, nil
+}
+
+func (c *recordingConn) ExecContext(_ context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	c.rec.record(query, args)
+	return driver.RowsAffected(0), nil
+}
+
+type recordingTx struct{}
+
+func (recordingTx) Commit() error   { return nil }
+func (recordingTx) Rollback() error { return nil }
+
+type emptyRows struct{}
+
+func (emptyRows) Columns() []string         { return nil }
+func (emptyRows) Close() error              { return nil }
+func (emptyRows) Next([]driver.Value) error { return io.EOF }
@@ -0,0 +1,89 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"go-boilerplate/logger"
+	"go-boilerplate/models/request"
+	"go-boilerplate/models/response"
+	"go-boilerplate/services/interfaces"
+	"go-boilerplate/utilities"
+
+	"github.com/gin-gonic/gin"
+)
+
+type AuditHandler struct {
+	auditService interfaces.AuditService
+}
+
+func NewAuditHandler(auditService interfaces.AuditService) *AuditHandler {
+	return &AuditHandler{auditService: auditService}
+}
+
+// ListEvents returns a page of audit events, newest first, filtered by query parameters.
+func (h *AuditHandler) ListEvents(c *gin.Context) {
+	ctx := c.Request.Context()
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	perPage, _ := strconv.Atoi(c.DefaultQuery("per_page", "10"))
+
+	var filter request.AuditFilter
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		c.JSON(http.StatusBadRequest, response.BaseResponse{
+			Success: false,
+			Message: "Invalid query parameters",
+			Error:   err.Error(),
+		})
+		return
+	}
+	if err := utilities.ValidateStruct(&filter); err != nil {
+		c.JSON(http.StatusBadRequest, response.BaseResponse{
+			Success: false,
+			Message: "Validation failed",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	events, err := h.auditService.Search(ctx, page, perPage, &filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, response.BaseResponse{
+			Success: false,
+			Message: "Failed to retrieve audit events",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, response.BaseResponse{
+		Success: true,
+		Message: "Audit events retrieved successfully",
+		Data:    events,
+	})
+}
+
+// VerifyChain checks the audit log's hash chain for tampering.
+func (h *AuditHandler) VerifyChain(c *gin.Context) {
+	ctx := c.Request.Context()
+	logger.Info(ctx, "VerifyChain request received", nil)
+
+	result, err := h.auditService.VerifyChain(ctx)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, response.BaseResponse{
+			Success: false,
+			Message: "Failed to verify audit log",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	message := "Audit log is intact"
+	if !result.Valid {
+		message = "Audit log has been tampered with"
+	}
+	c.JSON(http.StatusOK, response.BaseResponse{
+		Success: true,
+		Message: message,
+		Data:    result,
+	})
+}
@@ -1,9 +1,12 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
+	"strconv"
 
 	"go-boilerplate/logger"
+	"go-boilerplate/models"
 	"go-boilerplate/models/request"
 	"go-boilerplate/models/response"
 	"go-boilerplate/services/interfaces"
@@ -44,8 +47,17 @@ func (h *AuthHandler) Register(c *gin.Context) {
 		return
 	}
 
-	user, err := h.userService.CreateUser(&req)
+	user, err := h.userService.CreateUser(ctx, &req)
 	if err != nil {
+		if errors.Is(err, models.ErrEmailTaken) {
+			logger.Warn(ctx, "Register: email already exists", map[string]any{"email": req.Email})
+			c.JSON(http.StatusConflict, response.BaseResponse{
+				Success: false,
+				Message: "Failed to register user",
+				Error:   err.Error(),
+			})
+			return
+		}
 		logger.Error(ctx, "Register failed", map[string]any{"email": req.Email, "error": err.Error()})
 		c.JSON(http.StatusInternalServerError, response.BaseResponse{
 			Success: false,
@@ -87,10 +99,15 @@ func (h *AuthHandler) Login(c *gin.Context) {
 		return
 	}
 
-	loginResponse, err := h.userService.Login(&req)
+	client := models.ClientInfo{IP: c.ClientIP(), UserAgent: c.Request.UserAgent()}
+	loginResponse, err := h.userService.Login(ctx, &req, client)
 	if err != nil {
 		logger.Warn(ctx, "Login failed", map[string]any{"email": req.Email, "error": err.Error()})
-		c.JSON(http.StatusUnauthorized, response.BaseResponse{
+		status := http.StatusUnauthorized
+		if errors.Is(err, models.ErrUnavailable) {
+			status = http.StatusServiceUnavailable
+		}
+		c.JSON(status, response.BaseResponse{
 			Success: false,
 			Message: "Login failed",
 			Error:   err.Error(),
@@ -129,9 +146,13 @@ func (h *AuthHandler) Logout(c *gin.Context) {
 		return
 	}
 
-	if err := h.userService.Logout(userID); err != nil {
+	if err := h.userService.Logout(ctx, userID); err != nil {
 		logger.Error(ctx, "Logout failed", map[string]any{"user_id": userID, "error": err.Error()})
-		c.JSON(http.StatusInternalServerError, response.BaseResponse{
+		status := http.StatusInternalServerError
+		if errors.Is(err, models.ErrUnavailable) {
+			status = http.StatusServiceUnavailable
+		}
+		c.JSON(status, response.BaseResponse{
 			Success: false,
 			Message: "Failed to logout",
 			Error:   err.Error(),
@@ -169,7 +190,7 @@ func (h *AuthHandler) Me(c *gin.Context) {
 		return
 	}
 
-	user, err := h.userService.GetUserByID(userID)
+	user, err := h.userService.GetUserByID(ctx, userID)
 	if err != nil {
 		logger.Warn(ctx, "Me: user not found", map[string]any{"user_id": userID, "error": err.Error()})
 		c.JSON(http.StatusNotFound, response.BaseResponse{
@@ -187,3 +208,69 @@ func (h *AuthHandler) Me(c *gin.Context) {
 		Data:    user,
 	})
 }
+
+func (h *AuthHandler) ConfirmEmail(c *gin.Context) {
+	ctx := c.Request.Context()
+	logger.Info(ctx, "ConfirmEmail request received", nil)
+	token := c.Query("token")
+	if token == "" {
+		c.JSON(http.StatusBadRequest, response.BaseResponse{
+			Success: false,
+			Message: "Token is required",
+		})
+		return
+	}
+
+	user, err := h.userService.ConfirmEmailChange(ctx, token)
+	if err != nil {
+		status := http.StatusInternalServerError
+		switch {
+		case errors.Is(err, models.ErrInvalidToken):
+			status = http.StatusBadRequest
+		case errors.Is(err, models.ErrUserNotFound):
+			status = http.StatusNotFound
+		case errors.Is(err, models.ErrEmailTaken):
+			status = http.StatusConflict
+		}
+		logger.Warn(ctx, "ConfirmEmail failed", map[string]any{"error": err.Error()})
+		c.JSON(status, response.BaseResponse{
+			Success: false,
+			Message: "Failed to confirm email change",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	logger.Info(ctx, "ConfirmEmail successful", map[string]any{"user_id": user.ID})
+	c.JSON(http.StatusOK, response.BaseResponse{
+		Success: true,
+		Message: "Email address updated successfully",
+		Data:    user,
+	})
+}
+
+// LoginHistory lists the current user's login attempts, newest first.
+func (h *AuthHandler) LoginHistory(c *gin.Context) {
+	ctx := c.Request.Context()
+	userID := c.GetUint("user_id")
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	perPage, _ := strconv.Atoi(c.DefaultQuery("per_page", "10"))
+	logger.Info(ctx, "LoginHistory request received", map[string]any{"user_id": userID, "page": page, "per_page": perPage})
+
+	history, err := h.userService.GetLoginHistory(ctx, userID, page, perPage)
+	if err != nil {
+		logger.Error(ctx, "LoginHistory failed", map[string]any{"user_id": userID, "error": err.Error()})
+		c.JSON(http.StatusInternalServerError, response.BaseResponse{
+			Success: false,
+			Message: "Failed to retrieve login history",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, response.BaseResponse{
+		Success: true,
+		Message: "Login history retrieved successfully",
+		Data:    history,
+	})
+}
@@ -0,0 +1,148 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"go-boilerplate/logger"
+	"go-boilerplate/models"
+	"go-boilerplate/models/response"
+	"go-boilerplate/utilities"
+
+	"github.com/gin-gonic/gin"
+)
+
+// UpdateAvatar replaces a user's profile picture. The image is sent either as
+// the raw request body or as the "file" part of a multipart/form-data body.
+func (h *UserHandler) UpdateAvatar(c *gin.Context) {
+	ctx := c.Request.Context()
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, response.BaseResponse{
+			Success: false,
+			Message: "Invalid user ID",
+		})
+		return
+	}
+	logger.Info(ctx, "UpdateAvatar request received", map[string]any{"user_id": id, "content_length": c.Request.ContentLength})
+
+	versions, ok := ifMatchVersions(c, uint(id))
+	if !ok {
+		return
+	}
+
+	var body io.ReadCloser = c.Request.Body
+	if strings.HasPrefix(c.ContentType(), "multipart/") {
+		if body, err = formFilePart(c, "file"); err != nil {
+			logger.Warn(ctx, "UpdateAvatar: invalid multipart body", map[string]any{"error": err.Error()})
+			c.JSON(http.StatusBadRequest, response.BaseResponse{
+				Success: false,
+				Message: "Invalid multipart body",
+				Error:   err.Error(),
+			})
+			return
+		}
+		defer body.Close()
+	}
+
+	user, err := h.userService.SetAvatar(ctx, uint(id), versions, body)
+	if err != nil {
+		status := avatarErrorStatus(err)
+		logger.Warn(ctx, "UpdateAvatar failed", map[string]any{"user_id": id, "status": status, "error": err.Error()})
+		c.JSON(status, response.BaseResponse{
+			Success: false,
+			Message: "Failed to update avatar",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	c.Header("ETag", utilities.VersionETag(user.ID, user.Version))
+	c.JSON(http.StatusOK, response.BaseResponse{
+		Success: true,
+		Message: "Avatar updated successfully",
+		Data:    user,
+	})
+}
+
+func (h *UserHandler) DeleteAvatar(c *gin.Context) {
+	ctx := c.Request.Context()
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, response.BaseResponse{
+			Success: false,
+			Message: "Invalid user ID",
+		})
+		return
+	}
+	logger.Info(ctx, "DeleteAvatar request received", map[string]any{"user_id": id})
+
+	versions, ok := ifMatchVersions(c, uint(id))
+	if !ok {
+		return
+	}
+
+	user, err := h.userService.RemoveAvatar(ctx, uint(id), versions)
+	if err != nil {
+		status := avatarErrorStatus(err)
+		c.JSON(status, response.BaseResponse{
+			Success: false,
+			Message: "Failed to delete avatar",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	c.Header("ETag", utilities.VersionETag(user.ID, user.Version))
+	c.JSON(http.StatusOK, response.BaseResponse{
+		Success: true,
+		Message: "Avatar deleted successfully",
+		Data:    user,
+	})
+}
+
+// GetAvatar serves an avatar thumbnail. Avatar keys are never reused, so the
+// response can be cached indefinitely.
+func (h *UserHandler) GetAvatar(c *gin.Context) {
+	key := models.AvatarKeyPrefix + strings.TrimPrefix(c.Param("key"), "/")
+	rc, info, err := h.userService.OpenAvatar(c.Request.Context(), key)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, models.ErrNotFound) {
+			status = http.StatusNotFound
+		}
+		c.JSON(status, response.BaseResponse{
+			Success: false,
+			Message: "Failed to get avatar",
+			Error:   err.Error(),
+		})
+		return
+	}
+	defer rc.Close()
+
+	c.DataFromReader(http.StatusOK, info.Size, info.ContentType, rc, map[string]string{
+		"Cache-Control":          "public, max-age=31536000, immutable",
+		"X-Content-Type-Options": "nosniff",
+	})
+}
+
+// avatarErrorStatus maps avatar write errors to HTTP status codes.
+func avatarErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, models.ErrUserNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, models.ErrVersionConflict):
+		return http.StatusPreconditionFailed
+	case errors.Is(err, models.ErrUnsupportedMediaType):
+		return http.StatusUnsupportedMediaType
+	case errors.Is(err, models.ErrFileTooLarge):
+		return http.StatusRequestEntityTooLarge
+	case errors.Is(err, models.ErrInvalidImage):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,28 @@
+package handlers
+
+import (
+	"net/http"
+
+	"go-boilerplate/cache"
+	"go-boilerplate/models/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+type CacheHandler struct {
+	manager *cache.Manager
+}
+
+func NewCacheHandler(manager *cache.Manager) *CacheHandler {
+	return &CacheHandler{manager: manager}
+}
+
+// Stats returns the hit, miss and eviction counters of each cache tier on
+// this instance since it started.
+func (h *CacheHandler) Stats(c *gin.Context) {
+	c.JSON(http.StatusOK, response.BaseResponse{
+		Success: true,
+		Message: "Cache statistics retrieved successfully",
+		Data:    h.manager.Stats(),
+	})
+}
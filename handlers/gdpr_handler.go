@@ -0,0 +1,122 @@
+package handlers
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"go-boilerplate/logger"
+	"go-boilerplate/models"
+	"go-boilerplate/models/response"
+	"go-boilerplate/services/interfaces"
+
+	"github.com/gin-gonic/gin"
+)
+
+type GDPRHandler struct {
+	gdprService interfaces.GDPRService
+}
+
+func NewGDPRHandler(gdprService interfaces.GDPRService) *GDPRHandler {
+	return &GDPRHandler{gdprService: gdprService}
+}
+
+// DataExport returns a ZIP archive with all data held about the user.
+func (h *GDPRHandler) DataExport(c *gin.Context) {
+	ctx := c.Request.Context()
+	id, ok := gdprUserID(c)
+	if !ok {
+		return
+	}
+	logger.Info(ctx, "DataExport request received", map[string]any{"user_id": id})
+
+	// The archive is built in memory so that failures still produce a proper error response.
+	var buf bytes.Buffer
+	if err := h.gdprService.ExportUserData(ctx, gdprActor(c), id, &buf); err != nil {
+		gdprError(c, "Failed to export user data", err)
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="user-%d-data.zip"`, id))
+	c.Header("Cache-Control", "no-store")
+	c.Data(http.StatusOK, "application/zip", buf.Bytes())
+}
+
+// RequestErasure schedules the user's personal data for anonymization.
+func (h *GDPRHandler) RequestErasure(c *gin.Context) {
+	ctx := c.Request.Context()
+	id, ok := gdprUserID(c)
+	if !ok {
+		return
+	}
+	logger.Info(ctx, "RequestErasure request received", map[string]any{"user_id": id})
+
+	user, err := h.gdprService.RequestErasure(ctx, gdprActor(c), id)
+	if err != nil {
+		gdprError(c, "Failed to request erasure", err)
+		return
+	}
+
+	c.JSON(http.StatusAccepted, response.BaseResponse{
+		Success: true,
+		Message: "Erasure scheduled",
+		Data:    user,
+	})
+}
+
+// CancelErasure withdraws a pending erasure request during its grace period.
+func (h *GDPRHandler) CancelErasure(c *gin.Context) {
+	ctx := c.Request.Context()
+	id, ok := gdprUserID(c)
+	if !ok {
+		return
+	}
+	logger.Info(ctx, "CancelErasure request received", map[string]any{"user_id": id})
+
+	user, err := h.gdprService.CancelErasure(ctx, gdprActor(c), id)
+	if err != nil {
+		gdprError(c, "Failed to cancel erasure", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response.BaseResponse{
+		Success: true,
+		Message: "Erasure request cancelled",
+		Data:    user,
+	})
+}
+
+func gdprUserID(c *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, response.BaseResponse{
+			Success: false,
+			Message: "Invalid user ID",
+		})
+		return 0, false
+	}
+	return uint(id), true
+}
+
+// gdprActor identifies the authenticated caller for the audit log.
+func gdprActor(c *gin.Context) models.Actor {
+	return models.Actor{UserID: c.GetUint("user_id"), IP: c.ClientIP()}
+}
+
+func gdprError(c *gin.Context, message string, err error) {
+	status := http.StatusInternalServerError
+	switch {
+	case errors.Is(err, models.ErrUserNotFound), errors.Is(err, models.ErrNotFound):
+		status = http.StatusNotFound
+	case errors.Is(err, models.ErrVersionConflict):
+		status = http.StatusConflict
+	}
+	logger.Warn(c.Request.Context(), message, map[string]any{"status": status, "error": err.Error()})
+	c.JSON(status, response.BaseResponse{
+		Success: false,
+		Message: message,
+		Error:   err.Error(),
+	})
+}
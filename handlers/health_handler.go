@@ -4,28 +4,43 @@ import (
 	"net/http"
 	"time"
 
+	"go-boilerplate/circuit"
 	"go-boilerplate/logger"
 	"go-boilerplate/models/response"
 
 	"github.com/gin-gonic/gin"
 )
 
-type HealthHandler struct{}
+type HealthHandler struct {
+	redisBreaker *circuit.Breaker
+}
 
-func NewHealthHandler() *HealthHandler {
-	return &HealthHandler{}
+func NewHealthHandler(redisBreaker *circuit.Breaker) *HealthHandler {
+	return &HealthHandler{redisBreaker: redisBreaker}
 }
 
+// Check reports "degraded" while the Redis circuit breaker is not closed.
+// The app still serves requests then, so the status code stays 200.
 func (h *HealthHandler) Check(c *gin.Context) {
 	ctx := c.Request.Context()
-	logger.Info(ctx, "Health check", nil)
+	redisState := h.redisBreaker.State()
+	status, message := "ok", "Service is healthy"
+	if redisState != circuit.Closed {
+		status, message = "degraded", "Service is degraded"
+		logger.Warn(ctx, "Health check degraded", map[string]any{"redis": redisState.String()})
+	} else {
+		logger.Info(ctx, "Health check", nil)
+	}
 	c.JSON(http.StatusOK, response.BaseResponse{
 		Success: true,
-		Message: "Service is healthy",
+		Message: message,
 		Data: gin.H{
-			"status":    "ok",
+			"status":    status,
 			"timestamp": time.Now(),
 			"service":   "go-boilerplate",
+			"dependencies": gin.H{
+				"redis": redisState.String(),
+			},
 		},
 	})
 }
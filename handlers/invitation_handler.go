@@ -0,0 +1,189 @@
+package handlers
+
+import (
+	"net/http"
+
+	"go-boilerplate/logger"
+	"go-boilerplate/models/request"
+	"go-boilerplate/models/response"
+	"go-boilerplate/services/interfaces"
+	"go-boilerplate/utilities"
+
+	"github.com/gin-gonic/gin"
+)
+
+type InvitationHandler struct {
+	invitationService interfaces.InvitationService
+}
+
+func NewInvitationHandler(invitationService interfaces.InvitationService) *InvitationHandler {
+	return &InvitationHandler{invitationService: invitationService}
+}
+
+// CreateInvitation invites an email address to the organization and emails it a link.
+func (h *InvitationHandler) CreateInvitation(c *gin.Context) {
+	ctx := c.Request.Context()
+	orgID, ok := uintParam(c, "id", "Invalid organization ID")
+	if !ok {
+		return
+	}
+	logger.Info(ctx, "CreateInvitation request received", map[string]any{"organization_id": orgID})
+
+	var req request.CreateInvitationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, response.BaseResponse{
+			Success: false,
+			Message: "Invalid request body",
+			Error:   err.Error(),
+		})
+		return
+	}
+	if err := utilities.ValidateStruct(&req); err != nil {
+		c.JSON(http.StatusBadRequest, response.BaseResponse{
+			Success: false,
+			Message: "Validation failed",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	invitation, err := h.invitationService.CreateInvitation(ctx, orgID, c.GetUint("user_id"), &req)
+	if err != nil {
+		c.JSON(organizationErrorStatus(err), response.BaseResponse{
+			Success: false,
+			Message: "Failed to create invitation",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, response.BaseResponse{
+		Success: true,
+		Message: "Invitation sent successfully",
+		Data:    invitation,
+	})
+}
+
+func (h *InvitationHandler) ListInvitations(c *gin.Context) {
+	orgID, ok := uintParam(c, "id", "Invalid organization ID")
+	if !ok {
+		return
+	}
+
+	invitations, err := h.invitationService.ListPendingInvitations(c.Request.Context(), orgID, c.GetUint("user_id"))
+	if err != nil {
+		c.JSON(organizationErrorStatus(err), response.BaseResponse{
+			Success: false,
+			Message: "Failed to list invitations",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, response.BaseResponse{
+		Success: true,
+		Message: "Invitations retrieved successfully",
+		Data:    invitations,
+	})
+}
+
+func (h *InvitationHandler) RevokeInvitation(c *gin.Context) {
+	ctx := c.Request.Context()
+	orgID, ok := uintParam(c, "id", "Invalid organization ID")
+	if !ok {
+		return
+	}
+	invitationID, ok := uintParam(c, "invitation_id", "Invalid invitation ID")
+	if !ok {
+		return
+	}
+	logger.Info(ctx, "RevokeInvitation request received", map[string]any{"organization_id": orgID, "invitation_id": invitationID})
+
+	if err := h.invitationService.RevokeInvitation(ctx, orgID, invitationID, c.GetUint("user_id")); err != nil {
+		c.JSON(organizationErrorStatus(err), response.BaseResponse{
+			Success: false,
+			Message: "Failed to revoke invitation",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, response.BaseResponse{
+		Success: true,
+		Message: "Invitation revoked successfully",
+	})
+}
+
+// PreviewInvitation describes the invitation behind the emailed link, so a
+// client can ask for a name and password when no account exists yet.
+func (h *InvitationHandler) PreviewInvitation(c *gin.Context) {
+	token := c.Query("token")
+	if token == "" {
+		c.JSON(http.StatusBadRequest, response.BaseResponse{
+			Success: false,
+			Message: "Token is required",
+		})
+		return
+	}
+
+	invitation, err := h.invitationService.PreviewInvitation(c.Request.Context(), token)
+	if err != nil {
+		c.JSON(organizationErrorStatus(err), response.BaseResponse{
+			Success: false,
+			Message: "Failed to get invitation",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, response.BaseResponse{
+		Success: true,
+		Message: "Invitation retrieved successfully",
+		Data:    invitation,
+	})
+}
+
+// AcceptInvitation accepts an invitation, creating an account for the invited
+// address if it has none.
+func (h *InvitationHandler) AcceptInvitation(c *gin.Context) {
+	ctx := c.Request.Context()
+	logger.Info(ctx, "AcceptInvitation request received", nil)
+
+	var req request.AcceptInvitationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, response.BaseResponse{
+			Success: false,
+			Message: "Invalid request body",
+			Error:   err.Error(),
+		})
+		return
+	}
+	if err := utilities.ValidateStruct(&req); err != nil {
+		c.JSON(http.StatusBadRequest, response.BaseResponse{
+			Success: false,
+			Message: "Validation failed",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	accepted, err := h.invitationService.AcceptInvitation(ctx, &req)
+	if err != nil {
+		c.JSON(organizationErrorStatus(err), response.BaseResponse{
+			Success: false,
+			Message: "Failed to accept invitation",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	status := http.StatusOK
+	if accepted.AccountCreated {
+		status = http.StatusCreated
+	}
+	c.JSON(status, response.BaseResponse{
+		Success: true,
+		Message: "Invitation accepted successfully",
+		Data:    accepted,
+	})
+}
@@ -0,0 +1,174 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"go-boilerplate/logger"
+	"go-boilerplate/models"
+	"go-boilerplate/models/request"
+	"go-boilerplate/models/response"
+	"go-boilerplate/services/interfaces"
+	"go-boilerplate/utilities"
+
+	"github.com/gin-gonic/gin"
+)
+
+type OrganizationHandler struct {
+	orgService interfaces.OrganizationService
+}
+
+func NewOrganizationHandler(orgService interfaces.OrganizationService) *OrganizationHandler {
+	return &OrganizationHandler{orgService: orgService}
+}
+
+// CreateOrganization creates an organization owned by the authenticated user.
+func (h *OrganizationHandler) CreateOrganization(c *gin.Context) {
+	ctx := c.Request.Context()
+	userID := c.GetUint("user_id")
+	logger.Info(ctx, "CreateOrganization request received", map[string]any{"user_id": userID})
+
+	var req request.CreateOrganizationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, response.BaseResponse{
+			Success: false,
+			Message: "Invalid request body",
+			Error:   err.Error(),
+		})
+		return
+	}
+	if err := utilities.ValidateStruct(&req); err != nil {
+		c.JSON(http.StatusBadRequest, response.BaseResponse{
+			Success: false,
+			Message: "Validation failed",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	org, err := h.orgService.CreateOrganization(ctx, userID, &req)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, models.ErrSlugTaken) {
+			status = http.StatusConflict
+		}
+		c.JSON(status, response.BaseResponse{
+			Success: false,
+			Message: "Failed to create organization",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, response.BaseResponse{
+		Success: true,
+		Message: "Organization created successfully",
+		Data:    org,
+	})
+}
+
+// ListOrganizations lists the organizations the authenticated user belongs to.
+func (h *OrganizationHandler) ListOrganizations(c *gin.Context) {
+	orgs, err := h.orgService.ListOrganizations(c.Request.Context(), c.GetUint("user_id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, response.BaseResponse{
+			Success: false,
+			Message: "Failed to list organizations",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, response.BaseResponse{
+		Success: true,
+		Message: "Organizations retrieved successfully",
+		Data:    orgs,
+	})
+}
+
+// IssueToken returns a session token bound to the organization, so that later
+// requests act for it without sending the tenant header.
+func (h *OrganizationHandler) IssueToken(c *gin.Context) {
+	ctx := c.Request.Context()
+	orgID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, response.BaseResponse{
+			Success: false,
+			Message: "Invalid organization ID",
+		})
+		return
+	}
+
+	token, err := h.orgService.IssueTenantToken(ctx, uint(orgID), c.GetUint("user_id"))
+	if err != nil {
+		c.JSON(organizationErrorStatus(err), response.BaseResponse{
+			Success: false,
+			Message: "Failed to issue token",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, response.BaseResponse{
+		Success: true,
+		Message: "Token issued successfully",
+		Data:    token,
+	})
+}
+
+// ListMembers lists the members of an organization the caller belongs to.
+func (h *OrganizationHandler) ListMembers(c *gin.Context) {
+	orgID, ok := uintParam(c, "id", "Invalid organization ID")
+	if !ok {
+		return
+	}
+
+	members, err := h.orgService.ListMembers(c.Request.Context(), orgID, c.GetUint("user_id"))
+	if err != nil {
+		c.JSON(organizationErrorStatus(err), response.BaseResponse{
+			Success: false,
+			Message: "Failed to list members",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, response.BaseResponse{
+		Success: true,
+		Message: "Members retrieved successfully",
+		Data:    members,
+	})
+}
+
+// uintParam parses a numeric path parameter, writing 400 with message if it is invalid.
+func uintParam(c *gin.Context, name, message string) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param(name), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, response.BaseResponse{
+			Success: false,
+			Message: message,
+		})
+		return 0, false
+	}
+	return uint(id), true
+}
+
+// organizationErrorStatus maps errors from organization, team and invitation
+// management to HTTP status codes.
+func organizationErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, models.ErrNotFound), errors.Is(err, models.ErrUserNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, models.ErrForbidden):
+		return http.StatusForbidden
+	case errors.Is(err, models.ErrTeamExists), errors.Is(err, models.ErrAlreadyMember), errors.Is(err, models.ErrEmailTaken):
+		return http.StatusConflict
+	case errors.Is(err, models.ErrInvalidToken), errors.Is(err, models.ErrAccountDetailsRequired), errors.Is(err, models.ErrURLNotAllowed):
+		return http.StatusBadRequest
+	case errors.Is(err, models.ErrUnavailable):
+		return http.StatusServiceUnavailable
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,87 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"go-boilerplate/logger"
+	"go-boilerplate/models"
+	"go-boilerplate/models/response"
+	"go-boilerplate/services/interfaces"
+
+	"github.com/gin-gonic/gin"
+)
+
+type SettingsHandler struct {
+	settingsService interfaces.SettingsService
+}
+
+func NewSettingsHandler(settingsService interfaces.SettingsService) *SettingsHandler {
+	return &SettingsHandler{settingsService: settingsService}
+}
+
+// GetSettings returns the authenticated user's settings with defaults filled in.
+func (h *SettingsHandler) GetSettings(c *gin.Context) {
+	ctx := c.Request.Context()
+	userID := c.GetUint("user_id")
+	logger.Info(ctx, "GetSettings request received", map[string]any{"user_id": userID})
+
+	settings, err := h.settingsService.Get(ctx, userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, response.BaseResponse{
+			Success: false,
+			Message: "Failed to get settings",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, response.BaseResponse{
+		Success: true,
+		Message: "Settings retrieved successfully",
+		Data:    settings,
+	})
+}
+
+// UpdateSettings merges a JSON object of settings into the stored ones
+// (RFC 7396 merge patch); a null value resets a setting to its default.
+func (h *SettingsHandler) UpdateSettings(c *gin.Context) {
+	ctx := c.Request.Context()
+	userID := c.GetUint("user_id")
+	logger.Info(ctx, "UpdateSettings request received", map[string]any{"user_id": userID})
+
+	var patch map[string]any
+	if err := c.ShouldBindJSON(&patch); err != nil || patch == nil {
+		message := "request body must be a JSON object"
+		if err != nil {
+			message = err.Error()
+		}
+		logger.Warn(ctx, "UpdateSettings: invalid request body", map[string]any{"error": message})
+		c.JSON(http.StatusBadRequest, response.BaseResponse{
+			Success: false,
+			Message: "Invalid request body",
+			Error:   message,
+		})
+		return
+	}
+
+	settings, err := h.settingsService.Update(ctx, userID, patch)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, models.ErrInvalidSetting) {
+			status = http.StatusBadRequest
+		}
+		c.JSON(status, response.BaseResponse{
+			Success: false,
+			Message: "Failed to update settings",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, response.BaseResponse{
+		Success: true,
+		Message: "Settings updated successfully",
+		Data:    settings,
+	})
+}
@@ -0,0 +1,161 @@
+package handlers
+
+import (
+	"net/http"
+
+	"go-boilerplate/logger"
+	"go-boilerplate/models/request"
+	"go-boilerplate/models/response"
+	"go-boilerplate/services/interfaces"
+	"go-boilerplate/utilities"
+
+	"github.com/gin-gonic/gin"
+)
+
+type TeamHandler struct {
+	teamService interfaces.TeamService
+}
+
+func NewTeamHandler(teamService interfaces.TeamService) *TeamHandler {
+	return &TeamHandler{teamService: teamService}
+}
+
+func (h *TeamHandler) CreateTeam(c *gin.Context) {
+	ctx := c.Request.Context()
+	orgID, ok := uintParam(c, "id", "Invalid organization ID")
+	if !ok {
+		return
+	}
+	logger.Info(ctx, "CreateTeam request received", map[string]any{"organization_id": orgID})
+
+	var req request.CreateTeamRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, response.BaseResponse{
+			Success: false,
+			Message: "Invalid request body",
+			Error:   err.Error(),
+		})
+		return
+	}
+	if err := utilities.ValidateStruct(&req); err != nil {
+		c.JSON(http.StatusBadRequest, response.BaseResponse{
+			Success: false,
+			Message: "Validation failed",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	team, err := h.teamService.CreateTeam(ctx, orgID, c.GetUint("user_id"), &req)
+	if err != nil {
+		c.JSON(organizationErrorStatus(err), response.BaseResponse{
+			Success: false,
+			Message: "Failed to create team",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, response.BaseResponse{
+		Success: true,
+		Message: "Team created successfully",
+		Data:    team,
+	})
+}
+
+func (h *TeamHandler) ListTeams(c *gin.Context) {
+	orgID, ok := uintParam(c, "id", "Invalid organization ID")
+	if !ok {
+		return
+	}
+
+	teams, err := h.teamService.ListTeams(c.Request.Context(), orgID, c.GetUint("user_id"))
+	if err != nil {
+		c.JSON(organizationErrorStatus(err), response.BaseResponse{
+			Success: false,
+			Message: "Failed to list teams",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, response.BaseResponse{
+		Success: true,
+		Message: "Teams retrieved successfully",
+		Data:    teams,
+	})
+}
+
+func (h *TeamHandler) AddTeamMember(c *gin.Context) {
+	ctx := c.Request.Context()
+	orgID, ok := uintParam(c, "id", "Invalid organization ID")
+	if !ok {
+		return
+	}
+	teamID, ok := uintParam(c, "team_id", "Invalid team ID")
+	if !ok {
+		return
+	}
+	logger.Info(ctx, "AddTeamMember request received", map[string]any{"organization_id": orgID, "team_id": teamID})
+
+	var req request.AddTeamMemberRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, response.BaseResponse{
+			Success: false,
+			Message: "Invalid request body",
+			Error:   err.Error(),
+		})
+		return
+	}
+	if err := utilities.ValidateStruct(&req); err != nil {
+		c.JSON(http.StatusBadRequest, response.BaseResponse{
+			Success: false,
+			Message: "Validation failed",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	member, err := h.teamService.AddTeamMember(ctx, orgID, teamID, c.GetUint("user_id"), &req)
+	if err != nil {
+		c.JSON(organizationErrorStatus(err), response.BaseResponse{
+			Success: false,
+			Message: "Failed to add team member",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, response.BaseResponse{
+		Success: true,
+		Message: "Team member added successfully",
+		Data:    member,
+	})
+}
+
+func (h *TeamHandler) ListTeamMembers(c *gin.Context) {
+	orgID, ok := uintParam(c, "id", "Invalid organization ID")
+	if !ok {
+		return
+	}
+	teamID, ok := uintParam(c, "team_id", "Invalid team ID")
+	if !ok {
+		return
+	}
+
+	members, err := h.teamService.ListTeamMembers(c.Request.Context(), orgID, teamID, c.GetUint("user_id"))
+	if err != nil {
+		c.JSON(organizationErrorStatus(err), response.BaseResponse{
+			Success: false,
+			Message: "Failed to list team members",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, response.BaseResponse{
+		Success: true,
+		Message: "Team members retrieved successfully",
+		Data:    members,
+	})
+}
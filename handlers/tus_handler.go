@@ -0,0 +1,227 @@
+package handlers
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"go-boilerplate/config"
+	"go-boilerplate/logger"
+	"go-boilerplate/models"
+	"go-boilerplate/models/response"
+	"go-boilerplate/services/interfaces"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	tusVersion     = "1.0.0"
+	tusExtensions  = "creation,creation-with-upload,termination,expiration"
+	tusContentType = "application/offset+octet-stream"
+)
+
+// TusHandler exposes resumable uploads following the tus 1.0 protocol
+// (https://tus.io/protocols/resumable-upload).
+type TusHandler struct {
+	tusService interfaces.TusService
+	baseURL    string
+	maxSize    int64
+}
+
+func NewTusHandler(tusService interfaces.TusService, cfg *config.Config) *TusHandler {
+	return &TusHandler{tusService: tusService, baseURL: cfg.BaseURL, maxSize: cfg.Tus.MaxSize}
+}
+
+// RequireResumable rejects requests that do not speak the supported protocol version.
+func (h *TusHandler) RequireResumable(c *gin.Context) {
+	c.Header("Tus-Resumable", tusVersion)
+	if c.GetHeader("Tus-Resumable") != tusVersion {
+		c.Header("Tus-Version", tusVersion)
+		c.AbortWithStatusJSON(http.StatusPreconditionFailed, response.BaseResponse{
+			Success: false,
+			Message: "Unsupported Tus-Resumable version",
+		})
+		return
+	}
+	c.Next()
+}
+
+// Options advertises the protocol version, extensions and size limit.
+func (h *TusHandler) Options(c *gin.Context) {
+	c.Header("Tus-Resumable", tusVersion)
+	c.Header("Tus-Version", tusVersion)
+	c.Header("Tus-Extension", tusExtensions)
+	c.Header("Tus-Max-Size", strconv.FormatInt(h.maxSize, 10))
+	c.Status(http.StatusNoContent)
+}
+
+// Create starts an upload. With the creation-with-upload extension the body may
+// already carry the first chunk.
+func (h *TusHandler) Create(c *gin.Context) {
+	ctx := c.Request.Context()
+	userID := c.GetUint("user_id")
+
+	length, err := strconv.ParseInt(c.GetHeader("Upload-Length"), 10, 64)
+	if err != nil || length < 0 {
+		c.JSON(http.StatusBadRequest, response.BaseResponse{
+			Success: false,
+			Message: "A valid Upload-Length header is required",
+		})
+		return
+	}
+	metadata, err := parseTusMetadata(c.GetHeader("Upload-Metadata"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, response.BaseResponse{
+			Success: false,
+			Message: "Invalid Upload-Metadata header",
+			Error:   err.Error(),
+		})
+		return
+	}
+	logger.Info(ctx, "Tus create request received", map[string]any{"user_id": userID, "length": length})
+
+	upload, err := h.tusService.Create(ctx, userID, length, metadata)
+	if err != nil {
+		h.writeError(c, "Failed to create upload", err)
+		return
+	}
+	c.Header("Location", fmt.Sprintf("%s/api/v1/uploads/tus/%s", h.baseURL, upload.ID))
+
+	if c.Request.ContentLength != 0 && c.ContentType() == tusContentType {
+		if upload, err = h.tusService.WriteChunk(ctx, userID, upload.ID, 0, c.Request.Body); err != nil {
+			h.writeError(c, "Failed to write upload data", err)
+			return
+		}
+		c.Header("Upload-Offset", strconv.FormatInt(upload.Offset, 10))
+	}
+
+	c.Header("Upload-Expires", upload.ExpiresAt.Format(http.TimeFormat))
+	c.Status(http.StatusCreated)
+}
+
+// Head reports the current offset so a client can resume.
+func (h *TusHandler) Head(c *gin.Context) {
+	upload, err := h.tusService.Get(c.Request.Context(), c.GetUint("user_id"), c.Param("id"))
+	if err != nil {
+		c.Header("Cache-Control", "no-store")
+		if errors.Is(err, models.ErrNotFound) {
+			c.Status(http.StatusNotFound)
+			return
+		}
+		c.Status(http.StatusInternalServerError)
+		return
+	}
+
+	c.Header("Cache-Control", "no-store")
+	c.Header("Upload-Offset", strconv.FormatInt(upload.Offset, 10))
+	c.Header("Upload-Length", strconv.FormatInt(upload.Length, 10))
+	c.Header("Upload-Expires", upload.ExpiresAt.Format(http.TimeFormat))
+	if len(upload.Metadata) > 0 {
+		c.Header("Upload-Metadata", formatTusMetadata(upload.Metadata))
+	}
+	c.Status(http.StatusOK)
+}
+
+// Patch appends a chunk at the offset given in Upload-Offset.
+func (h *TusHandler) Patch(c *gin.Context) {
+	ctx := c.Request.Context()
+	if c.ContentType() != tusContentType {
+		c.JSON(http.StatusUnsupportedMediaType, response.BaseResponse{
+			Success: false,
+			Message: "Content-Type must be " + tusContentType,
+		})
+		return
+	}
+	offset, err := strconv.ParseInt(c.GetHeader("Upload-Offset"), 10, 64)
+	if err != nil || offset < 0 {
+		c.JSON(http.StatusBadRequest, response.BaseResponse{
+			Success: false,
+			Message: "A valid Upload-Offset header is required",
+		})
+		return
+	}
+
+	upload, err := h.tusService.WriteChunk(ctx, c.GetUint("user_id"), c.Param("id"), offset, c.Request.Body)
+	if err != nil {
+		h.writeError(c, "Failed to write upload data", err)
+		return
+	}
+
+	c.Header("Upload-Offset", strconv.FormatInt(upload.Offset, 10))
+	c.Header("Upload-Expires", upload.ExpiresAt.Format(http.TimeFormat))
+	c.Status(http.StatusNoContent)
+}
+
+// Terminate discards an upload and its data.
+func (h *TusHandler) Terminate(c *gin.Context) {
+	if err := h.tusService.Terminate(c.Request.Context(), c.GetUint("user_id"), c.Param("id")); err != nil {
+		h.writeError(c, "Failed to terminate upload", err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+func (h *TusHandler) writeError(c *gin.Context, message string, err error) {
+	status := http.StatusInternalServerError
+	switch {
+	case errors.Is(err, models.ErrNotFound):
+		status = http.StatusNotFound
+	case errors.Is(err, models.ErrOffsetMismatch):
+		status = http.StatusConflict
+	case errors.Is(err, models.ErrLocked):
+		status = http.StatusLocked
+	case errors.Is(err, models.ErrFileTooLarge):
+		status = http.StatusRequestEntityTooLarge
+	}
+	logger.Warn(c.Request.Context(), "Tus request failed", map[string]any{"status": status, "error": err.Error()})
+	c.JSON(status, response.BaseResponse{
+		Success: false,
+		Message: message,
+		Error:   err.Error(),
+	})
+}
+
+// parseTusMetadata decodes an Upload-Metadata header: comma-separated pairs of
+// a key and an optional base64-encoded value.
+func parseTusMetadata(header string) (map[string]string, error) {
+	if strings.TrimSpace(header) == "" {
+		return nil, nil
+	}
+	metadata := make(map[string]string)
+	for _, pair := range strings.Split(header, ",") {
+		key, encoded, _ := strings.Cut(strings.TrimSpace(pair), " ")
+		if key == "" {
+			return nil, errors.New("empty metadata key")
+		}
+		if _, dup := metadata[key]; dup {
+			return nil, fmt.Errorf("duplicate metadata key %q", key)
+		}
+		value, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return nil, fmt.Errorf("metadata %q: %w", key, err)
+		}
+		metadata[key] = string(value)
+	}
+	return metadata, nil
+}
+
+func formatTusMetadata(metadata map[string]string) string {
+	keys := make([]string, 0, len(metadata))
+	for key := range metadata {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	pairs := make([]string, len(keys))
+	for i, key := range keys {
+		pairs[i] = key
+		if metadata[key] != "" {
+			pairs[i] += " " + base64.StdEncoding.EncodeToString([]byte(metadata[key]))
+		}
+	}
+	return strings.Join(pairs, ",")
+}
@@ -0,0 +1,132 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"go-boilerplate/logger"
+	"go-boilerplate/models"
+	"go-boilerplate/models/response"
+	"go-boilerplate/services/interfaces"
+
+	"github.com/gin-gonic/gin"
+)
+
+// multipartOverhead is the allowance for multipart boundaries and part headers
+// on top of the file size limit.
+const multipartOverhead = 1 << 20
+
+type UploadHandler struct {
+	uploadService interfaces.UploadService
+	maxBytes      int64
+}
+
+func NewUploadHandler(uploadService interfaces.UploadService, maxBytes int64) *UploadHandler {
+	return &UploadHandler{uploadService: uploadService, maxBytes: maxBytes}
+}
+
+// Upload streams the "file" part of a multipart/form-data request into the blob store.
+func (h *UploadHandler) Upload(c *gin.Context) {
+	ctx := c.Request.Context()
+	logger.Info(ctx, "Upload request received", map[string]any{"content_length": c.Request.ContentLength})
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, h.maxBytes+multipartOverhead)
+
+	part, err := formFilePart(c, "file")
+	if err != nil {
+		logger.Warn(ctx, "Upload: invalid multipart body", map[string]any{"error": err.Error()})
+		c.JSON(http.StatusBadRequest, response.BaseResponse{
+			Success: false,
+			Message: "Invalid multipart body",
+			Error:   err.Error(),
+		})
+		return
+	}
+	defer part.Close()
+
+	upload, err := h.uploadService.Upload(ctx, part)
+	if err != nil {
+		status := uploadErrorStatus(err)
+		logger.Warn(ctx, "Upload failed", map[string]any{"error": err.Error(), "status": status})
+		c.JSON(status, response.BaseResponse{
+			Success: false,
+			Message: "Failed to upload file",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	logger.Info(ctx, "Upload successful", map[string]any{"key": upload.Key})
+	c.JSON(http.StatusCreated, response.BaseResponse{
+		Success: true,
+		Message: "File uploaded successfully",
+		Data:    upload,
+	})
+}
+
+// Download serves an object through an HMAC-signed, expiring URL.
+func (h *UploadHandler) Download(c *gin.Context) {
+	ctx := c.Request.Context()
+	key := strings.TrimPrefix(c.Param("key"), "/")
+	expires, _ := strconv.ParseInt(c.Query("expires"), 10, 64)
+	logger.Info(ctx, "Download request received", map[string]any{"key": key})
+
+	rc, info, err := h.uploadService.Open(ctx, key, expires, c.Query("signature"))
+	if err != nil {
+		status := http.StatusInternalServerError
+		switch {
+		case errors.Is(err, models.ErrInvalidToken):
+			status = http.StatusForbidden
+		case errors.Is(err, models.ErrNotFound):
+			status = http.StatusNotFound
+		}
+		c.JSON(status, response.BaseResponse{
+			Success: false,
+			Message: "Failed to download file",
+			Error:   err.Error(),
+		})
+		return
+	}
+	defer rc.Close()
+
+	c.DataFromReader(http.StatusOK, info.Size, info.ContentType, rc, map[string]string{
+		"Cache-Control":          "private, max-age=60",
+		"X-Content-Type-Options": "nosniff",
+	})
+}
+
+// formFilePart returns the multipart part with the given form name without buffering the body.
+func formFilePart(c *gin.Context, name string) (io.ReadCloser, error) {
+	mr, err := c.Request.MultipartReader()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		part, err := mr.NextPart()
+		if errors.Is(err, io.EOF) {
+			return nil, errors.New("missing form field: " + name)
+		}
+		if err != nil {
+			return nil, err
+		}
+		if part.FormName() == name && part.FileName() != "" {
+			return part, nil
+		}
+		part.Close()
+	}
+}
+
+// uploadErrorStatus maps upload validation errors to HTTP status codes.
+func uploadErrorStatus(err error) int {
+	var maxBytesErr *http.MaxBytesError
+	switch {
+	case errors.Is(err, models.ErrUnsupportedMediaType):
+		return http.StatusUnsupportedMediaType
+	case errors.Is(err, models.ErrFileTooLarge), errors.As(err, &maxBytesErr):
+		return http.StatusRequestEntityTooLarge
+	default:
+		return http.StatusBadRequest
+	}
+}
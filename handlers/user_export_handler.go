@@ -0,0 +1,83 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"go-boilerplate/logger"
+	"go-boilerplate/models/request"
+	"go-boilerplate/models/response"
+	"go-boilerplate/services/interfaces"
+	"go-boilerplate/utilities"
+
+	"github.com/gin-gonic/gin"
+)
+
+var exportContentTypes = map[string]string{
+	request.ExportFormatCSV:    "text/csv; charset=utf-8",
+	request.ExportFormatNDJSON: "application/x-ndjson",
+	request.ExportFormatXLSX:   "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+}
+
+type UserExportHandler struct {
+	exportService interfaces.UserExportService
+}
+
+func NewUserExportHandler(exportService interfaces.UserExportService) *UserExportHandler {
+	return &UserExportHandler{exportService: exportService}
+}
+
+// Export streams users as an attachment. Once the body has started, failures
+// can no longer change the status code, so they are logged and the connection is cut.
+func (h *UserExportHandler) Export(c *gin.Context) {
+	ctx := c.Request.Context()
+	var req request.UserExportRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, response.BaseResponse{
+			Success: false,
+			Message: "Invalid query parameters",
+			Error:   err.Error(),
+		})
+		return
+	}
+	if err := utilities.ValidateStruct(&req); err != nil {
+		c.JSON(http.StatusBadRequest, response.BaseResponse{
+			Success: false,
+			Message: "Validation failed",
+			Error:   err.Error(),
+		})
+		return
+	}
+	if _, err := req.ColumnList(); err != nil {
+		c.JSON(http.StatusBadRequest, response.BaseResponse{
+			Success: false,
+			Message: "Validation failed",
+			Error:   err.Error(),
+		})
+		return
+	}
+	logger.Info(ctx, "ExportUsers request received", map[string]any{"format": req.Format, "columns": req.Columns})
+
+	filename := fmt.Sprintf("users-%s.%s", time.Now().UTC().Format("20060102T150405Z"), req.Format)
+	c.Header("Content-Type", exportContentTypes[req.Format])
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+	c.Header("Cache-Control", "no-store")
+	c.Status(http.StatusOK)
+
+	if err := h.exportService.ExportUsers(ctx, c.Writer, &req); err != nil {
+		logger.Error(ctx, "ExportUsers failed mid-stream", map[string]any{"format": req.Format, "error": err.Error()})
+		abortConnection(c)
+		return
+	}
+	logger.Info(ctx, "ExportUsers: success", map[string]any{"format": req.Format})
+}
+
+// abortConnection drops the client connection so that a truncated stream is not
+// mistaken for a complete download.
+func abortConnection(c *gin.Context) {
+	c.Abort()
+	if conn, _, err := c.Writer.Hijack(); err == nil {
+		_ = conn.Close()
+	}
+}
@@ -1,10 +1,12 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
 
 	"go-boilerplate/logger"
+	"go-boilerplate/models"
 	"go-boilerplate/models/request"
 	"go-boilerplate/models/response"
 	"go-boilerplate/services/interfaces"
@@ -45,8 +47,17 @@ func (h *UserHandler) CreateUser(c *gin.Context) {
 		return
 	}
 
-	user, err := h.userService.CreateUser(&req)
+	user, err := h.userService.CreateUser(ctx, &req)
 	if err != nil {
+		if errors.Is(err, models.ErrEmailTaken) {
+			logger.Warn(ctx, "CreateUser: email already exists", map[string]any{"email": req.Email})
+			c.JSON(http.StatusConflict, response.BaseResponse{
+				Success: false,
+				Message: "Failed to create user",
+				Error:   err.Error(),
+			})
+			return
+		}
 		logger.Error(ctx, "CreateUser failed", map[string]any{"email": req.Email, "error": err.Error()})
 		c.JSON(http.StatusInternalServerError, response.BaseResponse{
 			Success: false,
@@ -78,7 +89,7 @@ func (h *UserHandler) GetUser(c *gin.Context) {
 		return
 	}
 
-	user, err := h.userService.GetUserByID(uint(id))
+	user, err := h.userService.GetUserByID(ctx, uint(id))
 	if err != nil {
 		logger.Warn(ctx, "GetUser: not found", map[string]any{"id": id, "error": err.Error()})
 		c.JSON(http.StatusNotFound, response.BaseResponse{
@@ -89,6 +100,14 @@ func (h *UserHandler) GetUser(c *gin.Context) {
 		return
 	}
 
+	etag := utilities.VersionETag(user.ID, user.Version)
+	c.Header("ETag", etag)
+	if inm := c.GetHeader("If-None-Match"); inm != "" && utilities.ETagMatchesAny(inm, etag) {
+		logger.Info(ctx, "GetUser: not modified", map[string]any{"id": id})
+		c.Status(http.StatusNotModified)
+		return
+	}
+
 	logger.Info(ctx, "GetUser: success", map[string]any{"id": id})
 	c.JSON(http.StatusOK, response.BaseResponse{
 		Success: true,
@@ -101,7 +120,25 @@ func (h *UserHandler) GetUsers(c *gin.Context) {
 	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
 	perPage, _ := strconv.Atoi(c.DefaultQuery("per_page", "10"))
 
-	users, err := h.userService.GetUsers(page, perPage)
+	var filter request.UserFilter
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		c.JSON(http.StatusBadRequest, response.BaseResponse{
+			Success: false,
+			Message: "Invalid query parameters",
+			Error:   err.Error(),
+		})
+		return
+	}
+	if err := utilities.ValidateStruct(&filter); err != nil {
+		c.JSON(http.StatusBadRequest, response.BaseResponse{
+			Success: false,
+			Message: "Validation failed",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	users, err := h.userService.GetUsers(c.Request.Context(), page, perPage, &filter)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, response.BaseResponse{
 			Success: false,
@@ -129,6 +166,11 @@ func (h *UserHandler) UpdateUser(c *gin.Context) {
 		return
 	}
 
+	versions, ok := ifMatchVersions(c, uint(id))
+	if !ok {
+		return
+	}
+
 	var req request.UpdateUserRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, response.BaseResponse{
@@ -139,8 +181,33 @@ func (h *UserHandler) UpdateUser(c *gin.Context) {
 		return
 	}
 
-	user, err := h.userService.UpdateUser(uint(id), &req)
+	if err := utilities.ValidateStruct(&req); err != nil {
+		c.JSON(http.StatusBadRequest, response.BaseResponse{
+			Success: false,
+			Message: "Validation failed",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	user, err := h.userService.UpdateUser(c.Request.Context(), uint(id), versions, &req)
 	if err != nil {
+		if errors.Is(err, models.ErrEmailTaken) {
+			c.JSON(http.StatusConflict, response.BaseResponse{
+				Success: false,
+				Message: "Failed to update user",
+				Error:   err.Error(),
+			})
+			return
+		}
+		if errors.Is(err, models.ErrVersionConflict) {
+			c.JSON(http.StatusPreconditionFailed, response.BaseResponse{
+				Success: false,
+				Message: "User has been modified, fetch the latest version and retry",
+				Error:   err.Error(),
+			})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, response.BaseResponse{
 			Success: false,
 			Message: "Failed to update user",
@@ -149,9 +216,15 @@ func (h *UserHandler) UpdateUser(c *gin.Context) {
 		return
 	}
 
+	message := "User updated successfully"
+	if user.PendingEmail != "" {
+		message = "User updated successfully, confirm the new email address to complete the change"
+	}
+
+	c.Header("ETag", utilities.VersionETag(user.ID, user.Version))
 	c.JSON(http.StatusOK, response.BaseResponse{
 		Success: true,
-		Message: "User updated successfully",
+		Message: message,
 		Data:    user,
 	})
 }
@@ -167,7 +240,20 @@ func (h *UserHandler) DeleteUser(c *gin.Context) {
 		return
 	}
 
-	if err := h.userService.DeleteUser(uint(id)); err != nil {
+	versions, ok := ifMatchVersions(c, uint(id))
+	if !ok {
+		return
+	}
+
+	if err := h.userService.DeleteUser(c.Request.Context(), uint(id), versions); err != nil {
+		if errors.Is(err, models.ErrVersionConflict) {
+			c.JSON(http.StatusPreconditionFailed, response.BaseResponse{
+				Success: false,
+				Message: "User has been modified, fetch the latest version and retry",
+				Error:   err.Error(),
+			})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, response.BaseResponse{
 			Success: false,
 			Message: "Failed to delete user",
@@ -181,3 +267,90 @@ func (h *UserHandler) DeleteUser(c *gin.Context) {
 		Message: "User deleted successfully",
 	})
 }
+
+func (h *UserHandler) GetTrash(c *gin.Context) {
+	ctx := c.Request.Context()
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	perPage, _ := strconv.Atoi(c.DefaultQuery("per_page", "10"))
+	logger.Info(ctx, "GetTrash request received", map[string]any{"page": page, "per_page": perPage})
+
+	users, err := h.userService.GetDeletedUsers(ctx, page, perPage)
+	if err != nil {
+		logger.Error(ctx, "GetTrash failed", map[string]any{"error": err.Error()})
+		c.JSON(http.StatusInternalServerError, response.BaseResponse{
+			Success: false,
+			Message: "Failed to retrieve deleted users",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, response.BaseResponse{
+		Success: true,
+		Message: "Deleted users retrieved successfully",
+		Data:    users,
+	})
+}
+
+func (h *UserHandler) RestoreUser(c *gin.Context) {
+	ctx := c.Request.Context()
+	idParam := c.Param("id")
+	logger.Info(ctx, "RestoreUser request received", map[string]any{"id": idParam})
+	id, err := strconv.ParseUint(idParam, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, response.BaseResponse{
+			Success: false,
+			Message: "Invalid user ID",
+		})
+		return
+	}
+
+	user, err := h.userService.RestoreUser(ctx, uint(id))
+	if err != nil {
+		status := http.StatusInternalServerError
+		switch {
+		case errors.Is(err, models.ErrEmailTaken):
+			status = http.StatusConflict
+		case errors.Is(err, models.ErrUserNotFound):
+			status = http.StatusNotFound
+		}
+		logger.Warn(ctx, "RestoreUser failed", map[string]any{"id": id, "error": err.Error()})
+		c.JSON(status, response.BaseResponse{
+			Success: false,
+			Message: "Failed to restore user",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	logger.Info(ctx, "RestoreUser: success", map[string]any{"id": id})
+	c.Header("ETag", utilities.VersionETag(user.ID, user.Version))
+	c.JSON(http.StatusOK, response.BaseResponse{
+		Success: true,
+		Message: "User restored successfully",
+		Data:    user,
+	})
+}
+
+// ifMatchVersions reads the If-Match header required for writes to a user:
+// "*" or a comma-separated list of ETags. It returns nil for "*". On a
+// missing or unusable header it writes 428 or 412 and returns false.
+func ifMatchVersions(c *gin.Context, id uint) (models.VersionMatch, bool) {
+	header := c.GetHeader("If-Match")
+	if header == "" {
+		c.JSON(http.StatusPreconditionRequired, response.BaseResponse{
+			Success: false,
+			Message: "If-Match header is required",
+		})
+		return nil, false
+	}
+	versions, ok := utilities.ParseVersionETags(id, header)
+	if !ok {
+		c.JSON(http.StatusPreconditionFailed, response.BaseResponse{
+			Success: false,
+			Message: "If-Match does not match the current user version",
+		})
+		return nil, false
+	}
+	return versions, true
+}
@@ -0,0 +1,135 @@
+package handlers
+
+import (
+	"errors"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"go-boilerplate/config"
+	"go-boilerplate/logger"
+	"go-boilerplate/models"
+	"go-boilerplate/models/request"
+	"go-boilerplate/models/response"
+	"go-boilerplate/services/interfaces"
+
+	"github.com/gin-gonic/gin"
+)
+
+type UserImportHandler struct {
+	importService       interfaces.UserImportService
+	asyncThresholdBytes int64
+}
+
+func NewUserImportHandler(importService interfaces.UserImportService, cfg *config.Config) *UserImportHandler {
+	return &UserImportHandler{
+		importService:       importService,
+		asyncThresholdBytes: cfg.Import.AsyncThresholdBytes,
+	}
+}
+
+// Import accepts a CSV or NDJSON body. Bodies larger than the configured threshold,
+// bodies of unknown length, or requests with async=true run as background jobs.
+func (h *UserImportHandler) Import(c *gin.Context) {
+	ctx := c.Request.Context()
+	format := importFormat(c)
+	dryRun, _ := strconv.ParseBool(c.DefaultQuery("dry_run", "false"))
+	async, _ := strconv.ParseBool(c.DefaultQuery("async", "false"))
+	size := c.Request.ContentLength
+	async = async || size < 0 || size > h.asyncThresholdBytes
+	logger.Info(ctx, "ImportUsers request received", map[string]any{"format": format, "dry_run": dryRun, "async": async, "content_length": size})
+
+	if format == "" {
+		c.JSON(http.StatusUnsupportedMediaType, response.BaseResponse{
+			Success: false,
+			Message: "Body must be CSV (text/csv) or NDJSON (application/x-ndjson)",
+		})
+		return
+	}
+
+	if async {
+		job, err := h.importService.StartImportJob(ctx, format, c.Request.Body, dryRun)
+		if err != nil {
+			logger.Error(ctx, "ImportUsers: start job failed", map[string]any{"error": err.Error()})
+			c.JSON(http.StatusInternalServerError, response.BaseResponse{
+				Success: false,
+				Message: "Failed to start import",
+				Error:   err.Error(),
+			})
+			return
+		}
+
+		logger.Info(ctx, "ImportUsers: job started", map[string]any{"job_id": job.ID})
+		c.Header("Location", c.Request.URL.Path+"/"+job.ID)
+		c.JSON(http.StatusAccepted, response.BaseResponse{
+			Success: true,
+			Message: "Import started",
+			Data:    job,
+		})
+		return
+	}
+
+	report, err := h.importService.ImportUsers(ctx, format, c.Request.Body, dryRun)
+	if err != nil {
+		logger.Warn(ctx, "ImportUsers failed", map[string]any{"error": err.Error()})
+		c.JSON(http.StatusBadRequest, response.BaseResponse{
+			Success: false,
+			Message: "Failed to import users",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	logger.Info(ctx, "ImportUsers: success", map[string]any{"imported": report.Imported, "failed": report.Failed})
+	c.JSON(http.StatusOK, response.BaseResponse{
+		Success: true,
+		Message: "Import finished",
+		Data:    report,
+	})
+}
+
+func (h *UserImportHandler) GetJob(c *gin.Context) {
+	ctx := c.Request.Context()
+	jobID := c.Param("job_id")
+	logger.Info(ctx, "GetImportJob request received", map[string]any{"job_id": jobID})
+
+	job, err := h.importService.GetImportJob(ctx, jobID)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, models.ErrNotFound) {
+			status = http.StatusNotFound
+		}
+		c.JSON(status, response.BaseResponse{
+			Success: false,
+			Message: "Import job not found",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, response.BaseResponse{
+		Success: true,
+		Message: "Import job retrieved successfully",
+		Data:    job,
+	})
+}
+
+// importFormat resolves the import format from ?format= or the Content-Type header.
+func importFormat(c *gin.Context) string {
+	switch strings.ToLower(c.Query("format")) {
+	case request.ImportFormatCSV:
+		return request.ImportFormatCSV
+	case request.ImportFormatNDJSON, "jsonl":
+		return request.ImportFormatNDJSON
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(c.ContentType())
+	switch mediaType {
+	case "text/csv", "application/csv":
+		return request.ImportFormatCSV
+	case "application/x-ndjson", "application/ndjson", "application/jsonl", "application/x-jsonlines":
+		return request.ImportFormatNDJSON
+	}
+	return ""
+}
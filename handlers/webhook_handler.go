@@ -0,0 +1,248 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"go-boilerplate/logger"
+	"go-boilerplate/models"
+	"go-boilerplate/models/request"
+	"go-boilerplate/models/response"
+	"go-boilerplate/services/interfaces"
+	"go-boilerplate/utilities"
+
+	"github.com/gin-gonic/gin"
+)
+
+type WebhookHandler struct {
+	webhookService interfaces.WebhookService
+}
+
+func NewWebhookHandler(webhookService interfaces.WebhookService) *WebhookHandler {
+	return &WebhookHandler{webhookService: webhookService}
+}
+
+func (h *WebhookHandler) CreateWebhook(c *gin.Context) {
+	ctx := c.Request.Context()
+	orgID, ok := uintParam(c, "id", "Invalid organization ID")
+	if !ok {
+		return
+	}
+	logger.Info(ctx, "CreateWebhook request received", map[string]any{"organization_id": orgID})
+
+	var req request.CreateWebhookRequest
+	if !bindWebhookRequest(c, &req) {
+		return
+	}
+
+	webhook, err := h.webhookService.CreateSubscription(ctx, orgID, c.GetUint("user_id"), &req)
+	if err != nil {
+		c.JSON(organizationErrorStatus(err), response.BaseResponse{
+			Success: false,
+			Message: "Failed to create webhook",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, response.BaseResponse{
+		Success: true,
+		Message: "Webhook created successfully, store the secret now as it is not shown again",
+		Data:    webhook,
+	})
+}
+
+func (h *WebhookHandler) ListWebhooks(c *gin.Context) {
+	orgID, ok := uintParam(c, "id", "Invalid organization ID")
+	if !ok {
+		return
+	}
+
+	webhooks, err := h.webhookService.ListSubscriptions(c.Request.Context(), orgID, c.GetUint("user_id"))
+	if err != nil {
+		c.JSON(organizationErrorStatus(err), response.BaseResponse{
+			Success: false,
+			Message: "Failed to list webhooks",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, response.BaseResponse{
+		Success: true,
+		Message: "Webhooks retrieved successfully",
+		Data:    webhooks,
+	})
+}
+
+func (h *WebhookHandler) GetWebhook(c *gin.Context) {
+	orgID, webhookID, ok := webhookParams(c)
+	if !ok {
+		return
+	}
+
+	webhook, err := h.webhookService.GetSubscription(c.Request.Context(), orgID, webhookID, c.GetUint("user_id"))
+	if err != nil {
+		c.JSON(organizationErrorStatus(err), response.BaseResponse{
+			Success: false,
+			Message: "Failed to get webhook",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, response.BaseResponse{
+		Success: true,
+		Message: "Webhook retrieved successfully",
+		Data:    webhook,
+	})
+}
+
+func (h *WebhookHandler) UpdateWebhook(c *gin.Context) {
+	ctx := c.Request.Context()
+	orgID, webhookID, ok := webhookParams(c)
+	if !ok {
+		return
+	}
+	logger.Info(ctx, "UpdateWebhook request received", map[string]any{"organization_id": orgID, "webhook_id": webhookID})
+
+	var req request.UpdateWebhookRequest
+	if !bindWebhookRequest(c, &req) {
+		return
+	}
+
+	webhook, err := h.webhookService.UpdateSubscription(ctx, orgID, webhookID, c.GetUint("user_id"), &req)
+	if err != nil {
+		c.JSON(organizationErrorStatus(err), response.BaseResponse{
+			Success: false,
+			Message: "Failed to update webhook",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, response.BaseResponse{
+		Success: true,
+		Message: "Webhook updated successfully",
+		Data:    webhook,
+	})
+}
+
+func (h *WebhookHandler) DeleteWebhook(c *gin.Context) {
+	ctx := c.Request.Context()
+	orgID, webhookID, ok := webhookParams(c)
+	if !ok {
+		return
+	}
+	logger.Info(ctx, "DeleteWebhook request received", map[string]any{"organization_id": orgID, "webhook_id": webhookID})
+
+	if err := h.webhookService.DeleteSubscription(ctx, orgID, webhookID, c.GetUint("user_id")); err != nil {
+		c.JSON(organizationErrorStatus(err), response.BaseResponse{
+			Success: false,
+			Message: "Failed to delete webhook",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, response.BaseResponse{
+		Success: true,
+		Message: "Webhook deleted successfully",
+	})
+}
+
+// ListDeliveries returns the webhook's delivery log, optionally filtered by ?status=.
+func (h *WebhookHandler) ListDeliveries(c *gin.Context) {
+	orgID, webhookID, ok := webhookParams(c)
+	if !ok {
+		return
+	}
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	perPage, _ := strconv.Atoi(c.DefaultQuery("per_page", "10"))
+
+	status := c.Query("status")
+	switch status {
+	case "", models.WebhookDeliveryPending, models.WebhookDeliverySucceeded, models.WebhookDeliveryDead:
+	default:
+		c.JSON(http.StatusBadRequest, response.BaseResponse{
+			Success: false,
+			Message: "Invalid status, expected pending, succeeded or dead",
+		})
+		return
+	}
+
+	deliveries, err := h.webhookService.ListDeliveries(c.Request.Context(), orgID, webhookID, c.GetUint("user_id"), page, perPage, status)
+	if err != nil {
+		c.JSON(organizationErrorStatus(err), response.BaseResponse{
+			Success: false,
+			Message: "Failed to list deliveries",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, response.BaseResponse{
+		Success: true,
+		Message: "Deliveries retrieved successfully",
+		Data:    deliveries,
+	})
+}
+
+func (h *WebhookHandler) Redeliver(c *gin.Context) {
+	ctx := c.Request.Context()
+	orgID, webhookID, ok := webhookParams(c)
+	if !ok {
+		return
+	}
+	deliveryID, ok := uintParam(c, "delivery_id", "Invalid delivery ID")
+	if !ok {
+		return
+	}
+	logger.Info(ctx, "Redeliver request received", map[string]any{"organization_id": orgID, "webhook_id": webhookID, "delivery_id": deliveryID})
+
+	delivery, err := h.webhookService.Redeliver(ctx, orgID, webhookID, deliveryID, c.GetUint("user_id"))
+	if err != nil {
+		c.JSON(organizationErrorStatus(err), response.BaseResponse{
+			Success: false,
+			Message: "Failed to redeliver",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, response.BaseResponse{
+		Success: true,
+		Message: "Delivery queued",
+		Data:    delivery,
+	})
+}
+
+func webhookParams(c *gin.Context) (orgID, webhookID uint, ok bool) {
+	if orgID, ok = uintParam(c, "id", "Invalid organization ID"); !ok {
+		return 0, 0, false
+	}
+	if webhookID, ok = uintParam(c, "webhook_id", "Invalid webhook ID"); !ok {
+		return 0, 0, false
+	}
+	return orgID, webhookID, true
+}
+
+func bindWebhookRequest(c *gin.Context, req any) bool {
+	if err := c.ShouldBindJSON(req); err != nil {
+		c.JSON(http.StatusBadRequest, response.BaseResponse{
+			Success: false,
+			Message: "Invalid request body",
+			Error:   err.Error(),
+		})
+		return false
+	}
+	if err := utilities.ValidateStruct(req); err != nil {
+		c.JSON(http.StatusBadRequest, response.BaseResponse{
+			Success: false,
+			Message: "Validation failed",
+			Error:   err.Error(),
+		})
+		return false
+	}
+	return true
+}
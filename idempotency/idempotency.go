@@ -0,0 +1,152 @@
+// Package idempotency records the outcome of requests made with an
+// Idempotency-Key, so that a client retrying one gets the first response
+// instead of repeating the request.
+//
+// A record is a Redis hash holding the fingerprint of the request that first
+// used the key. While that request runs the record is in flight and expires
+// after the lock TTL, so a crashed instance does not block the key forever;
+// once it completes the record holds the response for the record TTL. Every
+// step is a Lua script, so two requests racing with the same key cannot both
+// run.
+package idempotency
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	repoif "go-boilerplate/repository/interfaces"
+	"go-boilerplate/utilities"
+
+	"github.com/redis/go-redis/v9"
+)
+
+var (
+	// ErrInFlight is returned while another request with the same key runs.
+	ErrInFlight = errors.New("idempotency: a request with this key is still in progress")
+	// ErrKeyReused is returned when the key was used for a different request.
+	ErrKeyReused = errors.New("idempotency: key was used for a different request")
+	// ErrLost is returned when the record expired or was replaced before the
+	// request that claimed it completed.
+	ErrLost = errors.New("idempotency: record no longer held")
+)
+
+// beginScript claims KEYS[1] for the request with fingerprint ARGV[1] and
+// token ARGV[2] for ARGV[3] milliseconds if it is free. It returns
+// {"started"}, {"mismatch"}, {"in_flight"} or {"done", response}.
+var beginScript = redis.NewScript(`
+local record = redis.call('HMGET', KEYS[1], 'fingerprint', 'state', 'response')
+if not record[1] then
+	redis.call('HSET', KEYS[1], 'fingerprint', ARGV[1], 'state', 'in_flight', 'token', ARGV[2])
+	redis.call('PEXPIRE', KEYS[1], ARGV[3])
+	return {'started'}
+end
+if record[1] ~= ARGV[1] then
+	return {'mismatch'}
+end
+if record[2] ~= 'done' then
+	return {'in_flight'}
+end
+return {'done', record[3]}
+`)
+
+// completeScript stores the response ARGV[2] in KEYS[1] for ARGV[3]
+// milliseconds if it is still claimed with the token ARGV[1].
+var completeScript = redis.NewScript(`
+if redis.call('HGET', KEYS[1], 'token') ~= ARGV[1] then
+	return 0
+end
+redis.call('HSET', KEYS[1], 'state', 'done', 'response', ARGV[2])
+redis.call('PEXPIRE', KEYS[1], ARGV[3])
+return 1
+`)
+
+// abandonScript deletes KEYS[1] if it is still claimed with the token ARGV[1].
+var abandonScript = redis.NewScript(`
+if redis.call('HGET', KEYS[1], 'token') == ARGV[1] then
+	return redis.call('DEL', KEYS[1])
+end
+return 0
+`)
+
+// Response is a recorded response.
+type Response struct {
+	Status int         `json:"status"`
+	Header http.Header `json:"header,omitempty"`
+	Body   []byte      `json:"body,omitempty"`
+}
+
+// Store keeps idempotency records in Redis.
+type Store struct {
+	repo    repoif.RedisRepository
+	ttl     time.Duration
+	lockTTL time.Duration
+}
+
+// NewStore returns a Store that keeps responses for ttl and lets a request
+// hold its key for up to lockTTL while it runs.
+func NewStore(repo repoif.RedisRepository, ttl, lockTTL time.Duration) *Store {
+	return &Store{repo: repo, ttl: ttl, lockTTL: lockTTL}
+}
+
+// Begin claims key, which the caller has scoped to its client, for the
+// request with the given fingerprint. If the request should run, Begin
+// returns a token to pass to Complete or Abandon once it has. If it already
+// ran, Begin returns its response. It returns ErrInFlight while it is still
+// running and ErrKeyReused if key was used for a different request.
+func (s *Store) Begin(ctx context.Context, key, fingerprint string) (string, *Response, error) {
+	token := utilities.NewRandomID()
+	result, err := s.repo.RunScript(ctx, beginScript, []string{key}, fingerprint, token, s.lockTTL.Milliseconds()).StringSlice()
+	if err != nil {
+		return "", nil, err
+	}
+	if len(result) == 0 {
+		return "", nil, fmt.Errorf("idempotency: unexpected script result %v", result)
+	}
+
+	switch result[0] {
+	case "started":
+		return token, nil, nil
+	case "mismatch":
+		return "", nil, ErrKeyReused
+	case "in_flight":
+		return "", nil, ErrInFlight
+	case "done":
+		if len(result) != 2 {
+			return "", nil, fmt.Errorf("idempotency: unexpected script result %v", result)
+		}
+		var resp Response
+		if err := json.Unmarshal([]byte(result[1]), &resp); err != nil {
+			return "", nil, fmt.Errorf("idempotency: invalid stored response: %w", err)
+		}
+		return "", &resp, nil
+	default:
+		return "", nil, fmt.Errorf("idempotency: unexpected script result %v", result)
+	}
+}
+
+// Complete records resp as the response to the request that claimed key
+// with token.
+func (s *Store) Complete(ctx context.Context, key, token string, resp *Response) error {
+	data, err := json.Marshal(resp)
+	if err != nil {
+		return fmt.Errorf("idempotency: failed to encode response: %w", err)
+	}
+	stored, err := s.repo.RunScript(ctx, completeScript, []string{key}, token, data, s.ttl.Milliseconds()).Int64()
+	if err != nil {
+		return err
+	}
+	if stored == 0 {
+		return ErrLost
+	}
+	return nil
+}
+
+// Abandon frees key without recording a response, so that a retry runs the
+// request again.
+func (s *Store) Abandon(ctx context.Context, key, token string) error {
+	return s.repo.RunScript(ctx, abandonScript, []string{key}, token).Err()
+}
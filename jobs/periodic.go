@@ -0,0 +1,87 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go-boilerplate/lock"
+	"go-boilerplate/logger"
+)
+
+// Every runs fn in a background goroutine once per interval until ctx is cancelled.
+// Failures are logged and do not stop the schedule. A non-positive interval disables the job.
+func Every(ctx context.Context, name string, interval time.Duration, fn func(ctx context.Context) error) {
+	if interval <= 0 {
+		logger.Info(ctx, "Job disabled", map[string]any{"job": name})
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		logger.Info(ctx, "Job scheduled", map[string]any{"job": name, "interval": interval.String()})
+		for {
+			select {
+			case <-ctx.Done():
+				logger.Info(ctx, "Job stopped", map[string]any{"job": name})
+				return
+			case <-ticker.C:
+				runCtx, _, _ := logger.StartSpan(ctx)
+				start := time.Now()
+				if err := fn(runCtx); err != nil {
+					logger.Error(runCtx, "Job run failed", map[string]any{"job": name, "error": err.Error()})
+					continue
+				}
+				logger.Debug(runCtx, "Job run finished", map[string]any{"job": name, "duration_ms": time.Since(start).Milliseconds()})
+			}
+		}
+	}()
+}
+
+// Forever runs fn in a background goroutine until ctx is cancelled, for work
+// that loops on its own such as stream consumers. If fn returns early it is
+// logged and started again after restartDelay.
+func Forever(ctx context.Context, name string, restartDelay time.Duration, fn func(ctx context.Context) error) {
+	go func() {
+		logger.Info(ctx, "Job started", map[string]any{"job": name})
+		for {
+			err := fn(ctx)
+			if ctx.Err() != nil {
+				logger.Info(ctx, "Job stopped", map[string]any{"job": name})
+				return
+			}
+			fields := map[string]any{"job": name, "restart_in": restartDelay.String()}
+			if err != nil {
+				fields["error"] = err.Error()
+			}
+			logger.Error(ctx, "Job exited unexpectedly", fields)
+
+			t := time.NewTimer(restartDelay)
+			select {
+			case <-ctx.Done():
+				t.Stop()
+				logger.Info(ctx, "Job stopped", map[string]any{"job": name})
+				return
+			case <-t.C:
+			}
+		}
+	}()
+}
+
+// Exclusive wraps fn so that a run only happens on the instance that takes
+// the lock "job:<name>"; the other instances skip that run. The lock is held
+// for the whole run and fn's context is cancelled if it is lost.
+func Exclusive(locker *lock.Locker, name string, fn func(ctx context.Context) error) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		err := locker.Do(ctx, "job:"+name, lock.Options{}, func(ctx context.Context, _ int64) error {
+			return fn(ctx)
+		})
+		if errors.Is(err, lock.ErrNotAcquired) {
+			logger.Debug(ctx, "Job run skipped, running elsewhere", map[string]any{"job": name})
+			return nil
+		}
+		return err
+	}
+}
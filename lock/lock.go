@@ -0,0 +1,298 @@
+// Package lock implements distributed locks on top of Redis, for work that
+// must run on only one instance at a time such as scheduled jobs and
+// migrations.
+//
+// A lock is a key holding a random token that only its owner knows, so it
+// can only be extended or released by the owner: both go through Lua
+// scripts that compare the token first. Locks expire after their TTL unless
+// extended, which Acquire does in the background while the holder is alive.
+//
+// Expiry means a holder that stalls, e.g. in a long GC pause, can lose the
+// lock without noticing. Each acquisition therefore also gets a fencing
+// token, a number that grows with every acquisition of the same lock.
+// Storage that the holder writes to can record the highest token it has seen
+// and reject writes carrying a lower one.
+package lock
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"math/rand/v2"
+	"sync"
+	"time"
+
+	"go-boilerplate/logger"
+	repoif "go-boilerplate/repository/interfaces"
+	"go-boilerplate/utilities"
+
+	"github.com/redis/go-redis/v9"
+)
+
+var (
+	// ErrNotAcquired is returned when the lock is held by someone else.
+	ErrNotAcquired = errors.New("lock: held by another owner")
+	// ErrLost is returned when the lock expired or was taken over before
+	// it could be extended or released.
+	ErrLost = errors.New("lock: no longer held")
+)
+
+// acquireScript sets KEYS[1] to the token ARGV[1] for ARGV[2] milliseconds
+// if it is free and returns the next fencing token from KEYS[2].
+var acquireScript = redis.NewScript(`
+if redis.call('SET', KEYS[1], ARGV[1], 'NX', 'PX', ARGV[2]) then
+	return redis.call('INCR', KEYS[2])
+end
+return false
+`)
+
+// extendScript resets the TTL of KEYS[1] to ARGV[2] milliseconds if it still
+// holds the token ARGV[1].
+var extendScript = redis.NewScript(`
+if redis.call('GET', KEYS[1]) == ARGV[1] then
+	return redis.call('PEXPIRE', KEYS[1], ARGV[2])
+end
+return 0
+`)
+
+// releaseScript deletes KEYS[1] if it still holds the token ARGV[1].
+var releaseScript = redis.NewScript(`
+if redis.call('GET', KEYS[1]) == ARGV[1] then
+	return redis.call('DEL', KEYS[1])
+end
+return 0
+`)
+
+// Options configures an acquisition. Zero fields take the defaults.
+type Options struct {
+	// TTL is how long the lock is held without being extended (default 30s).
+	// While held it is extended every TTL/3.
+	TTL time.Duration
+	// Wait is how long Acquire keeps retrying a held lock; zero tries once.
+	Wait time.Duration
+	// RetryMin and RetryMax bound the exponential backoff between attempts
+	// (defaults 50ms and 1s). Each delay is jittered down by up to half.
+	RetryMin time.Duration
+	RetryMax time.Duration
+}
+
+// withDefaults fills the zero TTL and retry fields from defaults, then from
+// the package defaults.
+func (o Options) withDefaults(defaults Options) Options {
+	if o.TTL <= 0 {
+		o.TTL = cmp.Or(defaults.TTL, 30*time.Second)
+	}
+	if o.RetryMin <= 0 {
+		o.RetryMin = cmp.Or(defaults.RetryMin, 50*time.Millisecond)
+	}
+	if o.RetryMax <= 0 {
+		o.RetryMax = cmp.Or(defaults.RetryMax, time.Second)
+	}
+	o.RetryMax = max(o.RetryMax, o.RetryMin)
+	return o
+}
+
+// Locker acquires locks.
+type Locker struct {
+	repo     repoif.RedisRepository
+	defaults Options
+}
+
+// NewLocker returns a Locker whose acquisitions take their TTL and retry
+// settings from defaults unless they set their own. defaults.Wait is unused.
+func NewLocker(repo repoif.RedisRepository, defaults Options) *Locker {
+	return &Locker{repo: repo, defaults: defaults}
+}
+
+// Lock is a held lock. It is extended in the background until Release is
+// called or the lock is lost, whichever comes first.
+type Lock struct {
+	locker *Locker
+	name   string
+	key    string
+	token  string
+	fence  int64
+	ttl    time.Duration
+
+	stop     chan struct{}
+	stopOnce sync.Once
+	lost     chan struct{}
+	stopped  chan struct{}
+}
+
+// Acquire takes the named lock, retrying with backoff for up to opts.Wait
+// while someone else holds it. It returns ErrNotAcquired if the lock is
+// still held then, and ctx's error if ctx ends first.
+func (l *Locker) Acquire(ctx context.Context, name string, opts Options) (*Lock, error) {
+	opts = opts.withDefaults(l.defaults)
+	deadline := time.Now().Add(opts.Wait)
+	delay := opts.RetryMin
+	for attempt := 1; ; attempt++ {
+		lk, err := l.try(ctx, name, opts.TTL)
+		if !errors.Is(err, ErrNotAcquired) {
+			if err != nil {
+				logger.Warn(ctx, "Lock.Acquire failed", map[string]any{"lock": name, "error": err.Error()})
+			}
+			return lk, err
+		}
+
+		// Full jitter would let a retry land right away; half keeps the spread.
+		wait := delay/2 + rand.N(delay/2+1)
+		if time.Now().Add(wait).After(deadline) {
+			logger.Debug(ctx, "Lock.Acquire: held elsewhere", map[string]any{"lock": name, "attempts": attempt})
+			return nil, ErrNotAcquired
+		}
+		t := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return nil, ctx.Err()
+		case <-t.C:
+		}
+		delay = min(delay*2, opts.RetryMax)
+	}
+}
+
+func (l *Locker) try(ctx context.Context, name string, ttl time.Duration) (*Lock, error) {
+	key := utilities.LockKey(name)
+	token := utilities.NewRandomID()
+	fence, err := l.repo.RunScript(ctx, acquireScript, []string{key, utilities.LockFenceKey(name)}, token, ttl.Milliseconds()).Int64()
+	if errors.Is(err, redis.Nil) {
+		return nil, ErrNotAcquired
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	lk := &Lock{
+		locker:  l,
+		name:    name,
+		key:     key,
+		token:   token,
+		fence:   fence,
+		ttl:     ttl,
+		stop:    make(chan struct{}),
+		lost:    make(chan struct{}),
+		stopped: make(chan struct{}),
+	}
+	go lk.keepAlive(context.WithoutCancel(ctx))
+	logger.Debug(ctx, "Lock.Acquire success", map[string]any{"lock": name, "fence": fence})
+	return lk, nil
+}
+
+// Do runs fn while holding the named lock. fn's context is cancelled if the
+// lock is lost. It returns ErrNotAcquired without running fn when the lock
+// cannot be taken within opts.Wait.
+func (l *Locker) Do(ctx context.Context, name string, opts Options, fn func(ctx context.Context, fence int64) error) error {
+	lk, err := l.Acquire(ctx, name, opts)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := lk.Release(context.WithoutCancel(ctx)); err != nil && !errors.Is(err, ErrLost) {
+			logger.Warn(ctx, "Lock.Do: release failed", map[string]any{"lock": name, "error": err.Error()})
+		}
+	}()
+
+	runCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go func() {
+		select {
+		case <-lk.Lost():
+			cancel()
+		case <-runCtx.Done():
+		}
+	}()
+	err = fn(runCtx, lk.Fence())
+	if err == nil && lk.isLost() {
+		return ErrLost
+	}
+	return err
+}
+
+// Token returns the random value identifying this holder.
+func (lk *Lock) Token() string { return lk.token }
+
+// Fence returns the fencing token of this acquisition. It is larger than
+// the token of every earlier acquisition of the same lock.
+func (lk *Lock) Fence() int64 { return lk.fence }
+
+// Lost is closed when the lock could not be extended and may now be held by
+// someone else.
+func (lk *Lock) Lost() <-chan struct{} { return lk.lost }
+
+// Extend resets the lock's TTL. It returns ErrLost if the lock is no longer
+// held by lk.
+func (lk *Lock) Extend(ctx context.Context, ttl time.Duration) error {
+	n, err := lk.locker.repo.RunScript(ctx, extendScript, []string{lk.key}, lk.token, ttl.Milliseconds()).Int64()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrLost
+	}
+	return nil
+}
+
+// Release stops the background extension and deletes the lock if lk still
+// holds it. It returns ErrLost if it did not.
+func (lk *Lock) Release(ctx context.Context) error {
+	lk.stopOnce.Do(func() { close(lk.stop) })
+	<-lk.stopped
+
+	n, err := lk.locker.repo.RunScript(ctx, releaseScript, []string{lk.key}, lk.token).Int64()
+	if err != nil {
+		logger.Warn(ctx, "Lock.Release failed", map[string]any{"lock": lk.name, "error": err.Error()})
+		return err
+	}
+	if n == 0 {
+		logger.Warn(ctx, "Lock.Release: lock was already lost", map[string]any{"lock": lk.name, "fence": lk.fence})
+		return ErrLost
+	}
+	logger.Debug(ctx, "Lock.Release success", map[string]any{"lock": lk.name, "fence": lk.fence})
+	return nil
+}
+
+// keepAlive extends the lock every TTL/3 until it is released. A lock that
+// is taken over, or that cannot be extended before it would expire, is lost.
+func (lk *Lock) keepAlive(ctx context.Context) {
+	defer close(lk.stopped)
+	interval := lk.ttl / 3
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	expires := time.Now().Add(lk.ttl)
+	for {
+		select {
+		case <-lk.stop:
+			return
+		case <-ticker.C:
+		}
+
+		callCtx, cancel := context.WithTimeout(ctx, interval)
+		err := lk.Extend(callCtx, lk.ttl)
+		cancel()
+		switch {
+		case err == nil:
+			expires = time.Now().Add(lk.ttl)
+			continue
+		case errors.Is(err, ErrLost):
+			logger.Error(ctx, "Lock.keepAlive: lock was taken over", map[string]any{"lock": lk.name, "fence": lk.fence})
+		case time.Until(expires) > interval:
+			logger.Warn(ctx, "Lock.keepAlive: extend failed, retrying", map[string]any{"lock": lk.name, "error": err.Error()})
+			continue
+		default:
+			logger.Error(ctx, "Lock.keepAlive: extend failed, giving the lock up", map[string]any{"lock": lk.name, "fence": lk.fence, "error": err.Error()})
+		}
+		close(lk.lost)
+		return
+	}
+}
+
+func (lk *Lock) isLost() bool {
+	select {
+	case <-lk.lost:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,132 @@
+package lock
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"go-boilerplate/repository"
+	"go-boilerplate/utilities"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// newTestLocker connects to the Redis server in TEST_REDIS_ADDR and returns
+// a locker and a lock name no other test uses, e.g.
+//
+//	TEST_REDIS_ADDR=localhost:6379 go test ./lock
+func newTestLocker(t *testing.T) (*Locker, *redis.Client, string) {
+	t.Helper()
+	addr := os.Getenv("TEST_REDIS_ADDR")
+	if addr == "" {
+		t.Skip("TEST_REDIS_ADDR not set")
+	}
+	client := redis.NewClient(&redis.Options{Addr: addr})
+	if err := client.Ping(context.Background()).Err(); err != nil {
+		t.Fatalf("ping: %v", err)
+	}
+	name := "test-" + utilities.NewRandomID()[:8]
+	t.Cleanup(func() {
+		client.Del(context.Background(), utilities.LockKey(name), utilities.LockFenceKey(name))
+		client.Close()
+	})
+	return NewLocker(repository.NewRedisRepository(client), Options{}), client, name
+}
+
+// stopKeepAlive stops lk's background extension without releasing it, so
+// that it expires after its TTL.
+func stopKeepAlive(lk *Lock) {
+	lk.stopOnce.Do(func() { close(lk.stop) })
+	<-lk.stopped
+}
+
+func TestReleaseByNonOwnerIsNoOp(t *testing.T) {
+	locker, client, name := newTestLocker(t)
+	ctx := context.Background()
+
+	lk, err := locker.Acquire(ctx, name, Options{})
+	if err != nil {
+		t.Fatalf("acquire: %v", err)
+	}
+	defer lk.Release(ctx)
+
+	stopped := make(chan struct{})
+	close(stopped)
+	intruder := &Lock{locker: locker, name: name, key: lk.key, token: "intruder", stop: make(chan struct{}), stopped: stopped}
+	if err := intruder.Release(ctx); !errors.Is(err, ErrLost) {
+		t.Fatalf("release by non-owner: got %v, want ErrLost", err)
+	}
+
+	token, err := client.Get(ctx, utilities.LockKey(name)).Result()
+	if err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	if token != lk.Token() {
+		t.Fatalf("lock holds %q after non-owner release, want %q", token, lk.Token())
+	}
+}
+
+func TestExtendAfterExpiryFails(t *testing.T) {
+	locker, _, name := newTestLocker(t)
+	ctx := context.Background()
+
+	lk, err := locker.Acquire(ctx, name, Options{TTL: 200 * time.Millisecond})
+	if err != nil {
+		t.Fatalf("acquire: %v", err)
+	}
+	stopKeepAlive(lk)
+	time.Sleep(400 * time.Millisecond)
+
+	if err := lk.Extend(ctx, time.Second); !errors.Is(err, ErrLost) {
+		t.Fatalf("extend after expiry: got %v, want ErrLost", err)
+	}
+	if err := lk.Release(ctx); !errors.Is(err, ErrLost) {
+		t.Fatalf("release after expiry: got %v, want ErrLost", err)
+	}
+}
+
+func TestFencingTokensIncrease(t *testing.T) {
+	locker, _, name := newTestLocker(t)
+	ctx := context.Background()
+
+	var last int64
+	for i := 0; i < 3; i++ {
+		lk, err := locker.Acquire(ctx, name, Options{})
+		if err != nil {
+			t.Fatalf("acquire %d: %v", i, err)
+		}
+		if lk.Fence() <= last {
+			t.Fatalf("acquire %d: fence %d not above %d", i, lk.Fence(), last)
+		}
+		last = lk.Fence()
+		if err := lk.Release(ctx); err != nil {
+			t.Fatalf("release %d: %v", i, err)
+		}
+	}
+}
+
+func TestAcquireRespectsContextCancellation(t *testing.T) {
+	locker, _, name := newTestLocker(t)
+
+	held, err := locker.Acquire(context.Background(), name, Options{})
+	if err != nil {
+		t.Fatalf("acquire: %v", err)
+	}
+	defer held.Release(context.Background())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	lk, err := locker.Acquire(ctx, name, Options{Wait: 10 * time.Second})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("acquire while held: got %v, want context.DeadlineExceeded", err)
+	}
+	if lk != nil {
+		t.Fatal("acquire while held returned a lock")
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Fatalf("acquire returned after %s, long after ctx ended", elapsed)
+	}
+}
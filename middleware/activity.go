@@ -0,0 +1,19 @@
+package middleware
+
+import (
+	"go-boilerplate/services/interfaces"
+
+	"github.com/gin-gonic/gin"
+)
+
+// LastSeenMiddleware records activity for the user AuthMiddleware authenticated,
+// if any, once the request has been handled. Writes are debounced by the
+// user service, so most requests cost a single Redis command.
+func LastSeenMiddleware(userService interfaces.UserService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+		if userID := c.GetUint("user_id"); userID != 0 {
+			userService.TouchLastSeen(c.Request.Context(), userID)
+		}
+	}
+}
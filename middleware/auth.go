@@ -4,6 +4,8 @@ import (
 	"net/http"
 	"strings"
 
+	"go-boilerplate/audit"
+	"go-boilerplate/models"
 	"go-boilerplate/models/response"
 	"go-boilerplate/services/interfaces"
 
@@ -55,6 +57,22 @@ func AuthMiddleware(authService interfaces.AuthService) gin.HandlerFunc {
 		}
 
 		c.Set("user_id", userID)
+		c.Request = c.Request.WithContext(audit.WithActor(c.Request.Context(), models.Actor{UserID: userID, IP: c.ClientIP()}))
 		c.Next()
 	}
 }
+
+// bearerUserID returns the user of the request's bearer token, for middleware
+// that runs before AuthMiddleware. ok is false without a valid token.
+func bearerUserID(c *gin.Context, authService interfaces.AuthService) (userID uint, ok bool) {
+	tokenString, found := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+	if !found {
+		return 0, false
+	}
+	token, err := authService.ValidateToken(tokenString)
+	if err != nil {
+		return 0, false
+	}
+	userID, err = authService.GetUserIDFromToken(token)
+	return userID, err == nil
+}
@@ -0,0 +1,227 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strconv"
+
+	"go-boilerplate/circuit"
+	"go-boilerplate/config"
+	"go-boilerplate/idempotency"
+	"go-boilerplate/logger"
+	"go-boilerplate/models/response"
+	"go-boilerplate/services/interfaces"
+	"go-boilerplate/tenant"
+	"go-boilerplate/utilities"
+
+	"github.com/gin-gonic/gin"
+)
+
+// IdempotencyKeyHeader is the request header that makes a POST safe to retry.
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+// maxIdempotencyKeyLength bounds the keys clients may send; a UUID needs 36.
+const maxIdempotencyKeyLength = 255
+
+// unrecordedRoutes issue tokens, which must not be kept in Redis for
+// replays. Their Idempotency-Key is ignored.
+var unrecordedRoutes = map[string]bool{
+	"/api/v1/auth/login":              true,
+	"/api/v1/organizations/:id/token": true,
+}
+
+// IdempotencyMiddleware makes POST requests that carry an Idempotency-Key
+// header safe to retry. The first request with a key runs and its response
+// is recorded; retries with the same key and payload get that response
+// again, marked with Idempotent-Replayed: true. A retry while the first
+// request is still running gets 409, and reusing a key for a different
+// payload gets 422. Keys are scoped to the bearer token's user, or the
+// client IP for anonymous clients, and to the tenant. Server errors and
+// routes that issue tokens are not recorded, so retrying them runs the
+// request again.
+func IdempotencyMiddleware(store *idempotency.Store, authService interfaces.AuthService, cfg config.IdempotencyConfig) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		idempotencyKey := c.GetHeader(IdempotencyKeyHeader)
+		if c.Request.Method != http.MethodPost || idempotencyKey == "" || unrecordedRoutes[c.FullPath()] {
+			c.Next()
+			return
+		}
+		ctx := c.Request.Context()
+
+		if len(idempotencyKey) > maxIdempotencyKeyLength {
+			abortIdempotency(c, http.StatusBadRequest, "Invalid Idempotency-Key header",
+				fmt.Sprintf("key must be at most %d characters", maxIdempotencyKeyLength))
+			return
+		}
+
+		// The body is read up front to fingerprint it and replaced so that
+		// the handler can still read it.
+		body, err := io.ReadAll(io.LimitReader(c.Request.Body, cfg.MaxBodyBytes+1))
+		if err != nil {
+			abortIdempotency(c, http.StatusBadRequest, "Failed to read request body", err.Error())
+			return
+		}
+		if int64(len(body)) > cfg.MaxBodyBytes {
+			abortIdempotency(c, http.StatusRequestEntityTooLarge, "Request body too large",
+				fmt.Sprintf("requests with an Idempotency-Key may be at most %d bytes", cfg.MaxBodyBytes))
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		key := utilities.IdempotencyKey(idempotencyScope(c, authService), idempotencyKey)
+		token, stored, err := store.Begin(ctx, key, requestFingerprint(c.Request, body))
+		switch {
+		case errors.Is(err, idempotency.ErrKeyReused):
+			logger.Warn(ctx, "IdempotencyMiddleware: key reused with a different payload", map[string]any{"path": c.Request.URL.Path})
+			abortIdempotency(c, http.StatusUnprocessableEntity, "Idempotency-Key was already used for a different request", err.Error())
+			return
+		case errors.Is(err, idempotency.ErrInFlight):
+			c.Header("Retry-After", "1")
+			abortIdempotency(c, http.StatusConflict, "A request with this Idempotency-Key is still in progress", err.Error())
+			return
+		case err != nil:
+			fields := map[string]any{"path": c.Request.URL.Path, "policy": cfg.FailurePolicy, "error": err.Error()}
+			if cfg.FailurePolicy == config.RedisFailClosed {
+				logger.Warn(ctx, "IdempotencyMiddleware: record unavailable, rejecting", fields)
+				c.AbortWithStatusJSON(http.StatusServiceUnavailable, response.BaseResponse{
+					Success: false,
+					Message: "Service temporarily unavailable",
+				})
+				return
+			}
+			if errors.Is(err, circuit.ErrOpen) {
+				logger.Debug(ctx, "IdempotencyMiddleware: Redis unavailable, running without a record", fields)
+			} else {
+				logger.Warn(ctx, "IdempotencyMiddleware: record unavailable, running without it", fields)
+			}
+			c.Next()
+			return
+		case stored != nil:
+			logger.Info(ctx, "IdempotencyMiddleware: replaying response", map[string]any{"path": c.Request.URL.Path, "status": stored.Status})
+			header := c.Writer.Header()
+			for name, values := range stored.Header {
+				header[name] = values
+			}
+			header.Set("Idempotent-Replayed", "true")
+			c.Status(stored.Status)
+			_, _ = c.Writer.Write(stored.Body)
+			c.Abort()
+			return
+		}
+
+		// The outcome is recorded even if the client has gone away, since
+		// that is when it is most likely to retry.
+		recordCtx := context.WithoutCancel(ctx)
+		done := false
+		defer func() {
+			// Unless a response was recorded, e.g. after a server error or
+			// a panic, the key is freed so that a retry runs again.
+			if !done {
+				if err := store.Abandon(recordCtx, key, token); err != nil {
+					logger.Warn(recordCtx, "IdempotencyMiddleware: failed to release key", map[string]any{"error": err.Error()})
+				}
+			}
+		}()
+
+		before := c.Writer.Header().Clone()
+		recorder := &responseRecorder{ResponseWriter: c.Writer, limit: cfg.MaxResponseBytes}
+		c.Writer = recorder
+		c.Next()
+		c.Writer = recorder.ResponseWriter
+
+		status := c.Writer.Status()
+		if status >= http.StatusInternalServerError || recorder.overflow {
+			if recorder.overflow {
+				logger.Warn(recordCtx, "IdempotencyMiddleware: response too large to record", map[string]any{"path": c.Request.URL.Path, "limit": cfg.MaxResponseBytes})
+			}
+			return
+		}
+
+		// Only headers set downstream are recorded; the rest are set again
+		// for each request, replays included.
+		header := make(http.Header)
+		for name, values := range c.Writer.Header() {
+			if !slices.Equal(before[name], values) {
+				header[name] = values
+			}
+		}
+		err = store.Complete(recordCtx, key, token, &idempotency.Response{
+			Status: status,
+			Header: header,
+			Body:   recorder.body.Bytes(),
+		})
+		if err != nil {
+			logger.Warn(recordCtx, "IdempotencyMiddleware: failed to record response", map[string]any{"path": c.Request.URL.Path, "error": err.Error()})
+			return
+		}
+		done = true
+	}
+}
+
+// idempotencyScope names the client keys belong to, so that clients cannot
+// replay each other's responses by guessing keys.
+func idempotencyScope(c *gin.Context, authService interfaces.AuthService) string {
+	scope := "ip:" + c.ClientIP()
+	if userID, ok := bearerUserID(c, authService); ok {
+		scope = "user:" + strconv.FormatUint(uint64(userID), 10)
+	}
+	if tenantID, ok := tenant.ID(c.Request.Context()); ok {
+		scope += ":tenant:" + strconv.FormatUint(uint64(tenantID), 10)
+	}
+	return scope
+}
+
+// requestFingerprint hashes what makes two requests the same one.
+func requestFingerprint(r *http.Request, body []byte) string {
+	h := sha256.New()
+	io.WriteString(h, r.Method+" "+r.URL.RequestURI()+"\n")
+	io.WriteString(h, r.Header.Get("Content-Type")+"\n")
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func abortIdempotency(c *gin.Context, status int, message, detail string) {
+	c.AbortWithStatusJSON(status, response.BaseResponse{
+		Success: false,
+		Message: message,
+		Error:   detail,
+	})
+}
+
+// responseRecorder keeps a copy of the body written through it, up to limit
+// bytes.
+type responseRecorder struct {
+	gin.ResponseWriter
+	body     bytes.Buffer
+	limit    int
+	overflow bool
+}
+
+func (w *responseRecorder) Write(b []byte) (int, error) {
+	w.record(b)
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *responseRecorder) WriteString(s string) (int, error) {
+	w.record([]byte(s))
+	return w.ResponseWriter.WriteString(s)
+}
+
+func (w *responseRecorder) record(b []byte) {
+	if w.overflow {
+		return
+	}
+	if w.body.Len()+len(b) > w.limit {
+		w.overflow = true
+		w.body.Reset()
+		return
+	}
+	w.body.Write(b)
+}
@@ -0,0 +1,125 @@
+package middleware
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"go-boilerplate/circuit"
+	"go-boilerplate/config"
+	"go-boilerplate/logger"
+	"go-boilerplate/models/response"
+	"go-boilerplate/ratelimit"
+	"go-boilerplate/services/interfaces"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RateLimitMiddleware enforces the configured rate limits. A route's own
+// policy replaces the default one. Responses carry the RateLimit-Limit,
+// RateLimit-Remaining, RateLimit-Reset and RateLimit-Policy headers of the
+// IETF draft, and requests over the limit get 429 with Retry-After. It runs
+// before AuthMiddleware, so limits by user read the bearer token themselves.
+func RateLimitMiddleware(limiter *ratelimit.Limiter, authService interfaces.AuthService, cfg config.RateLimitConfig) gin.HandlerFunc {
+	apiKeys := make(map[string]struct{}, len(cfg.APIKeys))
+	for _, apiKey := range cfg.APIKeys {
+		apiKeys[hashAPIKey(apiKey)] = struct{}{}
+	}
+	return func(c *gin.Context) {
+		if !cfg.Enabled {
+			c.Next()
+			return
+		}
+		ctx := c.Request.Context()
+
+		route := c.Request.Method + " " + c.FullPath()
+		policy, scope := cfg.Default, "default"
+		if p, ok := cfg.Routes[route]; ok {
+			policy, scope = &p, route
+		}
+		if policy == nil {
+			c.Next()
+			return
+		}
+
+		key := policy.Algorithm + ":" + scope + ":" + rateLimitSubject(c, authService, policy.KeyBy, route, cfg.APIKeyHeader, apiKeys)
+		result, err := limiter.Allow(ctx, key, ratelimit.Policy{
+			Algorithm: ratelimit.Algorithm(policy.Algorithm),
+			Limit:     policy.Limit,
+			Window:    policy.Window,
+		})
+		if err != nil {
+			fields := map[string]any{"route": route, "policy": cfg.FailurePolicy, "error": err.Error()}
+			if cfg.FailurePolicy == config.RedisFailClosed {
+				logger.Warn(ctx, "RateLimitMiddleware: limit check failed, rejecting", fields)
+				c.AbortWithStatusJSON(http.StatusServiceUnavailable, response.BaseResponse{
+					Success: false,
+					Message: "Service temporarily unavailable",
+				})
+				return
+			}
+			if errors.Is(err, circuit.ErrOpen) {
+				logger.Debug(ctx, "RateLimitMiddleware: Redis unavailable, not limiting", fields)
+			} else {
+				logger.Warn(ctx, "RateLimitMiddleware: limit check failed, not limiting", fields)
+			}
+			c.Next()
+			return
+		}
+
+		header := c.Writer.Header()
+		header.Set("RateLimit-Limit", strconv.Itoa(policy.Limit))
+		header.Set("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+		header.Set("RateLimit-Reset", strconv.Itoa(ceilSeconds(result.Reset)))
+		header.Set("RateLimit-Policy", fmt.Sprintf("%d;w=%d", policy.Limit, ceilSeconds(policy.Window)))
+		if !result.Allowed {
+			retryAfter := max(ceilSeconds(result.RetryAfter), 1)
+			header.Set("Retry-After", strconv.Itoa(retryAfter))
+			logger.Warn(ctx, "RateLimitMiddleware: limit exceeded", map[string]any{"route": route, "key_by": policy.KeyBy, "retry_after": retryAfter})
+			c.AbortWithStatusJSON(http.StatusTooManyRequests, response.BaseResponse{
+				Success: false,
+				Message: "Too many requests",
+				Error:   fmt.Sprintf("rate limit exceeded, retry in %d seconds", retryAfter),
+			})
+			return
+		}
+		c.Next()
+	}
+}
+
+// rateLimitSubject identifies who a request is counted against. Requests
+// without a valid token or a known API key are counted by IP, so that a
+// client cannot get a fresh limit by sending made-up keys.
+func rateLimitSubject(c *gin.Context, authService interfaces.AuthService, keyBy, route, apiKeyHeader string, apiKeys map[string]struct{}) string {
+	switch keyBy {
+	case config.RateLimitByRoute:
+		return "route:" + route
+	case config.RateLimitByUser:
+		if userID, ok := bearerUserID(c, authService); ok {
+			return "user:" + strconv.FormatUint(uint64(userID), 10)
+		}
+	case config.RateLimitByAPIKey:
+		if apiKey := c.GetHeader(apiKeyHeader); apiKey != "" {
+			hash := hashAPIKey(apiKey)
+			if _, ok := apiKeys[hash]; ok {
+				return "api_key:" + hash
+			}
+		}
+	}
+	return "ip:" + c.ClientIP()
+}
+
+// hashAPIKey hashes an API key so that keys are not readable in Redis.
+func hashAPIKey(apiKey string) string {
+	sum := sha256.Sum256([]byte(apiKey))
+	return hex.EncodeToString(sum[:16])
+}
+
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
@@ -0,0 +1,53 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+
+	"go-boilerplate/models/response"
+	"go-boilerplate/services/interfaces"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequireRole allows the request only if the authenticated user has the given role.
+// The role is read from the database, since a cached user may outlive a
+// demotion. It must run after AuthMiddleware, which sets "user_id".
+func RequireRole(userService interfaces.UserService, role string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, ok := c.Get("user_id")
+		if !ok {
+			c.JSON(http.StatusUnauthorized, response.BaseResponse{
+				Success: false,
+				Message: "User not authenticated",
+			})
+			c.Abort()
+			return
+		}
+
+		userRole, err := userService.GetUserRole(c.Request.Context(), userID.(uint))
+		if err != nil || userRole != role {
+			c.JSON(http.StatusForbidden, response.BaseResponse{
+				Success: false,
+				Message: "Insufficient permissions",
+			})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// RequireSelfOrRole allows the request if the authenticated user is the one named
+// by the ":id" path parameter or has the given role. It must run after AuthMiddleware.
+func RequireSelfOrRole(userService interfaces.UserService, role string) gin.HandlerFunc {
+	requireRole := RequireRole(userService, role)
+	return func(c *gin.Context) {
+		if userID, ok := c.Get("user_id"); ok && c.Param("id") == strconv.FormatUint(uint64(userID.(uint)), 10) {
+			c.Next()
+			return
+		}
+		requireRole(c)
+	}
+}
@@ -0,0 +1,83 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"go-boilerplate/logger"
+	"go-boilerplate/models/response"
+	"go-boilerplate/services/interfaces"
+	"go-boilerplate/tenant"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TenantMiddleware resolves the organization a request acts for and stores it
+// in the request context (see package tenant) and as "tenant_id". The tenant
+// comes from the bearer token's tenant_id claim or from the given header; if
+// both are present they must agree. Selecting a tenant requires a valid token
+// whose user is a member of the organization. Requests without either are
+// passed through unscoped; requests with an invalid token are left to
+// AuthMiddleware.
+func TenantMiddleware(authService interfaces.AuthService, orgService interfaces.OrganizationService, header string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := c.Request.Context()
+
+		var userID, claimTenant uint
+		if tokenString, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer "); ok {
+			if token, err := authService.ValidateToken(tokenString); err == nil {
+				if id, err := authService.GetUserIDFromToken(token); err == nil {
+					userID = id
+					claimTenant = authService.GetTenantIDFromToken(token)
+				}
+			}
+		}
+
+		headerValue := c.GetHeader(header)
+		if headerValue == "" && claimTenant == 0 {
+			c.Next()
+			return
+		}
+		if userID == 0 {
+			abortTenant(c, http.StatusUnauthorized, "Authentication is required to select a tenant")
+			return
+		}
+
+		tenantID := claimTenant
+		if headerValue != "" {
+			id, err := strconv.ParseUint(headerValue, 10, 32)
+			if err != nil || id == 0 {
+				abortTenant(c, http.StatusBadRequest, "Invalid "+header+" header")
+				return
+			}
+			if claimTenant != 0 && uint(id) != claimTenant {
+				abortTenant(c, http.StatusForbidden, "Token is bound to another tenant")
+				return
+			}
+			tenantID = uint(id)
+		}
+
+		member, err := orgService.IsMember(ctx, tenantID, userID)
+		if err != nil {
+			abortTenant(c, http.StatusInternalServerError, "Failed to resolve tenant")
+			return
+		}
+		if !member {
+			logger.Warn(ctx, "TenantMiddleware: not a member", map[string]any{"user_id": userID, "tenant_id": tenantID})
+			abortTenant(c, http.StatusForbidden, "Not a member of this organization")
+			return
+		}
+
+		c.Request = c.Request.WithContext(tenant.WithID(ctx, tenantID))
+		c.Set("tenant_id", tenantID)
+		c.Next()
+	}
+}
+
+func abortTenant(c *gin.Context, status int, message string) {
+	c.AbortWithStatusJSON(status, response.BaseResponse{
+		Success: false,
+		Message: message,
+	})
+}
@@ -0,0 +1,108 @@
+package models
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"time"
+)
+
+// Audit actions recorded by the application.
+const (
+	AuditActionDataExport       = "gdpr.data_export"
+	AuditActionErasureRequested = "gdpr.erasure_requested"
+	AuditActionErasureCancelled = "gdpr.erasure_cancelled"
+	AuditActionErasureCompleted = "gdpr.erasure_completed"
+	AuditActionUserUpdated      = "user.updated"
+	AuditActionUserEmailChanged = "user.email_changed"
+	AuditActionUserDeleted      = "user.deleted"
+	AuditActionUserRestored     = "user.restored"
+	AuditEntityUser             = "user"
+)
+
+// AuditEvent is an append-only record of a security- or compliance-relevant action.
+// Events form a hash chain in id order: each Hash covers the event and the
+// previous event's Hash, so editing, removing or reordering rows breaks the chain.
+type AuditEvent struct {
+	ID uint `json:"id" gorm:"primarykey"`
+	// ActorID is the user who performed the action; nil for system actions such as jobs.
+	ActorID    *uint          `json:"actor_id" gorm:"index"`
+	Action     string         `json:"action" gorm:"not null;index"`
+	EntityType string         `json:"entity_type" gorm:"not null;index:idx_audit_events_entity"`
+	EntityID   uint           `json:"entity_id" gorm:"index:idx_audit_events_entity"`
+	IP         string         `json:"ip,omitempty"`
+	TraceID    string         `json:"trace_id,omitempty" gorm:"index"`
+	Details    map[string]any `json:"details,omitempty" gorm:"type:jsonb;serializer:json"`
+	// Changes holds the field-level diff of a data change, keyed by field name.
+	Changes   map[string]FieldChange `json:"changes,omitempty" gorm:"type:jsonb;serializer:json"`
+	CreatedAt time.Time              `json:"created_at" gorm:"index"`
+	// PrevHash is empty for the first event of the chain. Events recorded before
+	// the chain was introduced have no Hash.
+	PrevHash string `json:"prev_hash"`
+	Hash     string `json:"hash"`
+}
+
+func (AuditEvent) TableName() string {
+	return "audit_events"
+}
+
+// FieldChange is the value of a field before and after a change.
+type FieldChange struct {
+	From any `json:"from"`
+	To   any `json:"to"`
+}
+
+// ComputeHash returns the hex SHA-256 of PrevHash and the event's content.
+// Details and Changes are hashed in the form they have after a round trip
+// through JSON, and CreatedAt in UTC, so an event read back from the database
+// hashes the same as when it was written. CreatedAt must be truncated to
+// microseconds, the precision the database keeps.
+func (e *AuditEvent) ComputeHash() string {
+	content, _ := json.Marshal(struct {
+		ActorID    *uint  `json:"actor_id"`
+		Action     string `json:"action"`
+		EntityType string `json:"entity_type"`
+		EntityID   uint   `json:"entity_id"`
+		IP         string `json:"ip"`
+		TraceID    string `json:"trace_id"`
+		Details    any    `json:"details"`
+		Changes    any    `json:"changes"`
+		CreatedAt  string `json:"created_at"`
+	}{
+		ActorID:    e.ActorID,
+		Action:     e.Action,
+		EntityType: e.EntityType,
+		EntityID:   e.EntityID,
+		IP:         e.IP,
+		TraceID:    e.TraceID,
+		Details:    roundTripJSON(e.Details),
+		Changes:    roundTripJSON(e.Changes),
+		CreatedAt:  e.CreatedAt.UTC().Format(time.RFC3339Nano),
+	})
+
+	h := sha256.New()
+	h.Write([]byte(e.PrevHash))
+	h.Write([]byte{'\n'})
+	h.Write(content)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// roundTripJSON returns v as it decodes from its JSON encoding, e.g. with
+// numbers as float64 and times as strings.
+func roundTripJSON(v any) any {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil
+	}
+	var out any
+	if err := json.Unmarshal(data, &out); err != nil {
+		return nil
+	}
+	return out
+}
+
+// Actor identifies who performs an audited action. A zero UserID means the system.
+type Actor struct {
+	UserID uint
+	IP     string
+}
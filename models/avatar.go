@@ -0,0 +1,29 @@
+package models
+
+import "fmt"
+
+// AvatarKeyPrefix is the blob store prefix under which avatar thumbnails are stored.
+const AvatarKeyPrefix = "avatars/"
+
+// Avatar describes the thumbnails stored for a user's profile picture. It is
+// kept as JSON on the users row; each upload gets a fresh Prefix, so stored
+// files never change and can be cached indefinitely.
+type Avatar struct {
+	Prefix string `json:"prefix"`
+	Ext    string `json:"ext"`
+	Sizes  []int  `json:"sizes"`
+}
+
+// Key returns the blob store key of the thumbnail with the given edge length.
+func (a *Avatar) Key(size int) string {
+	return fmt.Sprintf("%s/%d%s", a.Prefix, size, a.Ext)
+}
+
+// Keys returns the blob store keys of all thumbnails.
+func (a *Avatar) Keys() []string {
+	keys := make([]string, len(a.Sizes))
+	for i, size := range a.Sizes {
+		keys[i] = a.Key(size)
+	}
+	return keys
+}
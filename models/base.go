@@ -1,8 +1,10 @@
 package models
 
 import (
-	"gorm.io/gorm"
+	"slices"
 	"time"
+
+	"gorm.io/gorm"
 )
 
 type BaseModel struct {
@@ -10,4 +12,15 @@ type BaseModel struct {
 	CreatedAt time.Time      `json:"created_at"`
 	UpdatedAt time.Time      `json:"updated_at"`
 	DeletedAt gorm.DeletedAt `json:"-" gorm:"index"`
+	// Version is incremented on every write and backs optimistic concurrency (ETag / If-Match).
+	Version uint `json:"version" gorm:"not null;default:1"`
+}
+
+// VersionMatch lists the versions a conditional write accepts, as sent in
+// If-Match. A nil VersionMatch ("If-Match: *") accepts any current version.
+type VersionMatch []uint
+
+// Matches reports whether a write to an entity at version may proceed.
+func (m VersionMatch) Matches(version uint) bool {
+	return m == nil || slices.Contains(m, version)
 }
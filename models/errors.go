@@ -0,0 +1,49 @@
+package models
+
+import "errors"
+
+// Sentinel errors shared by repositories, services and handlers so that
+// handlers can map them to HTTP status codes with errors.Is.
+var (
+	// ErrUserNotFound is returned when a user does not exist (or is not in the expected state).
+	ErrUserNotFound = errors.New("user not found")
+	// ErrVersionConflict is returned when a write targets a stale version of a record.
+	ErrVersionConflict = errors.New("resource has been modified by another request")
+	// ErrEmailTaken is returned when an email is already used by a live user.
+	ErrEmailTaken = errors.New("user with this email already exists")
+	// ErrNotFound is a generic not-found error for non-user resources.
+	ErrNotFound = errors.New("resource not found")
+	// ErrUnsupportedFormat is returned for unknown import/export formats.
+	ErrUnsupportedFormat = errors.New("unsupported format")
+	// ErrUnsupportedMediaType is returned when uploaded content is not an allowed type.
+	ErrUnsupportedMediaType = errors.New("unsupported media type")
+	// ErrFileTooLarge is returned when an upload exceeds the configured size limit.
+	ErrFileTooLarge = errors.New("file too large")
+	// ErrInvalidImage is returned when uploaded image data cannot be decoded.
+	ErrInvalidImage = errors.New("invalid image")
+	// ErrOffsetMismatch is returned when a resumable upload chunk does not start at the current offset.
+	ErrOffsetMismatch = errors.New("upload offset does not match")
+	// ErrLocked is returned when a resource is being modified by another request.
+	ErrLocked = errors.New("resource is locked by another request")
+	// ErrInvalidToken is returned when a signed link is malformed, expired or superseded.
+	ErrInvalidToken = errors.New("invalid or expired token")
+	// ErrSlugTaken is returned when an organization slug is already in use.
+	ErrSlugTaken = errors.New("slug already taken")
+	// ErrInvalidSetting is returned for unknown setting keys and values that fail validation.
+	ErrInvalidSetting = errors.New("invalid setting")
+	// ErrTeamExists is returned when a team name is already used in the organization.
+	ErrTeamExists = errors.New("team with this name already exists")
+	// ErrForbidden is returned when the caller's role does not allow an action.
+	ErrForbidden = errors.New("insufficient permissions")
+	// ErrAlreadyMember is returned when inviting or adding someone who already belongs.
+	ErrAlreadyMember = errors.New("already a member")
+	// ErrAccountDetailsRequired is returned when accepting an invitation would
+	// create an account but no name or password was given.
+	ErrAccountDetailsRequired = errors.New("name and password are required to create an account")
+	// ErrURLNotAllowed is returned when a webhook URL does not resolve to
+	// public addresses only.
+	ErrURLNotAllowed = errors.New("URL must point to a public address")
+	// ErrUnavailable is returned when a required dependency such as Redis is
+	// down and the feature is configured to fail closed.
+	ErrUnavailable = errors.New("service temporarily unavailable")
+)
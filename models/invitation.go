@@ -0,0 +1,56 @@
+package models
+
+import "time"
+
+// Invitation statuses, derived from the timestamps of an Invitation.
+const (
+	InvitationStatusPending  = "pending"
+	InvitationStatusAccepted = "accepted"
+	InvitationStatusRevoked  = "revoked"
+	InvitationStatusExpired  = "expired"
+)
+
+// Invitation asks someone, by email address, to join an organization and
+// optionally one of its teams. The invitee proves ownership of the address with
+// a signed link; the row records whether the invitation is still usable.
+type Invitation struct {
+	ID             uint          `json:"id" gorm:"primarykey"`
+	OrganizationID uint          `json:"organization_id" gorm:"not null;index"`
+	Organization   *Organization `json:"-" gorm:"constraint:OnDelete:CASCADE"`
+	// TeamID, if set, also adds the invitee to that team.
+	TeamID *uint  `json:"team_id,omitempty"`
+	Team   *Team  `json:"-" gorm:"constraint:OnDelete:SET NULL"`
+	Email  string `json:"email" gorm:"not null;index"`
+	// Role is the membership role granted on acceptance.
+	Role         string     `json:"role" gorm:"not null;default:member"`
+	InvitedByID  *uint      `json:"invited_by_id,omitempty"`
+	InvitedBy    *User      `json:"-" gorm:"constraint:OnDelete:SET NULL"`
+	ExpiresAt    time.Time  `json:"expires_at" gorm:"not null"`
+	AcceptedAt   *time.Time `json:"accepted_at,omitempty"`
+	AcceptedByID *uint      `json:"accepted_by_id,omitempty"`
+	AcceptedBy   *User      `json:"-" gorm:"constraint:OnDelete:SET NULL"`
+	RevokedAt    *time.Time `json:"revoked_at,omitempty"`
+	CreatedAt    time.Time  `json:"created_at"`
+}
+
+func (Invitation) TableName() string {
+	return "invitations"
+}
+
+func (Invitation) TenantCondition() string {
+	return "invitations.organization_id = ?"
+}
+
+// Status reports the invitation's state at the given time.
+func (i *Invitation) Status(now time.Time) string {
+	switch {
+	case i.AcceptedAt != nil:
+		return InvitationStatusAccepted
+	case i.RevokedAt != nil:
+		return InvitationStatusRevoked
+	case !now.Before(i.ExpiresAt):
+		return InvitationStatusExpired
+	default:
+		return InvitationStatusPending
+	}
+}
@@ -0,0 +1,41 @@
+package models
+
+import "time"
+
+// Authentication methods recorded with login events.
+const (
+	AuthMethodPassword = "password"
+)
+
+// Reasons a login attempt failed.
+const (
+	LoginFailureUnknownEmail    = "unknown_email"
+	LoginFailureInvalidPassword = "invalid_password"
+)
+
+// LoginEvent records a login attempt, successful or not.
+type LoginEvent struct {
+	ID uint `json:"id" gorm:"primarykey"`
+	// UserID is nil when no account exists for Email.
+	UserID        *uint     `json:"user_id,omitempty" gorm:"index:idx_login_events_user_created"`
+	User          *User     `json:"-" gorm:"constraint:OnDelete:CASCADE"`
+	Email         string    `json:"email" gorm:"not null"`
+	Success       bool      `json:"success" gorm:"not null"`
+	FailureReason string    `json:"failure_reason,omitempty"`
+	AuthMethod    string    `json:"auth_method" gorm:"not null"`
+	MFAUsed       bool      `json:"mfa_used" gorm:"not null;default:false"`
+	IP            string    `json:"ip"`
+	UserAgent     string    `json:"user_agent"`
+	TraceID       string    `json:"trace_id,omitempty"`
+	CreatedAt     time.Time `json:"created_at" gorm:"index:idx_login_events_user_created;index"`
+}
+
+func (LoginEvent) TableName() string {
+	return "login_events"
+}
+
+// ClientInfo describes the client that sent a request.
+type ClientInfo struct {
+	IP        string
+	UserAgent string
+}
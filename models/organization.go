@@ -0,0 +1,44 @@
+package models
+
+import "time"
+
+// Membership roles within an organization.
+const (
+	MembershipRoleOwner  = "owner"
+	MembershipRoleAdmin  = "admin"
+	MembershipRoleMember = "member"
+)
+
+// Organization is a tenant. Users belong to organizations through memberships.
+type Organization struct {
+	BaseModel
+	Name string `json:"name" gorm:"not null"`
+	Slug string `json:"slug" gorm:"uniqueIndex:idx_organizations_slug_active,where:deleted_at IS NULL;not null"`
+}
+
+func (Organization) TableName() string {
+	return "organizations"
+}
+
+// Membership links a user to an organization with a role.
+type Membership struct {
+	ID             uint          `json:"id" gorm:"primarykey"`
+	OrganizationID uint          `json:"organization_id" gorm:"not null;uniqueIndex:idx_memberships_org_user"`
+	Organization   *Organization `json:"-" gorm:"constraint:OnDelete:CASCADE"`
+	UserID         uint          `json:"user_id" gorm:"not null;uniqueIndex:idx_memberships_org_user;index"`
+	User           *User         `json:"-" gorm:"constraint:OnDelete:CASCADE"`
+	Role           string        `json:"role" gorm:"not null;default:member"`
+	CreatedAt      time.Time     `json:"created_at"`
+}
+
+func (Membership) TableName() string {
+	return "memberships"
+}
+
+// TenantScoped is implemented by models whose rows are only visible within an
+// organization. TenantCondition returns a SQL condition with a single
+// placeholder for the organization ID; it is added to every query, update and
+// delete on the model made with a tenant in the context.
+type TenantScoped interface {
+	TenantCondition() string
+}
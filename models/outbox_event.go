@@ -0,0 +1,34 @@
+package models
+
+import "time"
+
+// Aggregate and event types published through the outbox.
+const (
+	AggregateUser = "user"
+
+	EventUserCreated = "UserCreated"
+	EventUserUpdated = "UserUpdated"
+	EventUserDeleted = "UserDeleted"
+)
+
+// OutboxEvent is a domain event stored in the same transaction as the change it
+// describes. The outbox relay publishes it to a Redis stream afterwards.
+type OutboxEvent struct {
+	ID            uint   `json:"id" gorm:"primarykey"`
+	AggregateType string `json:"aggregate_type" gorm:"not null"`
+	AggregateID   uint   `json:"aggregate_id" gorm:"not null"`
+	EventType     string `json:"event_type" gorm:"not null"`
+	// TenantID is the organization the change was made in, if any.
+	TenantID  *uint          `json:"tenant_id,omitempty"`
+	Payload   map[string]any `json:"payload" gorm:"type:jsonb;serializer:json"`
+	TraceID   string         `json:"trace_id,omitempty"`
+	CreatedAt time.Time      `json:"created_at"`
+	// PublishedAt is nil until the relay has delivered the event.
+	PublishedAt *time.Time `json:"published_at,omitempty" gorm:"index:idx_outbox_unpublished,where:published_at IS NULL"`
+	Attempts    int        `json:"attempts" gorm:"not null;default:0"`
+	LastError   string     `json:"last_error,omitempty"`
+}
+
+func (OutboxEvent) TableName() string {
+	return "outbox"
+}
@@ -0,0 +1,14 @@
+package request
+
+import "time"
+
+// AuditFilter holds the query filters of the audit log endpoint.
+type AuditFilter struct {
+	ActorID       *uint      `form:"actor_id"`
+	Action        string     `form:"action" validate:"omitempty,max=100"`
+	EntityType    string     `form:"entity_type" validate:"omitempty,max=100"`
+	EntityID      *uint      `form:"entity_id"`
+	TraceID       string     `form:"trace_id" validate:"omitempty,max=100"`
+	CreatedAfter  *time.Time `form:"created_after" time_format:"2006-01-02T15:04:05Z07:00"`
+	CreatedBefore *time.Time `form:"created_before" time_format:"2006-01-02T15:04:05Z07:00"`
+}
@@ -0,0 +1,7 @@
+package request
+
+// Supported bulk import formats. Each CSV row or NDJSON line maps to a CreateUserRequest.
+const (
+	ImportFormatCSV    = "csv"
+	ImportFormatNDJSON = "ndjson"
+)
@@ -0,0 +1,31 @@
+package request
+
+type CreateOrganizationRequest struct {
+	Name string `json:"name" validate:"required,min=2,max=100"`
+	Slug string `json:"slug" validate:"required,min=2,max=63,slug"`
+}
+
+type CreateTeamRequest struct {
+	Name string `json:"name" validate:"required,min=2,max=100"`
+}
+
+type AddTeamMemberRequest struct {
+	UserID uint   `json:"user_id" validate:"required"`
+	Role   string `json:"role" validate:"omitempty,oneof=lead member"`
+}
+
+// CreateInvitationRequest invites an email address to the organization. Owners
+// cannot be invited; ownership is only granted on creation.
+type CreateInvitationRequest struct {
+	Email  string `json:"email" validate:"required,email"`
+	Role   string `json:"role" validate:"omitempty,oneof=admin member"`
+	TeamID *uint  `json:"team_id,omitempty"`
+}
+
+// AcceptInvitationRequest accepts an invitation. Name and password are only
+// used, and then required, when no account exists for the invited address.
+type AcceptInvitationRequest struct {
+	Token    string `json:"token" validate:"required"`
+	Name     string `json:"name" validate:"omitempty,min=2,max=100"`
+	Password string `json:"password" validate:"omitempty,min=6"`
+}
@@ -0,0 +1,59 @@
+package request
+
+import (
+	"errors"
+	"strings"
+	"time"
+)
+
+// UserFilter holds the query filters shared by the user list and export endpoints.
+type UserFilter struct {
+	Search        string     `form:"search" validate:"omitempty,max=100"`
+	Role          string     `form:"role" validate:"omitempty,oneof=user admin"`
+	CreatedAfter  *time.Time `form:"created_after" time_format:"2006-01-02T15:04:05Z07:00"`
+	CreatedBefore *time.Time `form:"created_before" time_format:"2006-01-02T15:04:05Z07:00"`
+}
+
+// Supported user export formats.
+const (
+	ExportFormatCSV    = "csv"
+	ExportFormatNDJSON = "ndjson"
+	ExportFormatXLSX   = "xlsx"
+)
+
+// UserExportColumns lists the columns that may be exported, in default order.
+var UserExportColumns = []string{"id", "name", "email", "role", "version", "created_at", "updated_at"}
+
+type UserExportRequest struct {
+	UserFilter
+	Format string `form:"format" validate:"required,oneof=csv ndjson xlsx"`
+	// Columns is a comma-separated subset of UserExportColumns; empty means all.
+	Columns string `form:"columns"`
+}
+
+// ColumnList parses Columns, rejecting unknown or repeated names.
+func (r *UserExportRequest) ColumnList() ([]string, error) {
+	if strings.TrimSpace(r.Columns) == "" {
+		return UserExportColumns, nil
+	}
+
+	allowed := make(map[string]bool, len(UserExportColumns))
+	for _, col := range UserExportColumns {
+		allowed[col] = true
+	}
+
+	var columns []string
+	seen := make(map[string]bool)
+	for _, col := range strings.Split(r.Columns, ",") {
+		col = strings.ToLower(strings.TrimSpace(col))
+		if !allowed[col] {
+			return nil, errors.New("unknown export column: " + col)
+		}
+		if seen[col] {
+			return nil, errors.New("duplicate export column: " + col)
+		}
+		seen[col] = true
+		columns = append(columns, col)
+	}
+	return columns, nil
+}
@@ -0,0 +1,21 @@
+package request
+
+// CreateWebhookRequest subscribes an endpoint to the organization's events. An
+// empty EventTypes subscribes to all events, including ones added later. The
+// URL's host must resolve to public addresses only, unless
+// WEBHOOK_ALLOW_PRIVATE_NETWORKS is set.
+type CreateWebhookRequest struct {
+	URL         string   `json:"url" validate:"required,http_url,max=2048"`
+	Description string   `json:"description" validate:"max=255"`
+	EventTypes  []string `json:"event_types" validate:"omitempty,dive,oneof=UserCreated UserUpdated UserDeleted"`
+}
+
+// UpdateWebhookRequest changes the fields that are present. RotateSecret
+// replaces the signing secret; the new one is returned once.
+type UpdateWebhookRequest struct {
+	URL          *string   `json:"url" validate:"omitempty,http_url,max=2048"`
+	Description  *string   `json:"description" validate:"omitempty,max=255"`
+	EventTypes   *[]string `json:"event_types" validate:"omitempty,dive,oneof=UserCreated UserUpdated UserDeleted"`
+	Active       *bool     `json:"active"`
+	RotateSecret bool      `json:"rotate_secret"`
+}
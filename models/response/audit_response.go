@@ -0,0 +1,15 @@
+package response
+
+// AuditVerifyResponse reports the result of checking the audit hash chain.
+type AuditVerifyResponse struct {
+	Valid bool `json:"valid"`
+	// Checked is the number of chained events that were verified.
+	Checked int64 `json:"checked"`
+	// Unchained counts events recorded before the hash chain was introduced.
+	Unchained int64 `json:"unchained"`
+	// HeadHash is the hash of the newest event. Recording it elsewhere allows
+	// detecting removal of the newest events, which the chain alone cannot.
+	HeadHash       string `json:"head_hash,omitempty"`
+	FirstInvalidID *uint  `json:"first_invalid_id,omitempty"`
+	Reason         string `json:"reason,omitempty"`
+}
@@ -0,0 +1,11 @@
+package response
+
+import "time"
+
+// SessionExport describes a login session in a data export. The token itself is
+// not included; TokenSHA256 lets the owner match it against their clients.
+type SessionExport struct {
+	TokenSHA256 string     `json:"token_sha256"`
+	IssuedAt    *time.Time `json:"issued_at,omitempty"`
+	ExpiresAt   *time.Time `json:"expires_at,omitempty"`
+}
@@ -0,0 +1,35 @@
+package response
+
+import "time"
+
+type ImportRowError struct {
+	Row   int    `json:"row"`
+	Email string `json:"email,omitempty"`
+	Error string `json:"error"`
+}
+
+type ImportReport struct {
+	DryRun          bool             `json:"dry_run"`
+	TotalRows       int              `json:"total_rows"`
+	Imported        int              `json:"imported"`
+	Failed          int              `json:"failed"`
+	Errors          []ImportRowError `json:"errors"`
+	ErrorsTruncated bool             `json:"errors_truncated,omitempty"`
+}
+
+const (
+	ImportJobQueued    = "queued"
+	ImportJobRunning   = "running"
+	ImportJobCompleted = "completed"
+	ImportJobFailed    = "failed"
+)
+
+type ImportJobResponse struct {
+	ID        string        `json:"id"`
+	Status    string        `json:"status"`
+	Format    string        `json:"format"`
+	Report    *ImportReport `json:"report,omitempty"`
+	Error     string        `json:"error,omitempty"`
+	CreatedAt time.Time     `json:"created_at"`
+	UpdatedAt time.Time     `json:"updated_at"`
+}
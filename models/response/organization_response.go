@@ -0,0 +1,61 @@
+package response
+
+import "time"
+
+// OrganizationResponse describes an organization along with the caller's role in it.
+type OrganizationResponse struct {
+	ID        uint      `json:"id"`
+	Name      string    `json:"name"`
+	Slug      string    `json:"slug"`
+	Role      string    `json:"role,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// TenantTokenResponse carries a token bound to an organization.
+type TenantTokenResponse struct {
+	Token          string `json:"token"`
+	OrganizationID uint   `json:"organization_id"`
+}
+
+// MemberResponse describes a member of an organization or team.
+type MemberResponse struct {
+	UserID   uint      `json:"user_id"`
+	Name     string    `json:"name"`
+	Email    string    `json:"email"`
+	Role     string    `json:"role"`
+	JoinedAt time.Time `json:"joined_at"`
+}
+
+type TeamResponse struct {
+	ID             uint      `json:"id"`
+	OrganizationID uint      `json:"organization_id"`
+	Name           string    `json:"name"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+type InvitationResponse struct {
+	ID               uint       `json:"id"`
+	OrganizationID   uint       `json:"organization_id"`
+	OrganizationName string     `json:"organization_name,omitempty"`
+	TeamID           *uint      `json:"team_id,omitempty"`
+	TeamName         string     `json:"team_name,omitempty"`
+	Email            string     `json:"email"`
+	Role             string     `json:"role"`
+	Status           string     `json:"status"`
+	ExpiresAt        time.Time  `json:"expires_at"`
+	AcceptedAt       *time.Time `json:"accepted_at,omitempty"`
+	CreatedAt        time.Time  `json:"created_at"`
+}
+
+// InvitationPreviewResponse is shown to an invitee before accepting. AccountExists
+// tells whether accepting links an existing account or needs a name and password.
+type InvitationPreviewResponse struct {
+	InvitationResponse
+	AccountExists bool `json:"account_exists"`
+}
+
+type AcceptInvitationResponse struct {
+	Organization   *OrganizationResponse `json:"organization"`
+	User           *UserResponse         `json:"user"`
+	AccountCreated bool                  `json:"account_created"`
+}
@@ -0,0 +1,11 @@
+package response
+
+import "time"
+
+// SettingsResponse carries the effective value of every known setting: the
+// user's own value where one is set, the default otherwise.
+type SettingsResponse struct {
+	SchemaVersion int            `json:"schema_version"`
+	Settings      map[string]any `json:"settings"`
+	UpdatedAt     *time.Time     `json:"updated_at,omitempty"`
+}
@@ -2,12 +2,24 @@ package response
 
 import "time"
 
+// UserResponse is the public view of a user. AvatarURL points to the largest
+// avatar thumbnail and AvatarURLs lists every thumbnail keyed by edge length in px.
+// ErasureScheduledAt is set while an erasure request can still be cancelled.
 type UserResponse struct {
-	ID        uint      `json:"id"`
-	Name      string    `json:"name"`
-	Email     string    `json:"email"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+	ID                 uint              `json:"id"`
+	Name               string            `json:"name"`
+	Email              string            `json:"email"`
+	PendingEmail       string            `json:"pending_email,omitempty"`
+	Role               string            `json:"role"`
+	AvatarURL          string            `json:"avatar_url,omitempty"`
+	AvatarURLs         map[string]string `json:"avatar_urls,omitempty"`
+	Version            uint              `json:"version"`
+	CreatedAt          time.Time         `json:"created_at"`
+	UpdatedAt          time.Time         `json:"updated_at"`
+	DeletedAt          *time.Time        `json:"deleted_at,omitempty"`
+	ErasureScheduledAt *time.Time        `json:"erasure_scheduled_at,omitempty"`
+	LastLoginAt        *time.Time        `json:"last_login_at,omitempty"`
+	LastSeenAt         *time.Time        `json:"last_seen_at,omitempty"`
 }
 
 type LoginResponse struct {
@@ -0,0 +1,17 @@
+package response
+
+import "time"
+
+// WebhookResponse describes a webhook subscription. Secret is only set when the
+// subscription is created or its secret rotated.
+type WebhookResponse struct {
+	ID             uint      `json:"id"`
+	OrganizationID uint      `json:"organization_id"`
+	URL            string    `json:"url"`
+	Description    string    `json:"description,omitempty"`
+	EventTypes     []string  `json:"event_types"`
+	Active         bool      `json:"active"`
+	Secret         string    `json:"secret,omitempty"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
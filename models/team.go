@@ -0,0 +1,44 @@
+package models
+
+import "time"
+
+// Team roles.
+const (
+	TeamRoleLead   = "lead"
+	TeamRoleMember = "member"
+)
+
+// Team groups members of an organization. Names are unique within the organization.
+type Team struct {
+	BaseModel
+	OrganizationID uint          `json:"organization_id" gorm:"not null;uniqueIndex:idx_teams_org_name_active,where:deleted_at IS NULL"`
+	Organization   *Organization `json:"-" gorm:"constraint:OnDelete:CASCADE"`
+	Name           string        `json:"name" gorm:"not null;uniqueIndex:idx_teams_org_name_active,where:deleted_at IS NULL"`
+}
+
+func (Team) TableName() string {
+	return "teams"
+}
+
+func (Team) TenantCondition() string {
+	return "teams.organization_id = ?"
+}
+
+// TeamMember links a member of the organization to one of its teams.
+type TeamMember struct {
+	ID        uint      `json:"id" gorm:"primarykey"`
+	TeamID    uint      `json:"team_id" gorm:"not null;uniqueIndex:idx_team_members_team_user"`
+	Team      *Team     `json:"-" gorm:"constraint:OnDelete:CASCADE"`
+	UserID    uint      `json:"user_id" gorm:"not null;uniqueIndex:idx_team_members_team_user;index"`
+	User      *User     `json:"-" gorm:"constraint:OnDelete:CASCADE"`
+	Role      string    `json:"role" gorm:"not null;default:member"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func (TeamMember) TableName() string {
+	return "team_members"
+}
+
+func (TeamMember) TenantCondition() string {
+	return "team_members.team_id IN (SELECT id FROM teams WHERE organization_id = ?)"
+}
@@ -0,0 +1,16 @@
+package models
+
+import "time"
+
+// TusUpload is the state of a resumable (tus) upload. It is stored as JSON in
+// Redis and expires together with the upload.
+type TusUpload struct {
+	ID        string            `json:"id"`
+	UserID    uint              `json:"user_id"`
+	Length    int64             `json:"length"`
+	Offset    int64             `json:"offset"`
+	Metadata  map[string]string `json:"metadata,omitempty"`
+	Completed bool              `json:"completed"`
+	CreatedAt time.Time         `json:"created_at"`
+	ExpiresAt time.Time         `json:"expires_at"`
+}
@@ -1,12 +1,41 @@
 package models
 
+import "time"
+
+const (
+	RoleUser  = "user"
+	RoleAdmin = "admin"
+)
+
+// User emails are unique among live rows only (partial index on deleted_at IS NULL),
+// so a soft-deleted user's address can be registered again.
 type User struct {
 	BaseModel
 	Name     string `json:"name" gorm:"not null" validate:"required,min=2,max=100"`
-	Email    string `json:"email" gorm:"uniqueIndex;not null" validate:"required,email"`
+	Email    string `json:"email" gorm:"uniqueIndex:idx_users_email_active,where:deleted_at IS NULL;not null" validate:"required,email"`
 	Password string `json:"-" gorm:"not null" validate:"required,min=6"`
+	Role     string `json:"role" gorm:"not null;default:user"`
+	// PendingEmail holds a requested new address until it is confirmed via a signed link.
+	PendingEmail string `json:"pending_email,omitempty"`
+	// Avatar is nil until the user uploads a profile picture.
+	Avatar *Avatar `json:"-" gorm:"type:jsonb;serializer:json"`
+	// ErasureScheduledAt is set while a right-to-erasure request is in its grace period.
+	ErasureScheduledAt *time.Time `json:"-" gorm:"index"`
+	// ErasedAt is set once the user's personal data has been anonymized.
+	ErasedAt *time.Time `json:"-"`
+	// LastLoginAt and LastSeenAt are written without bumping Version, as they
+	// are not edits of the user. LastSeenAt is updated at most once per
+	// LAST_SEEN_INTERVAL.
+	LastLoginAt *time.Time `json:"last_login_at,omitempty"`
+	LastSeenAt  *time.Time `json:"last_seen_at,omitempty"`
 }
 
 func (User) TableName() string {
 	return "users"
 }
+
+// TenantCondition limits users to members of the organization. A user can be a
+// member of several organizations and is visible in each of them.
+func (User) TenantCondition() string {
+	return "users.id IN (SELECT user_id FROM memberships WHERE organization_id = ?)"
+}
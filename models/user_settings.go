@@ -0,0 +1,18 @@
+package models
+
+import "time"
+
+// UserSettings holds the preferences a user has changed from their defaults.
+// Values only contains overrides, so new defaults reach users who never touched
+// a setting. SchemaVersion records the settings schema the values were written with.
+type UserSettings struct {
+	UserID        uint           `gorm:"primarykey;autoIncrement:false"`
+	User          *User          `gorm:"constraint:OnDelete:CASCADE"`
+	SchemaVersion int            `gorm:"not null;default:1"`
+	Values        map[string]any `gorm:"type:jsonb;serializer:json;not null"`
+	UpdatedAt     time.Time
+}
+
+func (UserSettings) TableName() string {
+	return "user_settings"
+}
@@ -0,0 +1,75 @@
+package models
+
+import (
+	"slices"
+	"time"
+)
+
+// Webhook delivery states. A delivery that keeps failing ends up dead, the
+// dead-letter state, until it is redelivered by hand.
+const (
+	WebhookDeliveryPending   = "pending"
+	WebhookDeliverySucceeded = "succeeded"
+	WebhookDeliveryDead      = "dead"
+)
+
+// WebhookSubscription sends the organization's events to an HTTP endpoint.
+type WebhookSubscription struct {
+	BaseModel
+	OrganizationID uint          `json:"organization_id" gorm:"not null;index"`
+	Organization   *Organization `json:"-" gorm:"constraint:OnDelete:CASCADE"`
+	URL            string        `json:"url" gorm:"not null"`
+	Description    string        `json:"description"`
+	// EventTypes limits the subscription to these events; empty means all of them.
+	EventTypes []string `json:"event_types" gorm:"type:jsonb;serializer:json"`
+	// Secret signs the deliveries. It is only shown when created or rotated.
+	Secret string `json:"-" gorm:"not null"`
+	Active bool   `json:"active" gorm:"not null;default:true"`
+}
+
+func (WebhookSubscription) TableName() string {
+	return "webhook_subscriptions"
+}
+
+func (WebhookSubscription) TenantCondition() string {
+	return "webhook_subscriptions.organization_id = ?"
+}
+
+// Matches reports whether the subscription wants events of eventType.
+func (s *WebhookSubscription) Matches(eventType string) bool {
+	return len(s.EventTypes) == 0 || slices.Contains(s.EventTypes, eventType)
+}
+
+// WebhookDelivery is one event to be sent to one subscription, with the
+// outcome of its latest attempt. An event is delivered at most once per
+// subscription, apart from manual redeliveries.
+type WebhookDelivery struct {
+	ID             uint                 `json:"id" gorm:"primarykey"`
+	SubscriptionID uint                 `json:"subscription_id" gorm:"not null;uniqueIndex:idx_webhook_deliveries_sub_event;index:idx_webhook_deliveries_sub_created"`
+	Subscription   *WebhookSubscription `json:"-" gorm:"constraint:OnDelete:CASCADE"`
+	OrganizationID uint                 `json:"organization_id" gorm:"not null"`
+	EventID        string               `json:"event_id" gorm:"not null;uniqueIndex:idx_webhook_deliveries_sub_event"`
+	EventType      string               `json:"event_type" gorm:"not null"`
+	// Body is the exact request body, so every attempt sends the same bytes.
+	Body    string `json:"body" gorm:"type:text;not null"`
+	TraceID string `json:"trace_id,omitempty"`
+	Status  string `json:"status" gorm:"not null;default:pending"`
+	// Attempts counts the attempts since the delivery was created or redelivered.
+	Attempts       int        `json:"attempts" gorm:"not null;default:0"`
+	NextAttemptAt  *time.Time `json:"next_attempt_at,omitempty" gorm:"index:idx_webhook_deliveries_due,where:status = 'pending'"`
+	LastAttemptAt  *time.Time `json:"last_attempt_at,omitempty"`
+	LastStatusCode int        `json:"last_status_code,omitempty"`
+	LastError      string     `json:"last_error,omitempty"`
+	LastDurationMs int64      `json:"last_duration_ms,omitempty"`
+	DeliveredAt    *time.Time `json:"delivered_at,omitempty"`
+	CreatedAt      time.Time  `json:"created_at" gorm:"index:idx_webhook_deliveries_sub_created"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+}
+
+func (WebhookDelivery) TableName() string {
+	return "webhook_deliveries"
+}
+
+func (WebhookDelivery) TenantCondition() string {
+	return "webhook_deliveries.organization_id = ?"
+}
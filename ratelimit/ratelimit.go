@@ -0,0 +1,158 @@
+// Package ratelimit implements request rate limits in Redis. Each check is a
+// single Lua script, so concurrent requests on any number of instances see
+// one consistent count, and the scripts read the clock from Redis so that
+// instances with skewed clocks agree too.
+//
+// Two algorithms are available:
+//
+//   - SlidingWindow allows Limit requests in any Window-long period. It keeps
+//     the timestamp of every allowed request in a sorted set, which is exact
+//     but costs memory per request.
+//   - TokenBucket holds up to Limit tokens and refills them at Limit per
+//     Window. It allows bursts of Limit and then a steady rate, in constant
+//     memory.
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	repoif "go-boilerplate/repository/interfaces"
+	"go-boilerplate/utilities"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Algorithm names a rate limiting algorithm.
+type Algorithm string
+
+const (
+	SlidingWindow Algorithm = "sliding_window"
+	TokenBucket   Algorithm = "token_bucket"
+)
+
+// Policy is a limit of Limit requests per Window.
+type Policy struct {
+	Algorithm Algorithm
+	Limit     int
+	Window    time.Duration
+}
+
+// Result is the outcome of a check.
+type Result struct {
+	Allowed bool
+	// Remaining is how many more requests would be allowed right now.
+	Remaining int
+	// Reset is how long until the full quota is available again.
+	Reset time.Duration
+	// RetryAfter is how long until the next request would be allowed; zero
+	// when this one was.
+	RetryAfter time.Duration
+}
+
+// slidingWindowScript records a request at the current time in the sorted
+// set KEYS[1] if fewer than ARGV[2] requests were recorded in the last
+// ARGV[1] milliseconds. ARGV[3] makes the member unique. It returns
+// {allowed, remaining, reset ms, retry after ms}.
+var slidingWindowScript = redis.NewScript(`
+local t = redis.call('TIME')
+local now = tonumber(t[1]) * 1000 + math.floor(tonumber(t[2]) / 1000)
+local window = tonumber(ARGV[1])
+local limit = tonumber(ARGV[2])
+
+redis.call('ZREMRANGEBYSCORE', KEYS[1], '-inf', now - window)
+local count = redis.call('ZCARD', KEYS[1])
+local allowed = 0
+if count < limit then
+	redis.call('ZADD', KEYS[1], now, now .. ':' .. ARGV[3])
+	count = count + 1
+	allowed = 1
+end
+redis.call('PEXPIRE', KEYS[1], window)
+
+local retry = 0
+local reset = 0
+local oldest = redis.call('ZRANGE', KEYS[1], 0, 0, 'WITHSCORES')
+if oldest[2] then
+	-- A slot frees up when the oldest request leaves the window.
+	retry = tonumber(oldest[2]) + window - now
+	local newest = redis.call('ZRANGE', KEYS[1], -1, -1, 'WITHSCORES')
+	reset = tonumber(newest[2]) + window - now
+end
+if allowed == 1 then
+	retry = 0
+end
+return {allowed, limit - count, reset, retry}
+`)
+
+// tokenBucketScript takes a token from the bucket in the hash KEYS[1], which
+// holds up to ARGV[2] tokens and refills completely in ARGV[1] milliseconds.
+// It returns {allowed, remaining, reset ms, retry after ms}.
+var tokenBucketScript = redis.NewScript(`
+local t = redis.call('TIME')
+local now = tonumber(t[1]) * 1000 + math.floor(tonumber(t[2]) / 1000)
+local window = tonumber(ARGV[1])
+local capacity = tonumber(ARGV[2])
+local rate = capacity / window
+
+local state = redis.call('HMGET', KEYS[1], 'tokens', 'ts')
+local tokens = tonumber(state[1]) or capacity
+local ts = tonumber(state[2]) or now
+tokens = math.min(capacity, tokens + math.max(0, now - ts) * rate)
+
+local allowed = 0
+local retry = 0
+if tokens >= 1 then
+	tokens = tokens - 1
+	allowed = 1
+else
+	retry = math.ceil((1 - tokens) / rate)
+end
+redis.call('HSET', KEYS[1], 'tokens', tostring(tokens), 'ts', now)
+-- An untouched bucket is full again after the window, like a missing one.
+redis.call('PEXPIRE', KEYS[1], window)
+
+return {allowed, math.floor(tokens), math.ceil((capacity - tokens) / rate), retry}
+`)
+
+// Limiter checks requests against policies.
+type Limiter struct {
+	repo repoif.RedisRepository
+}
+
+func NewLimiter(repo repoif.RedisRepository) *Limiter {
+	return &Limiter{repo: repo}
+}
+
+// Allow counts a request against the policy for key and reports whether it
+// is within the limit.
+func (l *Limiter) Allow(ctx context.Context, key string, p Policy) (Result, error) {
+	var script *redis.Script
+	switch p.Algorithm {
+	case SlidingWindow:
+		script = slidingWindowScript
+	case TokenBucket:
+		script = tokenBucketScript
+	default:
+		return Result{}, fmt.Errorf("ratelimit: unknown algorithm %q", p.Algorithm)
+	}
+
+	args := []any{p.Window.Milliseconds(), p.Limit}
+	if p.Algorithm == SlidingWindow {
+		args = append(args, utilities.NewRandomID())
+	}
+	values, err := l.repo.RunScript(ctx, script, []string{utilities.RateLimitKey(key)}, args...).Int64Slice()
+	if err != nil {
+		return Result{}, err
+	}
+	if len(values) != 4 {
+		return Result{}, fmt.Errorf("ratelimit: unexpected script result %v", values)
+	}
+	return Result{
+		Allowed:    values[0] == 1,
+		Remaining:  int(max(values[1], 0)),
+		Reset:      time.Duration(values[2]) * time.Millisecond,
+		RetryAfter: time.Duration(values[3]) * time.Millisecond,
+	}, nil
+}
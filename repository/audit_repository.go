@@ -0,0 +1,106 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"go-boilerplate/models"
+	"go-boilerplate/models/request"
+	"go-boilerplate/repository/interfaces"
+
+	"gorm.io/gorm"
+)
+
+// auditChainLockKey identifies the transaction-level advisory lock that
+// serializes appends to the audit hash chain.
+const auditChainLockKey = 0x61756474 // "audt"
+
+// auditWalkBatchSize is the number of events Walk loads per query.
+const auditWalkBatchSize = 500
+
+type auditRepository struct {
+	db *gorm.DB
+}
+
+func NewAuditRepository(db *gorm.DB) interfaces.AuditRepository {
+	return &auditRepository{db: db}
+}
+
+func (r *auditRepository) Create(ctx context.Context, event *models.AuditEvent) error {
+	return dbFrom(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("SELECT pg_advisory_xact_lock(?)", auditChainLockKey).Error; err != nil {
+			return err
+		}
+
+		var prev models.AuditEvent
+		if err := tx.Select("hash").Order("id DESC").Limit(1).Find(&prev).Error; err != nil {
+			return err
+		}
+		event.PrevHash = prev.Hash
+		event.CreatedAt = time.Now().UTC().Truncate(time.Microsecond)
+		event.Hash = event.ComputeHash()
+		return tx.Create(event).Error
+	})
+}
+
+func (r *auditRepository) ListByUser(ctx context.Context, userID uint) ([]*models.AuditEvent, error) {
+	var events []*models.AuditEvent
+	err := dbFrom(ctx, r.db).
+		Where("actor_id = ? OR (entity_type = ? AND entity_id = ?)", userID, models.AuditEntityUser, userID).
+		Order("id").
+		Find(&events).Error
+	return events, err
+}
+
+func (r *auditRepository) Search(ctx context.Context, filter *request.AuditFilter, offset, limit int) ([]*models.AuditEvent, int64, error) {
+	var events []*models.AuditEvent
+	var total int64
+
+	if err := applyAuditFilter(dbFrom(ctx, r.db).Model(&models.AuditEvent{}), filter).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	err := applyAuditFilter(dbFrom(ctx, r.db), filter).Order("id DESC").Offset(offset).Limit(limit).Find(&events).Error
+	return events, total, err
+}
+
+// applyAuditFilter adds the WHERE clauses for an AuditFilter. A nil filter matches everything.
+func applyAuditFilter(q *gorm.DB, filter *request.AuditFilter) *gorm.DB {
+	if filter == nil {
+		return q
+	}
+	if filter.ActorID != nil {
+		q = q.Where("actor_id = ?", *filter.ActorID)
+	}
+	if filter.Action != "" {
+		q = q.Where("action = ?", filter.Action)
+	}
+	if filter.EntityType != "" {
+		q = q.Where("entity_type = ?", filter.EntityType)
+	}
+	if filter.EntityID != nil {
+		q = q.Where("entity_id = ?", *filter.EntityID)
+	}
+	if filter.TraceID != "" {
+		q = q.Where("trace_id = ?", filter.TraceID)
+	}
+	if filter.CreatedAfter != nil {
+		q = q.Where("created_at >= ?", *filter.CreatedAfter)
+	}
+	if filter.CreatedBefore != nil {
+		q = q.Where("created_at < ?", *filter.CreatedBefore)
+	}
+	return q
+}
+
+func (r *auditRepository) Walk(ctx context.Context, fn func(*models.AuditEvent) error) error {
+	var batch []*models.AuditEvent
+	return dbFrom(ctx, r.db).Order("id").FindInBatches(&batch, auditWalkBatchSize, func(tx *gorm.DB, _ int) error {
+		for _, event := range batch {
+			if err := fn(event); err != nil {
+				return err
+			}
+		}
+		return nil
+	}).Error
+}
@@ -0,0 +1,21 @@
+package interfaces
+
+import (
+	"context"
+
+	"go-boilerplate/models"
+	"go-boilerplate/models/request"
+)
+
+// AuditRepository stores audit events. Events are never updated or deleted.
+type AuditRepository interface {
+	// Create appends the event to the hash chain, setting its PrevHash, Hash
+	// and CreatedAt. Appends are serialized so the chain never forks.
+	Create(ctx context.Context, event *models.AuditEvent) error
+	// ListByUser returns events performed by or targeting the user, oldest first.
+	ListByUser(ctx context.Context, userID uint) ([]*models.AuditEvent, error)
+	// Search returns a page of events matching filter, newest first, and the total count.
+	Search(ctx context.Context, filter *request.AuditFilter, offset, limit int) ([]*models.AuditEvent, int64, error)
+	// Walk calls fn for every event in id order, loading them in batches.
+	Walk(ctx context.Context, fn func(*models.AuditEvent) error) error
+}
@@ -0,0 +1,27 @@
+package interfaces
+
+import (
+	"context"
+	"time"
+
+	"go-boilerplate/models"
+)
+
+// InvitationRepository stores organization invitations.
+type InvitationRepository interface {
+	// Create inserts the invitation and revokes any pending invitation for the
+	// same address to the same organization, so only the newest link works.
+	Create(ctx context.Context, invitation *models.Invitation) error
+	// GetByID loads the invitation with its organization and team.
+	GetByID(ctx context.Context, id uint) (*models.Invitation, error)
+	// ListPending returns the organization's invitations that are still usable at now, newest first.
+	ListPending(ctx context.Context, orgID uint, now time.Time) ([]*models.Invitation, error)
+	// Revoke marks a pending invitation as revoked. It returns
+	// gorm.ErrRecordNotFound if there is no such pending invitation.
+	Revoke(ctx context.Context, orgID, id uint, now time.Time) error
+	// Accept marks the invitation as accepted by userID and adds the user to the
+	// organization and team in one transaction. It returns
+	// gorm.ErrRecordNotFound if the invitation is no longer pending and
+	// models.ErrAlreadyMember if the user already belongs to the organization.
+	Accept(ctx context.Context, invitation *models.Invitation, userID uint, now time.Time) error
+}
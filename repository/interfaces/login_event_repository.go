@@ -0,0 +1,20 @@
+package interfaces
+
+import (
+	"context"
+	"time"
+
+	"go-boilerplate/models"
+)
+
+// LoginEventRepository stores the login history.
+type LoginEventRepository interface {
+	Create(ctx context.Context, event *models.LoginEvent) error
+	// ListByUser returns a page of the user's login events, newest first.
+	ListByUser(ctx context.Context, userID uint, offset, limit int) ([]*models.LoginEvent, int64, error)
+	// ListAllByUser returns all of the user's login events, oldest first.
+	ListAllByUser(ctx context.Context, userID uint) ([]*models.LoginEvent, error)
+	DeleteByUser(ctx context.Context, userID uint) (int64, error)
+	// DeleteBefore removes events recorded before the cutoff.
+	DeleteBefore(ctx context.Context, before time.Time) (int64, error)
+}
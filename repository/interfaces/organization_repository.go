@@ -0,0 +1,22 @@
+package interfaces
+
+import (
+	"context"
+
+	"go-boilerplate/models"
+)
+
+// OrganizationRepository manages organizations and memberships. Its queries
+// are not tenant-scoped: they are what tenant resolution is built on.
+type OrganizationRepository interface {
+	// Create inserts the organization and makes ownerID its owner.
+	Create(ctx context.Context, org *models.Organization, ownerID uint) error
+	GetByID(ctx context.Context, id uint) (*models.Organization, error)
+	// ListForUser returns the organizations the user belongs to with the user's memberships.
+	ListForUser(ctx context.Context, userID uint) ([]*models.Membership, error)
+	// GetMembership returns gorm.ErrRecordNotFound if the user is not a member.
+	GetMembership(ctx context.Context, orgID, userID uint) (*models.Membership, error)
+	ListOrganizationIDs(ctx context.Context, userID uint) ([]uint, error)
+	// ListMembers returns the organization's memberships with their users, oldest first.
+	ListMembers(ctx context.Context, orgID uint) ([]*models.Membership, error)
+}
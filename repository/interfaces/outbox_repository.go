@@ -0,0 +1,28 @@
+package interfaces
+
+import (
+	"context"
+	"time"
+
+	"go-boilerplate/models"
+)
+
+// OutboxRepository stores domain events until the relay has published them.
+type OutboxRepository interface {
+	// Add stores events, inside the transaction carried by ctx if there is one.
+	Add(ctx context.Context, events ...*models.OutboxEvent) error
+	// PublishPending loads up to limit unpublished events in id order and hands
+	// them to publish, which returns how many of them it delivered, counted from
+	// the first, and the error that stopped it, if any. Delivered events are
+	// marked as published and the failed one records the error. Only one caller
+	// at a time publishes, so events leave in order; concurrent callers get 0
+	// without publish being called.
+	PublishPending(ctx context.Context, limit int, publish func([]*models.OutboxEvent) (int, error)) (int, error)
+	// RedactPayloads overwrites fields in the payloads of an aggregate's
+	// events, removing those whose value is nil, and returns how many events
+	// it changed. Events already published are changed too, but not their
+	// copies in the streams.
+	RedactPayloads(ctx context.Context, aggregateType string, aggregateID uint, fields map[string]any) (int64, error)
+	// DeletePublished removes events published before the cutoff.
+	DeletePublished(ctx context.Context, before time.Time) (int64, error)
+}
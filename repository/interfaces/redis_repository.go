@@ -3,15 +3,99 @@ package interfaces
 import (
 	"context"
 	"time"
+
+	"github.com/redis/go-redis/v9"
 )
 
 // RedisRepository defines low-level Redis operations used by the app.
 // Keep this limited to common primitives so it remains generic.
+// In Redis Cluster, keys passed to one call must hash to the same slot.
+// Missing keys and fields are reported as redis.Nil.
 type RedisRepository interface {
 	Set(ctx context.Context, key string, value string, expiration time.Duration) error
+	// SetNX sets key only if it does not exist and reports whether it was set.
+	SetNX(ctx context.Context, key string, value string, expiration time.Duration) (bool, error)
 	Get(ctx context.Context, key string) (string, error)
 	Del(ctx context.Context, keys ...string) (int64, error)
 	Exists(ctx context.Context, keys ...string) (int64, error)
 	Expire(ctx context.Context, key string, expiration time.Duration) (bool, error)
 	Incr(ctx context.Context, key string) (int64, error)
+	// SetTagged sets key and adds it to each tag set, atomically. The tag
+	// sets are kept at least as long as the key; expiration must be positive.
+	SetTagged(ctx context.Context, key string, value string, expiration time.Duration, tags []string) error
+	// InvalidateTags atomically deletes the tag sets and every key in them,
+	// returning the number of keys deleted.
+	InvalidateTags(ctx context.Context, tags ...string) (int64, error)
+
+	// Hashes
+	// HSet sets the given fields and returns how many of them were new.
+	HSet(ctx context.Context, key string, values map[string]any) (int64, error)
+	HGet(ctx context.Context, key, field string) (string, error)
+	HGetAll(ctx context.Context, key string) (map[string]string, error)
+	HDel(ctx context.Context, key string, fields ...string) (int64, error)
+	HIncrBy(ctx context.Context, key, field string, incr int64) (int64, error)
+
+	// Sets
+	SAdd(ctx context.Context, key string, members ...string) (int64, error)
+	SRem(ctx context.Context, key string, members ...string) (int64, error)
+	SMembers(ctx context.Context, key string) ([]string, error)
+	SIsMember(ctx context.Context, key, member string) (bool, error)
+	SCard(ctx context.Context, key string) (int64, error)
+
+	// Sorted sets. Score bounds use Redis syntax: "-inf", "+inf", "(5".
+	ZAdd(ctx context.Context, key string, members ...redis.Z) (int64, error)
+	ZRem(ctx context.Context, key string, members ...string) (int64, error)
+	ZIncrBy(ctx context.Context, key string, incr float64, member string) (float64, error)
+	ZScore(ctx context.Context, key, member string) (float64, error)
+	ZCard(ctx context.Context, key string) (int64, error)
+	// ZRange returns members by rank with their scores, lowest first.
+	ZRange(ctx context.Context, key string, start, stop int64) ([]redis.Z, error)
+	// ZRangeByScore returns up to count members scored within [min, max]
+	// after skipping offset of them; count <= 0 returns all the rest.
+	ZRangeByScore(ctx context.Context, key, min, max string, offset, count int64) ([]redis.Z, error)
+	ZRemRangeByScore(ctx context.Context, key, min, max string) (int64, error)
+
+	// Scan calls fn with each batch of keys matching the glob pattern. It
+	// walks every master in cluster mode. A key may be reported more than
+	// once; batches are roughly count keys.
+	Scan(ctx context.Context, match string, count int64, fn func(keys []string) error) error
+
+	// Pipelined sends the commands queued by fn in one round trip. The
+	// returned error is the first failed command's.
+	Pipelined(ctx context.Context, fn func(redis.Pipeliner) error) ([]redis.Cmder, error)
+	// TxPipelined is Pipelined wrapped in MULTI/EXEC.
+	TxPipelined(ctx context.Context, fn func(redis.Pipeliner) error) ([]redis.Cmder, error)
+	// Watch runs fn with keys under WATCH; fn reads through the Tx and
+	// writes with tx.TxPipelined. It returns redis.TxFailedErr when a
+	// watched key changed before EXEC.
+	Watch(ctx context.Context, fn func(*redis.Tx) error, keys ...string) error
+
+	// LoadScript loads script into the script cache, on every master in
+	// cluster mode.
+	LoadScript(ctx context.Context, script *redis.Script) error
+	// RunScript runs script with EVALSHA and falls back to EVAL, which also
+	// caches it, when Redis does not have it.
+	RunScript(ctx context.Context, script *redis.Script, keys []string, args ...any) *redis.Cmd
+
+	// Streams
+	// XAdd appends an entry and returns its ID.
+	XAdd(ctx context.Context, args *redis.XAddArgs) (string, error)
+	// XGroupCreateMkStream creates a consumer group starting after start,
+	// creating the stream if needed. An existing group is a BUSYGROUP error.
+	XGroupCreateMkStream(ctx context.Context, stream, group, start string) error
+	// XReadGroup reads entries for a consumer of a group; it returns
+	// redis.Nil if none arrived within args.Block.
+	XReadGroup(ctx context.Context, args *redis.XReadGroupArgs) ([]redis.XStream, error)
+	// XAck acknowledges entries and returns how many were pending.
+	XAck(ctx context.Context, stream, group string, ids ...string) (int64, error)
+	// XAutoClaim transfers entries pending for at least args.MinIdle to
+	// args.Consumer and returns them with the ID to continue from, "0-0"
+	// once the pending list has been walked.
+	XAutoClaim(ctx context.Context, args *redis.XAutoClaimArgs) ([]redis.XMessage, string, error)
+
+	// Publish returns the number of subscribers that received message.
+	Publish(ctx context.Context, channel, message string) (int64, error)
+	// Subscribe subscribes to channels. The subscription reconnects on its
+	// own and must be closed by the caller.
+	Subscribe(ctx context.Context, channels ...string) *redis.PubSub
 }
@@ -0,0 +1,16 @@
+package interfaces
+
+import (
+	"context"
+
+	"go-boilerplate/models"
+)
+
+type SettingsRepository interface {
+	// GetByUserID returns gorm.ErrRecordNotFound if the user has never saved settings.
+	GetByUserID(ctx context.Context, userID uint) (*models.UserSettings, error)
+	// Update runs fn on the user's settings row under a row lock and saves the
+	// result. A missing row is created empty first.
+	Update(ctx context.Context, userID uint, fn func(settings *models.UserSettings) error) (*models.UserSettings, error)
+	Delete(ctx context.Context, userID uint) error
+}
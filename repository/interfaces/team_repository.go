@@ -0,0 +1,21 @@
+package interfaces
+
+import (
+	"context"
+
+	"go-boilerplate/models"
+)
+
+// TeamRepository manages teams and their members. Queries are tenant-scoped.
+type TeamRepository interface {
+	Create(ctx context.Context, team *models.Team) error
+	// GetByID returns gorm.ErrRecordNotFound if the team is not in the organization.
+	GetByID(ctx context.Context, orgID, id uint) (*models.Team, error)
+	ListByOrganization(ctx context.Context, orgID uint) ([]*models.Team, error)
+	// AddMember adds the user to the team, or changes the role of an existing member.
+	AddMember(ctx context.Context, member *models.TeamMember) error
+	// GetMember returns the team member with its user.
+	GetMember(ctx context.Context, teamID, userID uint) (*models.TeamMember, error)
+	// ListMembers returns the team's members with their users, oldest first.
+	ListMembers(ctx context.Context, teamID uint) ([]*models.TeamMember, error)
+}
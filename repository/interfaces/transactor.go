@@ -0,0 +1,15 @@
+package interfaces
+
+import "context"
+
+// Transactor runs work spanning several repositories in one database
+// transaction. Repository calls made with the context passed to fn take part
+// in the transaction; it commits if fn returns nil and rolls back otherwise.
+type Transactor interface {
+	WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error
+	// AfterCommit defers fn, e.g. a cache update or an email, until the
+	// transaction carried by ctx has committed; it is dropped if the
+	// transaction rolls back. Without a transaction fn runs immediately. fn
+	// gets a context outside of any transaction.
+	AfterCommit(ctx context.Context, fn func(ctx context.Context))
+}
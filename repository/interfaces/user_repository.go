@@ -1,12 +1,36 @@
 package interfaces
 
-import "go-boilerplate/models"
+import (
+	"context"
+	"time"
 
+	"go-boilerplate/models"
+	"go-boilerplate/models/request"
+)
+
+// UserRepository queries are scoped to the tenant in ctx, if any (see models.TenantScoped).
 type UserRepository interface {
-	Create(user *models.User) error
-	GetByID(id uint) (*models.User, error)
-	GetByEmail(email string) (*models.User, error)
-	GetAll(offset, limit int) ([]*models.User, int64, error)
-	Update(user *models.User) error
-	Delete(id uint) error
+	Create(ctx context.Context, user *models.User) error
+	GetByID(ctx context.Context, id uint) (*models.User, error)
+	GetByEmail(ctx context.Context, email string) (*models.User, error)
+	GetAll(ctx context.Context, offset, limit int, filter *request.UserFilter) ([]*models.User, int64, error)
+	Stream(ctx context.Context, filter *request.UserFilter, columns []string, fn func(*models.User) error) error
+	Update(ctx context.Context, user *models.User) error
+	Delete(ctx context.Context, id uint, version uint) error
+
+	// Bulk import
+	GetExistingEmails(ctx context.Context, emails []string) (map[string]bool, error)
+	CreateBatch(ctx context.Context, users []*models.User) error
+
+	// Trash (soft-deleted users)
+	GetDeleted(ctx context.Context, offset, limit int) ([]*models.User, int64, error)
+	GetDeletedByID(ctx context.Context, id uint) (*models.User, error)
+	Restore(ctx context.Context, id uint) error
+	// PurgeDeleted permanently removes users soft-deleted before the cutoff and returns them.
+	PurgeDeleted(ctx context.Context, before time.Time) ([]*models.User, error)
+	// GetDueErasures returns users whose erasure grace period ended before the given time.
+	GetDueErasures(ctx context.Context, before time.Time) ([]*models.User, error)
+	// SetLastLogin and SetLastSeen record activity without bumping the version.
+	SetLastLogin(ctx context.Context, id uint, at time.Time) error
+	SetLastSeen(ctx context.Context, id uint, at time.Time) error
 }
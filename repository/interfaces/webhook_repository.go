@@ -0,0 +1,39 @@
+package interfaces
+
+import (
+	"context"
+	"time"
+
+	"go-boilerplate/models"
+)
+
+// WebhookRepository manages webhook subscriptions and their deliveries.
+// Queries are tenant-scoped.
+type WebhookRepository interface {
+	CreateSubscription(ctx context.Context, sub *models.WebhookSubscription) error
+	// GetSubscription returns gorm.ErrRecordNotFound if the subscription is not in the organization.
+	GetSubscription(ctx context.Context, orgID, id uint) (*models.WebhookSubscription, error)
+	ListSubscriptions(ctx context.Context, orgID uint) ([]*models.WebhookSubscription, error)
+	UpdateSubscription(ctx context.Context, sub *models.WebhookSubscription) error
+	DeleteSubscription(ctx context.Context, orgID, id uint) error
+	// ListActiveSubscriptions returns the active subscriptions of the organizations.
+	ListActiveSubscriptions(ctx context.Context, orgIDs []uint) ([]*models.WebhookSubscription, error)
+
+	// CreateDeliveries stores deliveries, skipping events already queued for a subscription.
+	CreateDeliveries(ctx context.Context, deliveries []*models.WebhookDelivery) error
+	// ListDeliveries returns a subscription's deliveries, newest first, optionally with the given status.
+	ListDeliveries(ctx context.Context, subID uint, status string, offset, limit int) ([]*models.WebhookDelivery, int64, error)
+	GetDelivery(ctx context.Context, subID, id uint) (*models.WebhookDelivery, error)
+	// ClaimDue returns up to limit pending deliveries that are due, with their
+	// subscriptions (including deleted ones), and pushes their next attempt back
+	// by lease so that other workers skip them while they are being sent.
+	ClaimDue(ctx context.Context, limit int, lease time.Duration) ([]*models.WebhookDelivery, error)
+	// UpdateDelivery saves the state and the outcome of the latest attempt.
+	UpdateDelivery(ctx context.Context, delivery *models.WebhookDelivery) error
+	// RedactUserDeliveries overwrites fields in the event data of the
+	// deliveries about a user, removing those whose value is nil, and returns
+	// how many deliveries it changed. Later attempts send the redacted body.
+	RedactUserDeliveries(ctx context.Context, userID uint, fields map[string]any) (int64, error)
+	// DeleteFinishedDeliveries removes succeeded and dead deliveries last updated before the cutoff.
+	DeleteFinishedDeliveries(ctx context.Context, before time.Time) (int64, error)
+}
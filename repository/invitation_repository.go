@@ -0,0 +1,98 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"go-boilerplate/models"
+	"go-boilerplate/repository/interfaces"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type invitationRepository struct {
+	db *gorm.DB
+}
+
+func NewInvitationRepository(db *gorm.DB) interfaces.InvitationRepository {
+	return &invitationRepository{db: db}
+}
+
+// pendingInvitation matches invitations that have been neither accepted nor revoked.
+const pendingInvitation = "accepted_at IS NULL AND revoked_at IS NULL"
+
+func (r *invitationRepository) Create(ctx context.Context, invitation *models.Invitation) error {
+	return dbFrom(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&models.Invitation{}).
+			Where("organization_id = ? AND email = ? AND "+pendingInvitation, invitation.OrganizationID, invitation.Email).
+			Update("revoked_at", time.Now()).Error
+		if err != nil {
+			return err
+		}
+		// The organization and team are loaded for the caller, not written.
+		return tx.Omit(clause.Associations).Create(invitation).Error
+	})
+}
+
+func (r *invitationRepository) GetByID(ctx context.Context, id uint) (*models.Invitation, error) {
+	var invitation models.Invitation
+	err := dbFrom(ctx, r.db).Preload("Organization").Preload("Team").First(&invitation, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &invitation, nil
+}
+
+func (r *invitationRepository) ListPending(ctx context.Context, orgID uint, now time.Time) ([]*models.Invitation, error) {
+	var invitations []*models.Invitation
+	err := dbFrom(ctx, r.db).
+		Where("organization_id = ? AND expires_at > ? AND "+pendingInvitation, orgID, now).
+		Order("created_at DESC").
+		Find(&invitations).Error
+	return invitations, err
+}
+
+func (r *invitationRepository) Revoke(ctx context.Context, orgID, id uint, now time.Time) error {
+	result := dbFrom(ctx, r.db).Model(&models.Invitation{}).
+		Where("id = ? AND organization_id = ? AND "+pendingInvitation, id, orgID).
+		Update("revoked_at", now)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+func (r *invitationRepository) Accept(ctx context.Context, invitation *models.Invitation, userID uint, now time.Time) error {
+	return dbFrom(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		// The conditional update claims the invitation, so it is accepted at most once.
+		result := tx.Model(&models.Invitation{}).
+			Where("id = ? AND expires_at > ? AND "+pendingInvitation, invitation.ID, now).
+			Updates(map[string]any{"accepted_at": now, "accepted_by_id": userID})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+
+		// An existing membership keeps its role, which may be higher than
+		// the invited one, and the invitation stays pending.
+		membership := &models.Membership{OrganizationID: invitation.OrganizationID, UserID: userID, Role: invitation.Role}
+		result = tx.Clauses(clause.OnConflict{DoNothing: true}).Create(membership)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return models.ErrAlreadyMember
+		}
+		if invitation.TeamID == nil {
+			return nil
+		}
+		member := &models.TeamMember{TeamID: *invitation.TeamID, UserID: userID, Role: models.TeamRoleMember}
+		return tx.Clauses(clause.OnConflict{DoNothing: true}).Create(member).Error
+	})
+}
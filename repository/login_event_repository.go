@@ -0,0 +1,52 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"go-boilerplate/models"
+	"go-boilerplate/repository/interfaces"
+
+	"gorm.io/gorm"
+)
+
+type loginEventRepository struct {
+	db *gorm.DB
+}
+
+func NewLoginEventRepository(db *gorm.DB) interfaces.LoginEventRepository {
+	return &loginEventRepository{db: db}
+}
+
+func (r *loginEventRepository) Create(ctx context.Context, event *models.LoginEvent) error {
+	return dbFrom(ctx, r.db).Create(event).Error
+}
+
+func (r *loginEventRepository) ListByUser(ctx context.Context, userID uint, offset, limit int) ([]*models.LoginEvent, int64, error) {
+	var events []*models.LoginEvent
+	var total int64
+
+	query := dbFrom(ctx, r.db).Model(&models.LoginEvent{}).Where("user_id = ?", userID)
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	err := query.Order("created_at DESC, id DESC").Offset(offset).Limit(limit).Find(&events).Error
+	return events, total, err
+}
+
+func (r *loginEventRepository) ListAllByUser(ctx context.Context, userID uint) ([]*models.LoginEvent, error) {
+	var events []*models.LoginEvent
+	err := dbFrom(ctx, r.db).Where("user_id = ?", userID).Order("id").Find(&events).Error
+	return events, err
+}
+
+func (r *loginEventRepository) DeleteByUser(ctx context.Context, userID uint) (int64, error) {
+	result := dbFrom(ctx, r.db).Where("user_id = ?", userID).Delete(&models.LoginEvent{})
+	return result.RowsAffected, result.Error
+}
+
+func (r *loginEventRepository) DeleteBefore(ctx context.Context, before time.Time) (int64, error) {
+	result := dbFrom(ctx, r.db).Where("created_at < ?", before).Delete(&models.LoginEvent{})
+	return result.RowsAffected, result.Error
+}
@@ -0,0 +1,74 @@
+package repository
+
+import (
+	"context"
+
+	"go-boilerplate/models"
+	"go-boilerplate/repository/interfaces"
+
+	"gorm.io/gorm"
+)
+
+type organizationRepository struct {
+	db *gorm.DB
+}
+
+func NewOrganizationRepository(db *gorm.DB) interfaces.OrganizationRepository {
+	return &organizationRepository{db: db}
+}
+
+func (r *organizationRepository) Create(ctx context.Context, org *models.Organization, ownerID uint) error {
+	return dbFrom(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(org).Error; err != nil {
+			return err
+		}
+		return tx.Create(&models.Membership{OrganizationID: org.ID, UserID: ownerID, Role: models.MembershipRoleOwner}).Error
+	})
+}
+
+func (r *organizationRepository) GetByID(ctx context.Context, id uint) (*models.Organization, error) {
+	var org models.Organization
+	if err := dbFrom(ctx, r.db).First(&org, id).Error; err != nil {
+		return nil, err
+	}
+	return &org, nil
+}
+
+func (r *organizationRepository) ListForUser(ctx context.Context, userID uint) ([]*models.Membership, error) {
+	var memberships []*models.Membership
+	err := dbFrom(ctx, r.db).
+		Joins("Organization").
+		Where("memberships.user_id = ?", userID).
+		Order("memberships.organization_id").
+		Find(&memberships).Error
+	return memberships, err
+}
+
+func (r *organizationRepository) GetMembership(ctx context.Context, orgID, userID uint) (*models.Membership, error) {
+	var membership models.Membership
+	err := dbFrom(ctx, r.db).
+		Where("organization_id = ? AND user_id = ?", orgID, userID).
+		First(&membership).Error
+	if err != nil {
+		return nil, err
+	}
+	return &membership, nil
+}
+
+func (r *organizationRepository) ListOrganizationIDs(ctx context.Context, userID uint) ([]uint, error) {
+	var ids []uint
+	err := dbFrom(ctx, r.db).Model(&models.Membership{}).
+		Where("user_id = ?", userID).
+		Pluck("organization_id", &ids).Error
+	return ids, err
+}
+
+func (r *organizationRepository) ListMembers(ctx context.Context, orgID uint) ([]*models.Membership, error) {
+	var memberships []*models.Membership
+	err := dbFrom(ctx, r.db).
+		Joins("User").
+		Where("memberships.organization_id = ?", orgID).
+		Order("memberships.id").
+		Find(&memberships).Error
+	return memberships, err
+}
@@ -0,0 +1,96 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"go-boilerplate/models"
+	"go-boilerplate/repository/interfaces"
+
+	"gorm.io/gorm"
+)
+
+// outboxRelayLockKey identifies the transaction-level advisory lock held while
+// publishing, so that only one instance relays at a time.
+const outboxRelayLockKey = 0x6f757462 // "outb"
+
+type outboxRepository struct {
+	db *gorm.DB
+}
+
+func NewOutboxRepository(db *gorm.DB) interfaces.OutboxRepository {
+	return &outboxRepository{db: db}
+}
+
+func (r *outboxRepository) Add(ctx context.Context, events ...*models.OutboxEvent) error {
+	if len(events) == 0 {
+		return nil
+	}
+	return dbFrom(ctx, r.db).Create(&events).Error
+}
+
+func (r *outboxRepository) PublishPending(ctx context.Context, limit int, publish func([]*models.OutboxEvent) (int, error)) (int, error) {
+	published := 0
+	var publishErr error
+	err := dbFrom(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		var locked bool
+		if err := tx.Raw("SELECT pg_try_advisory_xact_lock(?)", outboxRelayLockKey).Scan(&locked).Error; err != nil {
+			return err
+		}
+		if !locked {
+			return nil
+		}
+
+		var events []*models.OutboxEvent
+		if err := tx.Where("published_at IS NULL").Order("id").Limit(limit).Find(&events).Error; err != nil {
+			return err
+		}
+		if len(events) == 0 {
+			return nil
+		}
+
+		published, publishErr = publish(events)
+		if published > 0 {
+			ids := make([]uint, published)
+			for i, event := range events[:published] {
+				ids[i] = event.ID
+			}
+			err := tx.Model(&models.OutboxEvent{}).Where("id IN ?", ids).Updates(map[string]any{
+				"published_at": time.Now(),
+				"attempts":     gorm.Expr("attempts + 1"),
+				"last_error":   "",
+			}).Error
+			if err != nil {
+				return err
+			}
+		}
+		if publishErr != nil && published < len(events) {
+			return tx.Model(&models.OutboxEvent{}).Where("id = ?", events[published].ID).Updates(map[string]any{
+				"attempts":   gorm.Expr("attempts + 1"),
+				"last_error": publishErr.Error(),
+			}).Error
+		}
+		return nil
+	})
+	if err != nil {
+		// Nothing was marked; the events will be published again.
+		return 0, err
+	}
+	return published, publishErr
+}
+
+func (r *outboxRepository) RedactPayloads(ctx context.Context, aggregateType string, aggregateID uint, fields map[string]any) (int64, error) {
+	payload, err := redactJSON(gorm.Expr("payload"), fields)
+	if err != nil {
+		return 0, err
+	}
+	result := dbFrom(ctx, r.db).Model(&models.OutboxEvent{}).
+		Where("aggregate_type = ? AND aggregate_id = ?", aggregateType, aggregateID).
+		Update("payload", payload)
+	return result.RowsAffected, result.Error
+}
+
+func (r *outboxRepository) DeletePublished(ctx context.Context, before time.Time) (int64, error) {
+	result := dbFrom(ctx, r.db).Where("published_at IS NOT NULL AND published_at < ?", before).Delete(&models.OutboxEvent{})
+	return result.RowsAffected, result.Error
+}
@@ -0,0 +1,35 @@
+package repository
+
+import (
+	"encoding/json"
+	"slices"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// redactJSON returns an expression for the JSONB object expr with fields
+// overwritten by the given values, and removed where the value is nil.
+func redactJSON(expr clause.Expr, fields map[string]any) (clause.Expr, error) {
+	set := make(map[string]any)
+	var removed []string
+	for field, value := range fields {
+		if value == nil {
+			removed = append(removed, field)
+		} else {
+			set[field] = value
+		}
+	}
+	slices.Sort(removed)
+	for _, field := range removed {
+		expr = gorm.Expr("(? - ?)", expr, field)
+	}
+	if len(set) > 0 {
+		values, err := json.Marshal(set)
+		if err != nil {
+			return clause.Expr{}, err
+		}
+		expr = gorm.Expr("(? || ?::jsonb)", expr, string(values))
+	}
+	return expr, nil
+}
@@ -0,0 +1,336 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go-boilerplate/circuit"
+	repoif "go-boilerplate/repository/interfaces"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// breakerRedisRepository guards a RedisRepository with a circuit breaker, so
+// that calls fail fast with circuit.ErrOpen while Redis is down instead of
+// each waiting for a timeout.
+type breakerRedisRepository struct {
+	next    repoif.RedisRepository
+	breaker *circuit.Breaker
+}
+
+func NewBreakerRedisRepository(next repoif.RedisRepository, breaker *circuit.Breaker) repoif.RedisRepository {
+	return &breakerRedisRepository{next: next, breaker: breaker}
+}
+
+// guard runs fn through the breaker.
+func guard[T any](ctx context.Context, b *circuit.Breaker, fn func() (T, error)) (T, error) {
+	done, err := b.Allow()
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+	v, err := fn()
+	var cb *callbackError
+	if errors.As(err, &cb) {
+		done(false)
+		return v, cb.err
+	}
+	done(redisUnavailable(ctx, err))
+	return v, err
+}
+
+// callbackError marks an error returned by a caller's callback, which says
+// nothing about whether Redis is healthy. guard unwraps it.
+type callbackError struct{ err error }
+
+func (e *callbackError) Error() string { return e.err.Error() }
+
+// marked wraps a callback so that guard can tell its errors from Redis'.
+func marked[T any](fn func(T) error) func(T) error {
+	return func(v T) error {
+		if err := fn(v); err != nil {
+			return &callbackError{err: err}
+		}
+		return nil
+	}
+}
+
+// redisUnavailable reports whether err means that Redis could not serve the
+// call. Missing keys, error replies to bad commands and calls abandoned by
+// the caller do not count.
+func redisUnavailable(ctx context.Context, err error) bool {
+	if err == nil || errors.Is(err, redis.Nil) || ctx.Err() != nil {
+		return false
+	}
+	var reply redis.Error
+	if errors.As(err, &reply) {
+		for _, prefix := range []string{"LOADING", "READONLY", "MASTERDOWN", "CLUSTERDOWN", "TRYAGAIN"} {
+			if redis.HasErrorPrefix(err, prefix) {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
+
+func (r *breakerRedisRepository) Set(ctx context.Context, key string, value string, expiration time.Duration) error {
+	_, err := guard(ctx, r.breaker, func() (struct{}, error) {
+		return struct{}{}, r.next.Set(ctx, key, value, expiration)
+	})
+	return err
+}
+
+func (r *breakerRedisRepository) SetNX(ctx context.Context, key string, value string, expiration time.Duration) (bool, error) {
+	return guard(ctx, r.breaker, func() (bool, error) {
+		return r.next.SetNX(ctx, key, value, expiration)
+	})
+}
+
+func (r *breakerRedisRepository) Get(ctx context.Context, key string) (string, error) {
+	return guard(ctx, r.breaker, func() (string, error) {
+		return r.next.Get(ctx, key)
+	})
+}
+
+func (r *breakerRedisRepository) Del(ctx context.Context, keys ...string) (int64, error) {
+	return guard(ctx, r.breaker, func() (int64, error) {
+		return r.next.Del(ctx, keys...)
+	})
+}
+
+func (r *breakerRedisRepository) Exists(ctx context.Context, keys ...string) (int64, error) {
+	return guard(ctx, r.breaker, func() (int64, error) {
+		return r.next.Exists(ctx, keys...)
+	})
+}
+
+func (r *breakerRedisRepository) Expire(ctx context.Context, key string, expiration time.Duration) (bool, error) {
+	return guard(ctx, r.breaker, func() (bool, error) {
+		return r.next.Expire(ctx, key, expiration)
+	})
+}
+
+func (r *breakerRedisRepository) Incr(ctx context.Context, key string) (int64, error) {
+	return guard(ctx, r.breaker, func() (int64, error) {
+		return r.next.Incr(ctx, key)
+	})
+}
+
+func (r *breakerRedisRepository) SetTagged(ctx context.Context, key string, value string, expiration time.Duration, tags []string) error {
+	_, err := guard(ctx, r.breaker, func() (struct{}, error) {
+		return struct{}{}, r.next.SetTagged(ctx, key, value, expiration, tags)
+	})
+	return err
+}
+
+func (r *breakerRedisRepository) InvalidateTags(ctx context.Context, tags ...string) (int64, error) {
+	return guard(ctx, r.breaker, func() (int64, error) {
+		return r.next.InvalidateTags(ctx, tags...)
+	})
+}
+
+func (r *breakerRedisRepository) HSet(ctx context.Context, key string, values map[string]any) (int64, error) {
+	return guard(ctx, r.breaker, func() (int64, error) {
+		return r.next.HSet(ctx, key, values)
+	})
+}
+
+func (r *breakerRedisRepository) HGet(ctx context.Context, key, field string) (string, error) {
+	return guard(ctx, r.breaker, func() (string, error) {
+		return r.next.HGet(ctx, key, field)
+	})
+}
+
+func (r *breakerRedisRepository) HGetAll(ctx context.Context, key string) (map[string]string, error) {
+	return guard(ctx, r.breaker, func() (map[string]string, error) {
+		return r.next.HGetAll(ctx, key)
+	})
+}
+
+func (r *breakerRedisRepository) HDel(ctx context.Context, key string, fields ...string) (int64, error) {
+	return guard(ctx, r.breaker, func() (int64, error) {
+		return r.next.HDel(ctx, key, fields...)
+	})
+}
+
+func (r *breakerRedisRepository) HIncrBy(ctx context.Context, key, field string, incr int64) (int64, error) {
+	return guard(ctx, r.breaker, func() (int64, error) {
+		return r.next.HIncrBy(ctx, key, field, incr)
+	})
+}
+
+func (r *breakerRedisRepository) SAdd(ctx context.Context, key string, members ...string) (int64, error) {
+	return guard(ctx, r.breaker, func() (int64, error) {
+		return r.next.SAdd(ctx, key, members...)
+	})
+}
+
+func (r *breakerRedisRepository) SRem(ctx context.Context, key string, members ...string) (int64, error) {
+	return guard(ctx, r.breaker, func() (int64, error) {
+		return r.next.SRem(ctx, key, members...)
+	})
+}
+
+func (r *breakerRedisRepository) SMembers(ctx context.Context, key string) ([]string, error) {
+	return guard(ctx, r.breaker, func() ([]string, error) {
+		return r.next.SMembers(ctx, key)
+	})
+}
+
+func (r *breakerRedisRepository) SIsMember(ctx context.Context, key, member string) (bool, error) {
+	return guard(ctx, r.breaker, func() (bool, error) {
+		return r.next.SIsMember(ctx, key, member)
+	})
+}
+
+func (r *breakerRedisRepository) SCard(ctx context.Context, key string) (int64, error) {
+	return guard(ctx, r.breaker, func() (int64, error) {
+		return r.next.SCard(ctx, key)
+	})
+}
+
+func (r *breakerRedisRepository) ZAdd(ctx context.Context, key string, members ...redis.Z) (int64, error) {
+	return guard(ctx, r.breaker, func() (int64, error) {
+		return r.next.ZAdd(ctx, key, members...)
+	})
+}
+
+func (r *breakerRedisRepository) ZRem(ctx context.Context, key string, members ...string) (int64, error) {
+	return guard(ctx, r.breaker, func() (int64, error) {
+		return r.next.ZRem(ctx, key, members...)
+	})
+}
+
+func (r *breakerRedisRepository) ZIncrBy(ctx context.Context, key string, incr float64, member string) (float64, error) {
+	return guard(ctx, r.breaker, func() (float64, error) {
+		return r.next.ZIncrBy(ctx, key, incr, member)
+	})
+}
+
+func (r *breakerRedisRepository) ZScore(ctx context.Context, key, member string) (float64, error) {
+	return guard(ctx, r.breaker, func() (float64, error) {
+		return r.next.ZScore(ctx, key, member)
+	})
+}
+
+func (r *breakerRedisRepository) ZCard(ctx context.Context, key string) (int64, error) {
+	return guard(ctx, r.breaker, func() (int64, error) {
+		return r.next.ZCard(ctx, key)
+	})
+}
+
+func (r *breakerRedisRepository) ZRange(ctx context.Context, key string, start, stop int64) ([]redis.Z, error) {
+	return guard(ctx, r.breaker, func() ([]redis.Z, error) {
+		return r.next.ZRange(ctx, key, start, stop)
+	})
+}
+
+func (r *breakerRedisRepository) ZRangeByScore(ctx context.Context, key, min, max string, offset, count int64) ([]redis.Z, error) {
+	return guard(ctx, r.breaker, func() ([]redis.Z, error) {
+		return r.next.ZRangeByScore(ctx, key, min, max, offset, count)
+	})
+}
+
+func (r *breakerRedisRepository) ZRemRangeByScore(ctx context.Context, key, min, max string) (int64, error) {
+	return guard(ctx, r.breaker, func() (int64, error) {
+		return r.next.ZRemRangeByScore(ctx, key, min, max)
+	})
+}
+
+func (r *breakerRedisRepository) Scan(ctx context.Context, match string, count int64, fn func(keys []string) error) error {
+	_, err := guard(ctx, r.breaker, func() (struct{}, error) {
+		return struct{}{}, r.next.Scan(ctx, match, count, marked(fn))
+	})
+	return err
+}
+
+func (r *breakerRedisRepository) Pipelined(ctx context.Context, fn func(redis.Pipeliner) error) ([]redis.Cmder, error) {
+	return guard(ctx, r.breaker, func() ([]redis.Cmder, error) {
+		return r.next.Pipelined(ctx, marked(fn))
+	})
+}
+
+func (r *breakerRedisRepository) TxPipelined(ctx context.Context, fn func(redis.Pipeliner) error) ([]redis.Cmder, error) {
+	return guard(ctx, r.breaker, func() ([]redis.Cmder, error) {
+		return r.next.TxPipelined(ctx, marked(fn))
+	})
+}
+
+// Watch counts only the outcome of WATCH itself. Commands fn sends through
+// the Tx, EXEC included, cannot be told apart from fn's own errors.
+func (r *breakerRedisRepository) Watch(ctx context.Context, fn func(*redis.Tx) error, keys ...string) error {
+	_, err := guard(ctx, r.breaker, func() (struct{}, error) {
+		return struct{}{}, r.next.Watch(ctx, marked(fn), keys...)
+	})
+	return err
+}
+
+func (r *breakerRedisRepository) LoadScript(ctx context.Context, script *redis.Script) error {
+	_, err := guard(ctx, r.breaker, func() (struct{}, error) {
+		return struct{}{}, r.next.LoadScript(ctx, script)
+	})
+	return err
+}
+
+func (r *breakerRedisRepository) RunScript(ctx context.Context, script *redis.Script, keys []string, args ...any) *redis.Cmd {
+	done, err := r.breaker.Allow()
+	if err != nil {
+		cmd := redis.NewCmd(ctx)
+		cmd.SetErr(err)
+		return cmd
+	}
+	cmd := r.next.RunScript(ctx, script, keys, args...)
+	done(redisUnavailable(ctx, cmd.Err()))
+	return cmd
+}
+
+func (r *breakerRedisRepository) XAdd(ctx context.Context, args *redis.XAddArgs) (string, error) {
+	return guard(ctx, r.breaker, func() (string, error) {
+		return r.next.XAdd(ctx, args)
+	})
+}
+
+func (r *breakerRedisRepository) XGroupCreateMkStream(ctx context.Context, stream, group, start string) error {
+	_, err := guard(ctx, r.breaker, func() (struct{}, error) {
+		return struct{}{}, r.next.XGroupCreateMkStream(ctx, stream, group, start)
+	})
+	return err
+}
+
+func (r *breakerRedisRepository) XReadGroup(ctx context.Context, args *redis.XReadGroupArgs) ([]redis.XStream, error) {
+	return guard(ctx, r.breaker, func() ([]redis.XStream, error) {
+		return r.next.XReadGroup(ctx, args)
+	})
+}
+
+func (r *breakerRedisRepository) XAck(ctx context.Context, stream, group string, ids ...string) (int64, error) {
+	return guard(ctx, r.breaker, func() (int64, error) {
+		return r.next.XAck(ctx, stream, group, ids...)
+	})
+}
+
+func (r *breakerRedisRepository) XAutoClaim(ctx context.Context, args *redis.XAutoClaimArgs) ([]redis.XMessage, string, error) {
+	type claimed struct {
+		messages []redis.XMessage
+		next     string
+	}
+	c, err := guard(ctx, r.breaker, func() (claimed, error) {
+		messages, next, err := r.next.XAutoClaim(ctx, args)
+		return claimed{messages, next}, err
+	})
+	return c.messages, c.next, err
+}
+
+func (r *breakerRedisRepository) Publish(ctx context.Context, channel, message string) (int64, error) {
+	return guard(ctx, r.breaker, func() (int64, error) {
+		return r.next.Publish(ctx, channel, message)
+	})
+}
+
+// Subscribe is not guarded: the subscription dials lazily and reconnects by
+// itself, so there is no single call to fail fast.
+func (r *breakerRedisRepository) Subscribe(ctx context.Context, channels ...string) *redis.PubSub {
+	return r.next.Subscribe(ctx, channels...)
+}
@@ -9,12 +9,43 @@ import (
 	"github.com/redis/go-redis/v9"
 )
 
+// setTaggedScript sets KEYS[1] to ARGV[1] for ARGV[2] milliseconds and adds
+// it to the tag sets KEYS[2..], extending their expiry to cover the key.
+var setTaggedScript = redis.NewScript(`
+local ttl = tonumber(ARGV[2])
+redis.call('SET', KEYS[1], ARGV[1], 'PX', ttl)
+for i = 2, #KEYS do
+	redis.call('SADD', KEYS[i], KEYS[1])
+	if redis.call('PTTL', KEYS[i]) < ttl then
+		redis.call('PEXPIRE', KEYS[i], ttl)
+	end
+end
+return 1
+`)
+
+// invalidateTagsScript deletes the tag sets KEYS and the keys they contain.
+// Members are deleted in chunks to stay below Lua's unpack limit.
+var invalidateTagsScript = redis.NewScript(`
+local deleted = 0
+for i = 1, #KEYS do
+	local members = redis.call('SMEMBERS', KEYS[i])
+	for j = 1, #members, 1000 do
+		deleted = deleted + redis.call('DEL', unpack(members, j, math.min(j + 999, #members)))
+	end
+	redis.call('DEL', KEYS[i])
+end
+return deleted
+`)
+
 // redisRepository is a thin wrapper around go-redis client implementing RedisRepository.
 type redisRepository struct {
-	client *redis.Client
+	client redis.UniversalClient
 }
 
-func NewRedisRepository(client *redis.Client) repoif.RedisRepository {
+// NewRedisRepository works with a single server, Sentinel or Cluster client.
+// In cluster mode multi-key commands and scripts need their keys in one hash
+// slot, which the key builders in utilities ensure with hash tags.
+func NewRedisRepository(client redis.UniversalClient) repoif.RedisRepository {
 	return &redisRepository{client: client}
 }
 
@@ -22,6 +53,10 @@ func (r *redisRepository) Set(ctx context.Context, key string, value string, exp
 	return r.client.Set(ctx, key, value, expiration).Err()
 }
 
+func (r *redisRepository) SetNX(ctx context.Context, key string, value string, expiration time.Duration) (bool, error) {
+	return r.client.SetNX(ctx, key, value, expiration).Result()
+}
+
 func (r *redisRepository) Get(ctx context.Context, key string) (string, error) {
 	return r.client.Get(ctx, key).Result()
 }
@@ -41,3 +76,179 @@ func (r *redisRepository) Expire(ctx context.Context, key string, expiration tim
 func (r *redisRepository) Incr(ctx context.Context, key string) (int64, error) {
 	return r.client.Incr(ctx, key).Result()
 }
+
+func (r *redisRepository) SetTagged(ctx context.Context, key string, value string, expiration time.Duration, tags []string) error {
+	keys := append([]string{key}, tags...)
+	return setTaggedScript.Run(ctx, r.client, keys, value, expiration.Milliseconds()).Err()
+}
+
+func (r *redisRepository) InvalidateTags(ctx context.Context, tags ...string) (int64, error) {
+	if len(tags) == 0 {
+		return 0, nil
+	}
+	return invalidateTagsScript.Run(ctx, r.client, tags).Int64()
+}
+
+func (r *redisRepository) HSet(ctx context.Context, key string, values map[string]any) (int64, error) {
+	return r.client.HSet(ctx, key, values).Result()
+}
+
+func (r *redisRepository) HGet(ctx context.Context, key, field string) (string, error) {
+	return r.client.HGet(ctx, key, field).Result()
+}
+
+func (r *redisRepository) HGetAll(ctx context.Context, key string) (map[string]string, error) {
+	return r.client.HGetAll(ctx, key).Result()
+}
+
+func (r *redisRepository) HDel(ctx context.Context, key string, fields ...string) (int64, error) {
+	return r.client.HDel(ctx, key, fields...).Result()
+}
+
+func (r *redisRepository) HIncrBy(ctx context.Context, key, field string, incr int64) (int64, error) {
+	return r.client.HIncrBy(ctx, key, field, incr).Result()
+}
+
+func (r *redisRepository) SAdd(ctx context.Context, key string, members ...string) (int64, error) {
+	return r.client.SAdd(ctx, key, toAny(members)...).Result()
+}
+
+func (r *redisRepository) SRem(ctx context.Context, key string, members ...string) (int64, error) {
+	return r.client.SRem(ctx, key, toAny(members)...).Result()
+}
+
+func (r *redisRepository) SMembers(ctx context.Context, key string) ([]string, error) {
+	return r.client.SMembers(ctx, key).Result()
+}
+
+func (r *redisRepository) SIsMember(ctx context.Context, key, member string) (bool, error) {
+	return r.client.SIsMember(ctx, key, member).Result()
+}
+
+func (r *redisRepository) SCard(ctx context.Context, key string) (int64, error) {
+	return r.client.SCard(ctx, key).Result()
+}
+
+func (r *redisRepository) ZAdd(ctx context.Context, key string, members ...redis.Z) (int64, error) {
+	return r.client.ZAdd(ctx, key, members...).Result()
+}
+
+func (r *redisRepository) ZRem(ctx context.Context, key string, members ...string) (int64, error) {
+	return r.client.ZRem(ctx, key, toAny(members)...).Result()
+}
+
+func (r *redisRepository) ZIncrBy(ctx context.Context, key string, incr float64, member string) (float64, error) {
+	return r.client.ZIncrBy(ctx, key, incr, member).Result()
+}
+
+func (r *redisRepository) ZScore(ctx context.Context, key, member string) (float64, error) {
+	return r.client.ZScore(ctx, key, member).Result()
+}
+
+func (r *redisRepository) ZCard(ctx context.Context, key string) (int64, error) {
+	return r.client.ZCard(ctx, key).Result()
+}
+
+func (r *redisRepository) ZRange(ctx context.Context, key string, start, stop int64) ([]redis.Z, error) {
+	return r.client.ZRangeWithScores(ctx, key, start, stop).Result()
+}
+
+func (r *redisRepository) ZRangeByScore(ctx context.Context, key, min, max string, offset, count int64) ([]redis.Z, error) {
+	// LIMIT is only sent when offset or count is non-zero; -1 means no limit.
+	if count <= 0 {
+		count = 0
+		if offset > 0 {
+			count = -1
+		}
+	}
+	return r.client.ZRangeByScoreWithScores(ctx, key, &redis.ZRangeBy{Min: min, Max: max, Offset: offset, Count: count}).Result()
+}
+
+func (r *redisRepository) ZRemRangeByScore(ctx context.Context, key, min, max string) (int64, error) {
+	return r.client.ZRemRangeByScore(ctx, key, min, max).Result()
+}
+
+func (r *redisRepository) Scan(ctx context.Context, match string, count int64, fn func(keys []string) error) error {
+	// A cluster client would send SCAN to one node only.
+	if cluster, ok := r.client.(*redis.ClusterClient); ok {
+		return cluster.ForEachMaster(ctx, func(ctx context.Context, node *redis.Client) error {
+			return scan(ctx, node, match, count, fn)
+		})
+	}
+	return scan(ctx, r.client, match, count, fn)
+}
+
+func scan(ctx context.Context, client redis.Cmdable, match string, count int64, fn func(keys []string) error) error {
+	var cursor uint64
+	for {
+		keys, next, err := client.Scan(ctx, cursor, match, count).Result()
+		if err != nil {
+			return err
+		}
+		if len(keys) > 0 {
+			if err := fn(keys); err != nil {
+				return err
+			}
+		}
+		if next == 0 {
+			return nil
+		}
+		cursor = next
+	}
+}
+
+func (r *redisRepository) Pipelined(ctx context.Context, fn func(redis.Pipeliner) error) ([]redis.Cmder, error) {
+	return r.client.Pipelined(ctx, fn)
+}
+
+func (r *redisRepository) TxPipelined(ctx context.Context, fn func(redis.Pipeliner) error) ([]redis.Cmder, error) {
+	return r.client.TxPipelined(ctx, fn)
+}
+
+func (r *redisRepository) Watch(ctx context.Context, fn func(*redis.Tx) error, keys ...string) error {
+	return r.client.Watch(ctx, fn, keys...)
+}
+
+func (r *redisRepository) LoadScript(ctx context.Context, script *redis.Script) error {
+	return script.Load(ctx, r.client).Err()
+}
+
+func (r *redisRepository) RunScript(ctx context.Context, script *redis.Script, keys []string, args ...any) *redis.Cmd {
+	return script.Run(ctx, r.client, keys, args...)
+}
+
+func (r *redisRepository) XAdd(ctx context.Context, args *redis.XAddArgs) (string, error) {
+	return r.client.XAdd(ctx, args).Result()
+}
+
+func (r *redisRepository) XGroupCreateMkStream(ctx context.Context, stream, group, start string) error {
+	return r.client.XGroupCreateMkStream(ctx, stream, group, start).Err()
+}
+
+func (r *redisRepository) XReadGroup(ctx context.Context, args *redis.XReadGroupArgs) ([]redis.XStream, error) {
+	return r.client.XReadGroup(ctx, args).Result()
+}
+
+func (r *redisRepository) XAck(ctx context.Context, stream, group string, ids ...string) (int64, error) {
+	return r.client.XAck(ctx, stream, group, ids...).Result()
+}
+
+func (r *redisRepository) XAutoClaim(ctx context.Context, args *redis.XAutoClaimArgs) ([]redis.XMessage, string, error) {
+	return r.client.XAutoClaim(ctx, args).Result()
+}
+
+func (r *redisRepository) Publish(ctx context.Context, channel, message string) (int64, error) {
+	return r.client.Publish(ctx, channel, message).Result()
+}
+
+func (r *redisRepository) Subscribe(ctx context.Context, channels ...string) *redis.PubSub {
+	return r.client.Subscribe(ctx, channels...)
+}
+
+func toAny(values []string) []any {
+	out := make([]any, len(values))
+	for i, v := range values {
+		out[i] = v
+	}
+	return out
+}
@@ -0,0 +1,53 @@
+package repository
+
+import (
+	"context"
+
+	"go-boilerplate/models"
+	"go-boilerplate/repository/interfaces"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type settingsRepository struct {
+	db *gorm.DB
+}
+
+func NewSettingsRepository(db *gorm.DB) interfaces.SettingsRepository {
+	return &settingsRepository{db: db}
+}
+
+func (r *settingsRepository) GetByUserID(ctx context.Context, userID uint) (*models.UserSettings, error) {
+	var settings models.UserSettings
+	if err := dbFrom(ctx, r.db).Where("user_id = ?", userID).First(&settings).Error; err != nil {
+		return nil, err
+	}
+	return &settings, nil
+}
+
+func (r *settingsRepository) Update(ctx context.Context, userID uint, fn func(settings *models.UserSettings) error) (*models.UserSettings, error) {
+	var settings models.UserSettings
+	err := dbFrom(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		// Create the row first if needed so that concurrent first writes queue on its lock.
+		empty := models.UserSettings{UserID: userID, Values: map[string]any{}}
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&empty).Error; err != nil {
+			return err
+		}
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("user_id = ?", userID).First(&settings).Error; err != nil {
+			return err
+		}
+		if err := fn(&settings); err != nil {
+			return err
+		}
+		return tx.Save(&settings).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &settings, nil
+}
+
+func (r *settingsRepository) Delete(ctx context.Context, userID uint) error {
+	return dbFrom(ctx, r.db).Where("user_id = ?", userID).Delete(&models.UserSettings{}).Error
+}
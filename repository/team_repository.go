@@ -0,0 +1,67 @@
+package repository
+
+import (
+	"context"
+
+	"go-boilerplate/models"
+	"go-boilerplate/repository/interfaces"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type teamRepository struct {
+	db *gorm.DB
+}
+
+func NewTeamRepository(db *gorm.DB) interfaces.TeamRepository {
+	return &teamRepository{db: db}
+}
+
+func (r *teamRepository) Create(ctx context.Context, team *models.Team) error {
+	return dbFrom(ctx, r.db).Create(team).Error
+}
+
+func (r *teamRepository) GetByID(ctx context.Context, orgID, id uint) (*models.Team, error) {
+	var team models.Team
+	err := dbFrom(ctx, r.db).Where("organization_id = ?", orgID).First(&team, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &team, nil
+}
+
+func (r *teamRepository) ListByOrganization(ctx context.Context, orgID uint) ([]*models.Team, error) {
+	var teams []*models.Team
+	err := dbFrom(ctx, r.db).Where("organization_id = ?", orgID).Order("name").Find(&teams).Error
+	return teams, err
+}
+
+func (r *teamRepository) AddMember(ctx context.Context, member *models.TeamMember) error {
+	return dbFrom(ctx, r.db).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "team_id"}, {Name: "user_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"role"}),
+	}).Create(member).Error
+}
+
+func (r *teamRepository) GetMember(ctx context.Context, teamID, userID uint) (*models.TeamMember, error) {
+	var member models.TeamMember
+	err := dbFrom(ctx, r.db).
+		Joins("User").
+		Where("team_members.team_id = ? AND team_members.user_id = ?", teamID, userID).
+		First(&member).Error
+	if err != nil {
+		return nil, err
+	}
+	return &member, nil
+}
+
+func (r *teamRepository) ListMembers(ctx context.Context, teamID uint) ([]*models.TeamMember, error) {
+	var members []*models.TeamMember
+	err := dbFrom(ctx, r.db).
+		Joins("User").
+		Where("team_members.team_id = ?", teamID).
+		Order("team_members.id").
+		Find(&members).Error
+	return members, err
+}
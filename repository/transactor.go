@@ -0,0 +1,73 @@
+package repository
+
+import (
+	"context"
+	"sync"
+
+	"go-boilerplate/repository/interfaces"
+
+	"gorm.io/gorm"
+)
+
+type txKey struct{}
+
+// afterCommitKey carries the hooks of the innermost transaction.
+type afterCommitKey struct{}
+
+type afterCommitHooks struct {
+	mu  sync.Mutex
+	fns []func(ctx context.Context)
+}
+
+func (h *afterCommitHooks) add(fns ...func(ctx context.Context)) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.fns = append(h.fns, fns...)
+}
+
+type transactor struct {
+	db *gorm.DB
+}
+
+func NewTransactor(db *gorm.DB) interfaces.Transactor {
+	return &transactor{db: db}
+}
+
+// WithinTransaction nests as a savepoint when ctx already carries a
+// transaction. Hooks registered with AfterCommit in a savepoint that rolls
+// back are dropped; the others run once the outermost transaction commits.
+func (t *transactor) WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	parent, nested := ctx.Value(afterCommitKey{}).(*afterCommitHooks)
+	hooks := &afterCommitHooks{}
+	err := dbFrom(ctx, t.db).Transaction(func(tx *gorm.DB) error {
+		txCtx := context.WithValue(context.WithValue(ctx, txKey{}, tx), afterCommitKey{}, hooks)
+		return fn(txCtx)
+	})
+	if err != nil {
+		return err
+	}
+	if nested {
+		parent.add(hooks.fns...)
+		return nil
+	}
+	for _, hook := range hooks.fns {
+		hook(ctx)
+	}
+	return nil
+}
+
+func (t *transactor) AfterCommit(ctx context.Context, fn func(ctx context.Context)) {
+	if hooks, ok := ctx.Value(afterCommitKey{}).(*afterCommitHooks); ok {
+		hooks.add(fn)
+		return
+	}
+	fn(ctx)
+}
+
+// dbFrom returns the transaction carried by ctx, or db if there is none, bound to ctx.
+func dbFrom(ctx context.Context, db *gorm.DB) *gorm.DB {
+	if tx, ok := ctx.Value(txKey{}).(*gorm.DB); ok {
+		return tx.WithContext(ctx)
+	}
+	return db.WithContext(ctx)
+}
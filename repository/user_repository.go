@@ -1,10 +1,17 @@
 package repository
 
 import (
+	"context"
+	"strings"
+	"time"
+
 	"go-boilerplate/models"
+	"go-boilerplate/models/request"
 	"go-boilerplate/repository/interfaces"
+	"go-boilerplate/tenant"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type userRepository struct {
@@ -15,44 +22,239 @@ func NewUserRepository(db *gorm.DB) interfaces.UserRepository {
 	return &userRepository{db: db}
 }
 
-func (r *userRepository) Create(user *models.User) error {
-	return r.db.Create(user).Error
+// Create inserts the user. Within a tenant the user also becomes a member of
+// that organization, in the same transaction.
+func (r *userRepository) Create(ctx context.Context, user *models.User) error {
+	return dbFrom(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(user).Error; err != nil {
+			return err
+		}
+		return addTenantMemberships(ctx, tx, []*models.User{user})
+	})
 }
 
-func (r *userRepository) GetByID(id uint) (*models.User, error) {
+func (r *userRepository) GetByID(ctx context.Context, id uint) (*models.User, error) {
 	var user models.User
-	err := r.db.First(&user, id).Error
+	err := dbFrom(ctx, r.db).First(&user, id).Error
 	if err != nil {
 		return nil, err
 	}
 	return &user, nil
 }
 
-func (r *userRepository) GetByEmail(email string) (*models.User, error) {
+func (r *userRepository) GetByEmail(ctx context.Context, email string) (*models.User, error) {
 	var user models.User
-	err := r.db.Where("email = ?", email).First(&user).Error
+	err := dbFrom(ctx, r.db).Where("email = ?", email).First(&user).Error
 	if err != nil {
 		return nil, err
 	}
 	return &user, nil
 }
 
-func (r *userRepository) GetAll(offset, limit int) ([]*models.User, int64, error) {
+// GetExistingEmails returns which of the given emails already belong to live users.
+func (r *userRepository) GetExistingEmails(ctx context.Context, emails []string) (map[string]bool, error) {
+	existing := make(map[string]bool, len(emails))
+	if len(emails) == 0 {
+		return existing, nil
+	}
+
+	var found []string
+	if err := dbFrom(ctx, r.db).Model(&models.User{}).Where("email IN ?", emails).Pluck("email", &found).Error; err != nil {
+		return nil, err
+	}
+	for _, email := range found {
+		existing[email] = true
+	}
+	return existing, nil
+}
+
+// CreateBatch inserts users in a single transaction; either all rows are stored or none.
+func (r *userRepository) CreateBatch(ctx context.Context, users []*models.User) error {
+	if len(users) == 0 {
+		return nil
+	}
+	return dbFrom(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&users).Error; err != nil {
+			return err
+		}
+		return addTenantMemberships(ctx, tx, users)
+	})
+}
+
+// addTenantMemberships makes newly created users members of the context's tenant, if any.
+func addTenantMemberships(ctx context.Context, tx *gorm.DB, users []*models.User) error {
+	tenantID, ok := tenant.ID(ctx)
+	if !ok {
+		return nil
+	}
+	memberships := make([]*models.Membership, len(users))
+	for i, user := range users {
+		memberships[i] = &models.Membership{OrganizationID: tenantID, UserID: user.ID, Role: models.MembershipRoleMember}
+	}
+	return tx.Create(&memberships).Error
+}
+
+func (r *userRepository) GetAll(ctx context.Context, offset, limit int, filter *request.UserFilter) ([]*models.User, int64, error) {
 	var users []*models.User
 	var total int64
 
-	if err := r.db.Model(&models.User{}).Count(&total).Error; err != nil {
+	if err := applyUserFilter(dbFrom(ctx, r.db).Model(&models.User{}), filter).Count(&total).Error; err != nil {
 		return nil, 0, err
 	}
 
-	err := r.db.Offset(offset).Limit(limit).Find(&users).Error
+	err := applyUserFilter(dbFrom(ctx, r.db), filter).Order("id").Offset(offset).Limit(limit).Find(&users).Error
 	return users, total, err
 }
 
-func (r *userRepository) Update(user *models.User) error {
-	return r.db.Save(user).Error
+// Stream walks all users matching filter in id order using a database cursor,
+// so memory use does not grow with the result size. Only the given columns are loaded.
+func (r *userRepository) Stream(ctx context.Context, filter *request.UserFilter, columns []string, fn func(*models.User) error) error {
+	rows, err := applyUserFilter(dbFrom(ctx, r.db).Model(&models.User{}), filter).Select(columns).Order("id").Rows()
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var user models.User
+		if err := r.db.ScanRows(rows, &user); err != nil {
+			return err
+		}
+		if err := fn(&user); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// applyUserFilter adds the WHERE clauses for a UserFilter. A nil filter matches everything.
+func applyUserFilter(q *gorm.DB, filter *request.UserFilter) *gorm.DB {
+	if filter == nil {
+		return q
+	}
+	if filter.Search != "" {
+		pattern := "%" + likeEscaper.Replace(filter.Search) + "%"
+		q = q.Where("name ILIKE ? OR email ILIKE ?", pattern, pattern)
+	}
+	if filter.Role != "" {
+		q = q.Where("role = ?", filter.Role)
+	}
+	if filter.CreatedAfter != nil {
+		q = q.Where("created_at >= ?", *filter.CreatedAfter)
+	}
+	if filter.CreatedBefore != nil {
+		q = q.Where("created_at < ?", *filter.CreatedBefore)
+	}
+	return q
+}
+
+// likeEscaper escapes LIKE wildcards so search terms match literally.
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
+// Update writes all columns except the activity timestamps with a
+// version-checked UPDATE ... WHERE version = ? and bumps the version. It
+// returns models.ErrVersionConflict when the row was modified since
+// user.Version was read.
+func (r *userRepository) Update(ctx context.Context, user *models.User) error {
+	current := user.Version
+	user.Version = current + 1
+	result := dbFrom(ctx, r.db).Model(user).
+		Where("version = ?", current).
+		Select("*").
+		Omit("created_at", "deleted_at", "last_login_at", "last_seen_at").
+		Updates(user)
+	if result.Error != nil {
+		user.Version = current
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		user.Version = current
+		return models.ErrVersionConflict
+	}
+	return nil
+}
+
+// Delete soft-deletes the user only if it is still at the given version.
+func (r *userRepository) Delete(ctx context.Context, id uint, version uint) error {
+	result := dbFrom(ctx, r.db).Where("version = ?", version).Delete(&models.User{}, id)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return models.ErrVersionConflict
+	}
+	return nil
+}
+
+// GetDeleted lists soft-deleted users, most recently deleted first.
+func (r *userRepository) GetDeleted(ctx context.Context, offset, limit int) ([]*models.User, int64, error) {
+	var users []*models.User
+	var total int64
+
+	trashed := dbFrom(ctx, r.db).Unscoped().Model(&models.User{}).Where("deleted_at IS NOT NULL")
+	if err := trashed.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	err := dbFrom(ctx, r.db).Unscoped().Where("deleted_at IS NOT NULL").
+		Order("deleted_at DESC").
+		Offset(offset).Limit(limit).
+		Find(&users).Error
+	return users, total, err
+}
+
+// GetDeletedByID fetches a single soft-deleted user.
+func (r *userRepository) GetDeletedByID(ctx context.Context, id uint) (*models.User, error) {
+	var user models.User
+	err := dbFrom(ctx, r.db).Unscoped().Where("deleted_at IS NOT NULL").First(&user, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// Restore clears deleted_at on a soft-deleted user and bumps its version.
+// It returns gorm.ErrRecordNotFound if the user is not in the trash.
+func (r *userRepository) Restore(ctx context.Context, id uint) error {
+	result := dbFrom(ctx, r.db).Unscoped().Model(&models.User{}).
+		Where("id = ? AND deleted_at IS NOT NULL", id).
+		Updates(map[string]interface{}{
+			"deleted_at": nil,
+			"version":    gorm.Expr("version + 1"),
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// PurgeDeleted permanently removes users soft-deleted before the cutoff. The
+// removed rows are returned (via RETURNING) so callers can clean up their files.
+func (r *userRepository) PurgeDeleted(ctx context.Context, before time.Time) ([]*models.User, error) {
+	var users []*models.User
+	err := dbFrom(ctx, r.db).Unscoped().Clauses(clause.Returning{}).
+		Where("deleted_at IS NOT NULL AND deleted_at < ?", before).
+		Delete(&users).Error
+	return users, err
+}
+
+func (r *userRepository) GetDueErasures(ctx context.Context, before time.Time) ([]*models.User, error) {
+	var users []*models.User
+	err := dbFrom(ctx, r.db).Where("erasure_scheduled_at IS NOT NULL AND erasure_scheduled_at <= ? AND erased_at IS NULL", before).
+		Order("id").
+		Find(&users).Error
+	return users, err
+}
+
+// SetLastLogin and SetLastSeen write a single activity column without
+// bumping the version, so they never conflict with concurrent profile edits.
+func (r *userRepository) SetLastLogin(ctx context.Context, id uint, at time.Time) error {
+	return dbFrom(ctx, r.db).Model(&models.User{}).Where("id = ?", id).UpdateColumn("last_login_at", at).Error
 }
 
-func (r *userRepository) Delete(id uint) error {
-	return r.db.Delete(&models.User{}, id).Error
+func (r *userRepository) SetLastSeen(ctx context.Context, id uint, at time.Time) error {
+	return dbFrom(ctx, r.db).Model(&models.User{}).Where("id = ?", id).UpdateColumn("last_seen_at", at).Error
 }
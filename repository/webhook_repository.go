@@ -0,0 +1,161 @@
+package repository
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"go-boilerplate/models"
+	"go-boilerplate/repository/interfaces"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type webhookRepository struct {
+	db *gorm.DB
+}
+
+func NewWebhookRepository(db *gorm.DB) interfaces.WebhookRepository {
+	return &webhookRepository{db: db}
+}
+
+func (r *webhookRepository) CreateSubscription(ctx context.Context, sub *models.WebhookSubscription) error {
+	return dbFrom(ctx, r.db).Create(sub).Error
+}
+
+func (r *webhookRepository) GetSubscription(ctx context.Context, orgID, id uint) (*models.WebhookSubscription, error) {
+	var sub models.WebhookSubscription
+	err := dbFrom(ctx, r.db).Where("organization_id = ?", orgID).First(&sub, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &sub, nil
+}
+
+func (r *webhookRepository) ListSubscriptions(ctx context.Context, orgID uint) ([]*models.WebhookSubscription, error) {
+	var subs []*models.WebhookSubscription
+	err := dbFrom(ctx, r.db).Where("organization_id = ?", orgID).Order("id").Find(&subs).Error
+	return subs, err
+}
+
+func (r *webhookRepository) UpdateSubscription(ctx context.Context, sub *models.WebhookSubscription) error {
+	sub.Version++
+	return dbFrom(ctx, r.db).Model(sub).
+		Select("url", "description", "event_types", "secret", "active", "version").
+		Updates(sub).Error
+}
+
+func (r *webhookRepository) DeleteSubscription(ctx context.Context, orgID, id uint) error {
+	result := dbFrom(ctx, r.db).Where("organization_id = ?", orgID).Delete(&models.WebhookSubscription{}, id)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+func (r *webhookRepository) ListActiveSubscriptions(ctx context.Context, orgIDs []uint) ([]*models.WebhookSubscription, error) {
+	var subs []*models.WebhookSubscription
+	if len(orgIDs) == 0 {
+		return subs, nil
+	}
+	err := dbFrom(ctx, r.db).Where("organization_id IN ? AND active", orgIDs).Order("id").Find(&subs).Error
+	return subs, err
+}
+
+func (r *webhookRepository) CreateDeliveries(ctx context.Context, deliveries []*models.WebhookDelivery) error {
+	if len(deliveries) == 0 {
+		return nil
+	}
+	return dbFrom(ctx, r.db).
+		Omit(clause.Associations).
+		Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "subscription_id"}, {Name: "event_id"}},
+			DoNothing: true,
+		}).
+		Create(&deliveries).Error
+}
+
+func (r *webhookRepository) ListDeliveries(ctx context.Context, subID uint, status string, offset, limit int) ([]*models.WebhookDelivery, int64, error) {
+	var deliveries []*models.WebhookDelivery
+	var total int64
+
+	query := dbFrom(ctx, r.db).Model(&models.WebhookDelivery{}).Where("subscription_id = ?", subID)
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	err := query.Order("created_at DESC, id DESC").Offset(offset).Limit(limit).Find(&deliveries).Error
+	return deliveries, total, err
+}
+
+func (r *webhookRepository) GetDelivery(ctx context.Context, subID, id uint) (*models.WebhookDelivery, error) {
+	var delivery models.WebhookDelivery
+	err := dbFrom(ctx, r.db).Where("subscription_id = ?", subID).First(&delivery, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &delivery, nil
+}
+
+func (r *webhookRepository) ClaimDue(ctx context.Context, limit int, lease time.Duration) ([]*models.WebhookDelivery, error) {
+	var ids []uint
+	err := dbFrom(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		// SKIP LOCKED lets several instances claim disjoint batches.
+		err := tx.Model(&models.WebhookDelivery{}).
+			Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status = ? AND next_attempt_at <= ?", models.WebhookDeliveryPending, now).
+			Order("next_attempt_at").
+			Limit(limit).
+			Pluck("id", &ids).Error
+		if err != nil || len(ids) == 0 {
+			return err
+		}
+		return tx.Model(&models.WebhookDelivery{}).Where("id IN ?", ids).
+			Update("next_attempt_at", now.Add(lease)).Error
+	})
+	if err != nil || len(ids) == 0 {
+		return nil, err
+	}
+
+	var deliveries []*models.WebhookDelivery
+	err = dbFrom(ctx, r.db).
+		Preload("Subscription", func(db *gorm.DB) *gorm.DB { return db.Unscoped() }).
+		Where("id IN ?", ids).
+		Order("id").
+		Find(&deliveries).Error
+	return deliveries, err
+}
+
+func (r *webhookRepository) UpdateDelivery(ctx context.Context, delivery *models.WebhookDelivery) error {
+	return dbFrom(ctx, r.db).Model(delivery).
+		Select("status", "attempts", "next_attempt_at", "last_attempt_at", "last_status_code", "last_error", "last_duration_ms", "delivered_at").
+		Updates(delivery).Error
+}
+
+func (r *webhookRepository) RedactUserDeliveries(ctx context.Context, userID uint, fields map[string]any) (int64, error) {
+	data, err := redactJSON(gorm.Expr("(body::jsonb -> 'data')"), fields)
+	if err != nil {
+		return 0, err
+	}
+	// Only user events are delivered, and their data carries the user's id.
+	// updated_at is left alone so that retention is unaffected.
+	result := dbFrom(ctx, r.db).Model(&models.WebhookDelivery{}).
+		Where("body::jsonb #>> '{data,id}' = ?", strconv.FormatUint(uint64(userID), 10)).
+		UpdateColumn("body", gorm.Expr("jsonb_set(body::jsonb, '{data}', ?)::text", data))
+	return result.RowsAffected, result.Error
+}
+
+func (r *webhookRepository) DeleteFinishedDeliveries(ctx context.Context, before time.Time) (int64, error) {
+	result := dbFrom(ctx, r.db).
+		Where("status IN ? AND updated_at < ?", []string{models.WebhookDeliverySucceeded, models.WebhookDeliveryDead}, before).
+		Delete(&models.WebhookDelivery{})
+	return result.RowsAffected, result.Error
+}
@@ -1,8 +1,15 @@
 package routes
 
 import (
+	"context"
+
+	"go-boilerplate/config"
 	"go-boilerplate/handlers"
+	"go-boilerplate/idempotency"
+	"go-boilerplate/logger"
 	"go-boilerplate/middleware"
+	"go-boilerplate/models"
+	"go-boilerplate/ratelimit"
 	"go-boilerplate/services/interfaces"
 
 	"github.com/gin-gonic/gin"
@@ -11,25 +18,62 @@ import (
 func SetupRoutes(
 	userHandler *handlers.UserHandler,
 	authHandler *handlers.AuthHandler,
+	importHandler *handlers.UserImportHandler,
+	exportHandler *handlers.UserExportHandler,
+	uploadHandler *handlers.UploadHandler,
+	tusHandler *handlers.TusHandler,
+	gdprHandler *handlers.GDPRHandler,
+	settingsHandler *handlers.SettingsHandler,
+	orgHandler *handlers.OrganizationHandler,
+	teamHandler *handlers.TeamHandler,
+	invitationHandler *handlers.InvitationHandler,
+	auditHandler *handlers.AuditHandler,
+	webhookHandler *handlers.WebhookHandler,
+	cacheHandler *handlers.CacheHandler,
 	healthHandler *handlers.HealthHandler,
 	authService interfaces.AuthService,
+	userService interfaces.UserService,
+	orgService interfaces.OrganizationService,
+	tenantHeader string,
+	rateLimiter *ratelimit.Limiter,
+	rateLimits config.RateLimitConfig,
+	idempotencyStore *idempotency.Store,
+	idempotencyCfg config.IdempotencyConfig,
+	trustedProxies []string,
 ) *gin.Engine {
 	router := gin.Default()
 
-	// Middleware
-	router.Use(middleware.CORSMiddleware())
+	// c.ClientIP, which rate limits count by, only believes forwarding
+	// headers set by these proxies. Gin trusts every proxy by default, so a
+	// list it rejects falls back to trusting none.
+	if err := router.SetTrustedProxies(trustedProxies); err != nil {
+		logger.Error(context.Background(), "SetupRoutes: trusted proxies rejected, trusting none", map[string]any{"error": err.Error()})
+		_ = router.SetTrustedProxies(nil)
+	}
+
+	// Middleware. Tracing is attached first so that every handler sees the trace ID.
+	router.Use(logger.GinMiddleware(), middleware.CORSMiddleware())
 
 	// Health check
 	router.GET("/health", healthHandler.Check)
 
-	// API v1 routes
-	v1 := router.Group("/api/v1")
+	// API v1 routes. Rate limits are checked first. The tenant, if any, is
+	// resolved before any handler runs, and the authenticated user's
+	// last_seen_at is updated after it. POSTs with an Idempotency-Key are
+	// replayed from their first response.
+	v1 := router.Group("/api/v1",
+		middleware.RateLimitMiddleware(rateLimiter, authService, rateLimits),
+		middleware.TenantMiddleware(authService, orgService, tenantHeader),
+		middleware.LastSeenMiddleware(userService),
+		middleware.IdempotencyMiddleware(idempotencyStore, authService, idempotencyCfg),
+	)
 	{
 		// Auth routes (public)
 		auth := v1.Group("/auth")
 		{
 			auth.POST("/register", authHandler.Register)
 			auth.POST("/login", authHandler.Login)
+			auth.GET("/email/confirm", authHandler.ConfirmEmail)
 
 			// Protected auth routes
 			authProtected := auth.Use(middleware.AuthMiddleware(authService))
@@ -39,6 +83,68 @@ func SetupRoutes(
 			}
 		}
 
+		// Current user's own resources
+		me := v1.Group("/me", middleware.AuthMiddleware(authService))
+		{
+			me.GET("/settings", settingsHandler.GetSettings)
+			me.PATCH("/settings", settingsHandler.UpdateSettings)
+			me.GET("/login-history", authHandler.LoginHistory)
+		}
+
+		// Organizations (tenants) of the current user
+		orgs := v1.Group("/organizations", middleware.AuthMiddleware(authService))
+		{
+			orgs.POST("", orgHandler.CreateOrganization)
+			orgs.GET("", orgHandler.ListOrganizations)
+			orgs.POST("/:id/token", orgHandler.IssueToken)
+			orgs.GET("/:id/members", orgHandler.ListMembers)
+			orgs.POST("/:id/teams", teamHandler.CreateTeam)
+			orgs.GET("/:id/teams", teamHandler.ListTeams)
+			orgs.POST("/:id/teams/:team_id/members", teamHandler.AddTeamMember)
+			orgs.GET("/:id/teams/:team_id/members", teamHandler.ListTeamMembers)
+			orgs.POST("/:id/invitations", invitationHandler.CreateInvitation)
+			orgs.GET("/:id/invitations", invitationHandler.ListInvitations)
+			orgs.DELETE("/:id/invitations/:invitation_id", invitationHandler.RevokeInvitation)
+			orgs.POST("/:id/webhooks", webhookHandler.CreateWebhook)
+			orgs.GET("/:id/webhooks", webhookHandler.ListWebhooks)
+			orgs.GET("/:id/webhooks/:webhook_id", webhookHandler.GetWebhook)
+			orgs.PATCH("/:id/webhooks/:webhook_id", webhookHandler.UpdateWebhook)
+			orgs.DELETE("/:id/webhooks/:webhook_id", webhookHandler.DeleteWebhook)
+			orgs.GET("/:id/webhooks/:webhook_id/deliveries", webhookHandler.ListDeliveries)
+			orgs.POST("/:id/webhooks/:webhook_id/deliveries/:delivery_id/redeliver", webhookHandler.Redeliver)
+		}
+
+		// Audit log (admin only)
+		auditLog := v1.Group("/audit", middleware.AuthMiddleware(authService), middleware.RequireRole(userService, models.RoleAdmin))
+		{
+			auditLog.GET("", auditHandler.ListEvents)
+			auditLog.GET("/verify", auditHandler.VerifyChain)
+		}
+
+		// Cache statistics of this instance (admin only)
+		v1.GET("/cache/stats", middleware.AuthMiddleware(authService), middleware.RequireRole(userService, models.RoleAdmin), cacheHandler.Stats)
+
+		// Invitation links are authorized by their signed token.
+		v1.GET("/invitations", invitationHandler.PreviewInvitation)
+		v1.POST("/invitations/accept", invitationHandler.AcceptInvitation)
+
+		// Upload routes. Downloads are authorized by the signed URL itself.
+		v1.POST("/uploads", middleware.AuthMiddleware(authService), uploadHandler.Upload)
+		v1.GET("/files/*key", uploadHandler.Download)
+
+		// Resumable uploads (tus 1.0). Discovery via OPTIONS is public.
+		v1.OPTIONS("/uploads/tus", tusHandler.Options)
+		tus := v1.Group("/uploads/tus", tusHandler.RequireResumable, middleware.AuthMiddleware(authService))
+		{
+			tus.POST("", tusHandler.Create)
+			tus.HEAD("/:id", tusHandler.Head)
+			tus.PATCH("/:id", tusHandler.Patch)
+			tus.DELETE("/:id", tusHandler.Terminate)
+		}
+
+		// Avatar thumbnails are public and immutable.
+		v1.GET("/avatars/*key", userHandler.GetAvatar)
+
 		// User routes
 		users := v1.Group("/users")
 		{
@@ -50,7 +156,28 @@ func SetupRoutes(
 			protected := users.Use(middleware.AuthMiddleware(authService))
 			{
 				protected.PUT("/:id", userHandler.UpdateUser)
+				protected.PATCH("/:id", userHandler.UpdateUser)
 				protected.DELETE("/:id", userHandler.DeleteUser)
+				protected.PUT("/:id/avatar", userHandler.UpdateAvatar)
+				protected.DELETE("/:id/avatar", userHandler.DeleteAvatar)
+			}
+
+			// Data subject requests (GDPR), for the user themselves or an admin
+			gdpr := users.Group("/:id", middleware.RequireSelfOrRole(userService, models.RoleAdmin))
+			{
+				gdpr.POST("/data-export", gdprHandler.DataExport)
+				gdpr.POST("/erasure", gdprHandler.RequestErasure)
+				gdpr.DELETE("/erasure", gdprHandler.CancelErasure)
+			}
+
+			// Admin routes
+			admin := users.Group("", middleware.RequireRole(userService, models.RoleAdmin))
+			{
+				admin.GET("/trash", userHandler.GetTrash)
+				admin.POST("/:id/restore", userHandler.RestoreUser)
+				admin.POST("/import", importHandler.Import)
+				admin.GET("/import/:job_id", importHandler.GetJob)
+				admin.GET("/export", exportHandler.Export)
 			}
 		}
 	}
@@ -0,0 +1,133 @@
+package services
+
+import (
+	"context"
+	"errors"
+
+	"go-boilerplate/audit"
+	"go-boilerplate/logger"
+	"go-boilerplate/models"
+	"go-boilerplate/models/request"
+	"go-boilerplate/models/response"
+	repoInterfaces "go-boilerplate/repository/interfaces"
+	serviceInterfaces "go-boilerplate/services/interfaces"
+	"go-boilerplate/utilities"
+)
+
+// errChainBroken stops walking the audit log at the first invalid event.
+var errChainBroken = errors.New("audit chain broken")
+
+type auditService struct {
+	auditRepo repoInterfaces.AuditRepository
+}
+
+func NewAuditService(auditRepo repoInterfaces.AuditRepository) serviceInterfaces.AuditService {
+	return &auditService{auditRepo: auditRepo}
+}
+
+func (s *auditService) Record(ctx context.Context, actor models.Actor, action, entityType string, entityID uint, details map[string]any) error {
+	event := &models.AuditEvent{
+		Action:     action,
+		EntityType: entityType,
+		EntityID:   entityID,
+		Details:    details,
+	}
+	return s.append(ctx, actor, event)
+}
+
+func (s *auditService) RecordChange(ctx context.Context, action, entityType string, entityID uint, changes map[string]models.FieldChange) error {
+	event := &models.AuditEvent{
+		Action:     action,
+		EntityType: entityType,
+		EntityID:   entityID,
+		Changes:    changes,
+	}
+	return s.append(ctx, audit.ActorFrom(ctx), event)
+}
+
+// append fills in the actor and trace ID and stores the event.
+func (s *auditService) append(ctx context.Context, actor models.Actor, event *models.AuditEvent) error {
+	event.IP = actor.IP
+	event.TraceID = logger.TraceID(ctx)
+	if actor.UserID != 0 {
+		actorID := actor.UserID
+		event.ActorID = &actorID
+	}
+	if err := s.auditRepo.Create(ctx, event); err != nil {
+		logger.Error(ctx, "AuditService.Record failed", map[string]any{"action": event.Action, "entity_id": event.EntityID, "error": err.Error()})
+		return err
+	}
+	logger.Info(ctx, "AuditService.Record success", map[string]any{"audit_id": event.ID, "action": event.Action, "actor_id": actor.UserID, "entity_id": event.EntityID})
+	return nil
+}
+
+func (s *auditService) ListForUser(ctx context.Context, userID uint) ([]*models.AuditEvent, error) {
+	events, err := s.auditRepo.ListByUser(ctx, userID)
+	if err != nil {
+		logger.Error(ctx, "AuditService.ListForUser failed", map[string]any{"user_id": userID, "error": err.Error()})
+		return nil, err
+	}
+	return events, nil
+}
+
+func (s *auditService) Search(ctx context.Context, page, perPage int, filter *request.AuditFilter) (*response.PaginationResponse, error) {
+	page, perPage, offset := utilities.CalculateOffset(page, perPage)
+	events, total, err := s.auditRepo.Search(ctx, filter, offset, perPage)
+	if err != nil {
+		logger.Error(ctx, "AuditService.Search failed", map[string]any{"page": page, "per_page": perPage, "error": err.Error()})
+		return nil, err
+	}
+	return &response.PaginationResponse{
+		Data:       events,
+		Page:       page,
+		PerPage:    perPage,
+		Total:      total,
+		TotalPages: utilities.TotalPages(int(total), perPage),
+	}, nil
+}
+
+func (s *auditService) VerifyChain(ctx context.Context) (*response.AuditVerifyResponse, error) {
+	logger.Info(ctx, "AuditService.VerifyChain start", nil)
+	res := &response.AuditVerifyResponse{Valid: true}
+	prevHash := ""
+	chained := false
+	err := s.auditRepo.Walk(ctx, func(event *models.AuditEvent) error {
+		// Events from before the chain existed have no hash; after the first
+		// chained event every event must have one.
+		if event.Hash == "" && !chained {
+			res.Unchained++
+			return nil
+		}
+		chained = true
+
+		reason := ""
+		switch {
+		case event.PrevHash != prevHash:
+			reason = "previous hash does not match the preceding event"
+		case event.Hash != event.ComputeHash():
+			reason = "hash does not match the event content"
+		}
+		if reason != "" {
+			id := event.ID
+			res.Valid = false
+			res.FirstInvalidID = &id
+			res.Reason = reason
+			return errChainBroken
+		}
+		res.Checked++
+		prevHash = event.Hash
+		return nil
+	})
+	if err != nil && !errors.Is(err, errChainBroken) {
+		logger.Error(ctx, "AuditService.VerifyChain failed", map[string]any{"error": err.Error()})
+		return nil, err
+	}
+	if res.Valid {
+		res.HeadHash = prevHash
+	} else {
+		logger.Warn(ctx, "VerifyChain: chain broken", map[string]any{"audit_id": *res.FirstInvalidID, "reason": res.Reason})
+	}
+
+	logger.Info(ctx, "AuditService.VerifyChain success", map[string]any{"valid": res.Valid, "checked": res.Checked})
+	return res, nil
+}
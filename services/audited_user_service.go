@@ -0,0 +1,156 @@
+package services
+
+import (
+	"context"
+	"time"
+
+	"go-boilerplate/config"
+	"go-boilerplate/logger"
+	"go-boilerplate/models"
+	"go-boilerplate/models/request"
+	"go-boilerplate/models/response"
+	repoInterfaces "go-boilerplate/repository/interfaces"
+	serviceInterfaces "go-boilerplate/services/interfaces"
+	"go-boilerplate/tenant"
+	"go-boilerplate/utilities"
+)
+
+// auditedUserService decorates a UserService so that updates, email changes,
+// deletions and restores of users are recorded in the audit log with a
+// field-level diff. The actor is taken from the request context (see
+// audit.WithActor). Each change and its audit event are written in one
+// transaction, so a change that cannot be audited does not happen.
+type auditedUserService struct {
+	serviceInterfaces.UserService
+	userRepo     repoInterfaces.UserRepository
+	transactor   repoInterfaces.Transactor
+	authService  serviceInterfaces.AuthService
+	auditService serviceInterfaces.AuditService
+	piiKey       string
+}
+
+func NewAuditedUserService(userService serviceInterfaces.UserService, userRepo repoInterfaces.UserRepository, transactor repoInterfaces.Transactor, authService serviceInterfaces.AuthService, auditService serviceInterfaces.AuditService, cfg *config.Config) serviceInterfaces.UserService {
+	return &auditedUserService{
+		UserService:  userService,
+		userRepo:     userRepo,
+		transactor:   transactor,
+		authService:  authService,
+		auditService: auditService,
+		piiKey:       cfg.GDPR.AuditPIIKey,
+	}
+}
+
+func (s *auditedUserService) UpdateUser(ctx context.Context, id uint, versions models.VersionMatch, req *request.UpdateUserRequest) (*response.UserResponse, error) {
+	var user *response.UserResponse
+	err := s.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		before := s.snapshot(ctx, "UpdateUser", id)
+		var err error
+		if user, err = s.UserService.UpdateUser(ctx, id, versions, req); err != nil {
+			return err
+		}
+		return s.record(ctx, models.AuditActionUserUpdated, id, diffFields(before, s.auditFields(user)))
+	})
+	if err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// ConfirmEmailChange is the only write that changes a user's email; updates
+// only set pending_email.
+func (s *auditedUserService) ConfirmEmailChange(ctx context.Context, token string) (*response.UserResponse, error) {
+	var user *response.UserResponse
+	err := s.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		var before map[string]any
+		if id, _, err := parseEmailChangeToken(ctx, s.authService, token); err == nil {
+			before = s.snapshot(tenant.WithoutScope(ctx), "ConfirmEmailChange", id)
+		}
+		var err error
+		if user, err = s.UserService.ConfirmEmailChange(ctx, token); err != nil {
+			return err
+		}
+		return s.record(ctx, models.AuditActionUserEmailChanged, user.ID, diffFields(before, s.auditFields(user)))
+	})
+	if err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+func (s *auditedUserService) DeleteUser(ctx context.Context, id uint, versions models.VersionMatch) error {
+	return s.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := s.UserService.DeleteUser(ctx, id, versions); err != nil {
+			return err
+		}
+		return s.record(ctx, models.AuditActionUserDeleted, id, map[string]models.FieldChange{
+			"deleted_at": {From: nil, To: time.Now().UTC()},
+		})
+	})
+}
+
+func (s *auditedUserService) RestoreUser(ctx context.Context, id uint) (*response.UserResponse, error) {
+	var user *response.UserResponse
+	err := s.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		var deletedAt any
+		if deleted, err := s.userRepo.GetDeletedByID(ctx, id); err == nil && deleted.DeletedAt.Valid {
+			deletedAt = deleted.DeletedAt.Time.UTC()
+		}
+		var err error
+		if user, err = s.UserService.RestoreUser(ctx, id); err != nil {
+			return err
+		}
+		return s.record(ctx, models.AuditActionUserRestored, id, map[string]models.FieldChange{
+			"deleted_at": {From: deletedAt, To: nil},
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// snapshot reads the audited fields of a user before a change. It returns nil
+// if the user cannot be read; the wrapped call then reports the error.
+func (s *auditedUserService) snapshot(ctx context.Context, op string, id uint) map[string]any {
+	user, err := s.userRepo.GetByID(ctx, id)
+	if err != nil {
+		logger.Debug(ctx, op+": audit snapshot failed", map[string]any{"user_id": id, "error": err.Error()})
+		return nil
+	}
+	return s.auditFields(utilities.ToUserResponse(user))
+}
+
+func (s *auditedUserService) record(ctx context.Context, action string, id uint, changes map[string]models.FieldChange) error {
+	if err := s.auditService.RecordChange(ctx, action, models.AuditEntityUser, id, changes); err != nil {
+		logger.Error(ctx, "auditedUserService: audit record failed", map[string]any{"action": action, "user_id": id, "error": err.Error()})
+		return err
+	}
+	return nil
+}
+
+// auditFields returns the user fields whose changes are audited. Passwords
+// are never part of a UserResponse and so never reach the log. The audit log
+// is append-only and outlives erasure, so email addresses are recorded as
+// keyed hashes (see utilities.HashPII).
+func (s *auditedUserService) auditFields(user *response.UserResponse) map[string]any {
+	return map[string]any{
+		"name":          user.Name,
+		"email":         utilities.HashPII(s.piiKey, user.Email),
+		"pending_email": utilities.HashPII(s.piiKey, user.PendingEmail),
+		"role":          user.Role,
+	}
+}
+
+// diffFields returns the fields whose values differ between before and after.
+// With a nil before, every field is reported as changed from null.
+func diffFields(before, after map[string]any) map[string]models.FieldChange {
+	changes := make(map[string]models.FieldChange)
+	for field, to := range after {
+		from, ok := before[field]
+		if ok && from == to {
+			continue
+		}
+		changes[field] = models.FieldChange{From: from, To: to}
+	}
+	return changes
+}
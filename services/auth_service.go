@@ -2,6 +2,8 @@ package services
 
 import (
 	"context"
+	"crypto/hmac"
+	"crypto/sha256"
 	"errors"
 	"time"
 
@@ -22,16 +24,24 @@ func NewAuthService(cfg *config.Config) interfaces.AuthService {
 	}
 }
 
+// Claims are carried by session tokens. TenantID is set on tokens bound to an
+// organization and selects the tenant for every request made with them.
 type Claims struct {
-	UserID uint `json:"user_id"`
+	UserID   uint `json:"user_id"`
+	TenantID uint `json:"tenant_id,omitempty"`
 	jwt.RegisteredClaims
 }
 
 func (s *authService) GenerateToken(userID uint) (string, error) {
+	return s.GenerateTenantToken(userID, 0)
+}
+
+func (s *authService) GenerateTenantToken(userID, tenantID uint) (string, error) {
 	ctx := context.Background()
-	logger.Debug(ctx, "AuthService.GenerateToken start", map[string]any{"user_id": userID})
+	logger.Debug(ctx, "AuthService.GenerateToken start", map[string]any{"user_id": userID, "tenant_id": tenantID})
 	claims := &Claims{
-		UserID: userID,
+		UserID:   userID,
+		TenantID: tenantID,
 		RegisteredClaims: jwt.RegisteredClaims{
 			ExpiresAt: jwt.NewNumericDate(time.Now().Add(24 * time.Hour)),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
@@ -44,7 +54,7 @@ func (s *authService) GenerateToken(userID uint) (string, error) {
 		logger.Error(ctx, "AuthService.GenerateToken failed", map[string]any{"user_id": userID, "error": err.Error()})
 		return "", err
 	}
-	logger.Info(ctx, "AuthService.GenerateToken success", map[string]any{"user_id": userID})
+	logger.Info(ctx, "AuthService.GenerateToken success", map[string]any{"user_id": userID, "tenant_id": tenantID})
 	return signed, nil
 }
 
@@ -73,3 +83,60 @@ func (s *authService) GetUserIDFromToken(token *jwt.Token) (uint, error) {
 	logger.Debug(ctx, "AuthService.GetUserIDFromToken success", map[string]any{"user_id": claims.UserID})
 	return claims.UserID, nil
 }
+
+func (s *authService) GetTenantIDFromToken(token *jwt.Token) uint {
+	if claims, ok := token.Claims.(*Claims); ok && token.Valid {
+		return claims.TenantID
+	}
+	return 0
+}
+
+// ActionClaims are carried by single-purpose tokens such as email confirmation links.
+type ActionClaims struct {
+	Data map[string]string `json:"data"`
+	jwt.RegisteredClaims
+}
+
+// actionKey derives a per-purpose signing key so that action tokens can never be
+// accepted as session tokens (or as tokens for a different purpose).
+func (s *authService) actionKey(purpose string) []byte {
+	mac := hmac.New(sha256.New, []byte(s.jwtSecret))
+	mac.Write([]byte("action:" + purpose))
+	return mac.Sum(nil)
+}
+
+func (s *authService) GenerateActionToken(purpose string, data map[string]string, ttl time.Duration) (string, error) {
+	ctx := context.Background()
+	logger.Debug(ctx, "AuthService.GenerateActionToken start", map[string]any{"purpose": purpose})
+	now := time.Now()
+	claims := &ActionClaims{
+		Data: data,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Audience:  jwt.ClaimStrings{purpose},
+			ExpiresAt: jwt.NewNumericDate(now.Add(ttl)),
+			IssuedAt:  jwt.NewNumericDate(now),
+		},
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	signed, err := token.SignedString(s.actionKey(purpose))
+	if err != nil {
+		logger.Error(ctx, "AuthService.GenerateActionToken failed", map[string]any{"purpose": purpose, "error": err.Error()})
+		return "", err
+	}
+	return signed, nil
+}
+
+func (s *authService) ParseActionToken(purpose string, tokenString string) (map[string]string, error) {
+	ctx := context.Background()
+	logger.Debug(ctx, "AuthService.ParseActionToken start", map[string]any{"purpose": purpose})
+	claims := &ActionClaims{}
+	_, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
+		return s.actionKey(purpose), nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}), jwt.WithAudience(purpose), jwt.WithExpirationRequired())
+	if err != nil {
+		logger.Warn(ctx, "AuthService.ParseActionToken failed", map[string]any{"purpose": purpose, "error": err.Error()})
+		return nil, err
+	}
+	return claims.Data, nil
+}
@@ -0,0 +1,193 @@
+package services
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"io"
+
+	"go-boilerplate/models"
+
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp" // registers the WebP decoder with image.Decode
+)
+
+// maxAvatarPixels bounds the decoded size of an avatar source image so a small,
+// highly compressed file cannot exhaust memory.
+const maxAvatarPixels = 4096 * 4096
+
+// avatarJPEGQuality is used when re-encoding JPEG sources.
+const avatarJPEGQuality = 85
+
+// avatarTypes are the sniffed MIME types accepted as avatar sources.
+var avatarTypes = map[string]bool{
+	"image/jpeg": true,
+	"image/png":  true,
+	"image/webp": true,
+}
+
+// avatarThumbnail is one encoded square thumbnail.
+type avatarThumbnail struct {
+	Size        int
+	Data        []byte
+	ContentType string
+}
+
+// makeAvatarThumbnails decodes src and renders a center-cropped square
+// thumbnail for each size. Thumbnails are re-encoded from raw pixels, which
+// drops EXIF and any other metadata; the EXIF orientation of JPEG sources is
+// applied first so the result is upright. JPEG sources stay JPEG, PNG and WebP
+// sources become PNG to keep transparency. It returns the file extension used.
+func makeAvatarThumbnails(src io.ReadSeeker, contentType string, sizes []int) ([]avatarThumbnail, string, error) {
+	cfg, _, err := image.DecodeConfig(src)
+	if err != nil {
+		return nil, "", fmt.Errorf("%w: %v", models.ErrInvalidImage, err)
+	}
+	if cfg.Width <= 0 || cfg.Height <= 0 || cfg.Width*cfg.Height > maxAvatarPixels {
+		return nil, "", fmt.Errorf("%w: %dx%d exceeds %d pixels", models.ErrFileTooLarge, cfg.Width, cfg.Height, maxAvatarPixels)
+	}
+
+	orientation := 1
+	if contentType == "image/jpeg" {
+		if _, err := src.Seek(0, io.SeekStart); err != nil {
+			return nil, "", err
+		}
+		orientation = jpegOrientation(src)
+	}
+
+	if _, err := src.Seek(0, io.SeekStart); err != nil {
+		return nil, "", err
+	}
+	img, _, err := image.Decode(src)
+	if err != nil {
+		return nil, "", fmt.Errorf("%w: %v", models.ErrInvalidImage, err)
+	}
+
+	// The center square is the same region before and after any EXIF rotation
+	// or flip, so crop and scale first and orient the (small) thumbnail.
+	b := img.Bounds()
+	side := min(b.Dx(), b.Dy())
+	crop := image.Rect(0, 0, side, side).Add(b.Min).Add(image.Pt((b.Dx()-side)/2, (b.Dy()-side)/2))
+
+	ext := ".png"
+	if contentType == "image/jpeg" {
+		ext = ".jpg"
+	}
+
+	thumbs := make([]avatarThumbnail, 0, len(sizes))
+	for _, size := range sizes {
+		dst := image.NewRGBA(image.Rect(0, 0, size, size))
+		draw.CatmullRom.Scale(dst, dst.Bounds(), img, crop, draw.Src, nil)
+		dst = orientSquare(dst, orientation)
+
+		var buf bytes.Buffer
+		thumb := avatarThumbnail{Size: size}
+		if ext == ".jpg" {
+			err = jpeg.Encode(&buf, dst, &jpeg.Options{Quality: avatarJPEGQuality})
+			thumb.ContentType = "image/jpeg"
+		} else {
+			err = (&png.Encoder{CompressionLevel: png.BestCompression}).Encode(&buf, dst)
+			thumb.ContentType = "image/png"
+		}
+		if err != nil {
+			return nil, "", err
+		}
+		thumb.Data = buf.Bytes()
+		thumbs = append(thumbs, thumb)
+	}
+	return thumbs, ext, nil
+}
+
+// orientSquare applies an EXIF orientation (1-8) to a square image.
+func orientSquare(src *image.RGBA, orientation int) *image.RGBA {
+	if orientation < 2 || orientation > 8 {
+		return src
+	}
+	n := src.Bounds().Dx() - 1
+	dst := image.NewRGBA(src.Bounds())
+	for y := 0; y <= n; y++ {
+		for x := 0; x <= n; x++ {
+			var sx, sy int
+			switch orientation {
+			case 2: // mirror horizontal
+				sx, sy = n-x, y
+			case 3: // rotate 180
+				sx, sy = n-x, n-y
+			case 4: // mirror vertical
+				sx, sy = x, n-y
+			case 5: // transpose
+				sx, sy = y, x
+			case 6: // rotate 90 clockwise
+				sx, sy = y, n-x
+			case 7: // transverse
+				sx, sy = n-y, n-x
+			case 8: // rotate 90 counter-clockwise
+				sx, sy = n-y, x
+			}
+			copy(dst.Pix[dst.PixOffset(x, y):dst.PixOffset(x, y)+4], src.Pix[src.PixOffset(sx, sy):src.PixOffset(sx, sy)+4])
+		}
+	}
+	return dst
+}
+
+// jpegOrientation returns the EXIF orientation tag of a JPEG stream, or 1 if
+// there is none. Only the segments before the image data are read.
+func jpegOrientation(r io.Reader) int {
+	var marker [4]byte
+	if _, err := io.ReadFull(r, marker[:2]); err != nil || marker[0] != 0xFF || marker[1] != 0xD8 {
+		return 1
+	}
+	for {
+		if _, err := io.ReadFull(r, marker[:]); err != nil || marker[0] != 0xFF {
+			return 1
+		}
+		length := int(binary.BigEndian.Uint16(marker[2:])) - 2
+		if marker[1] == 0xDA || length < 0 { // start of scan: no more metadata
+			return 1
+		}
+		segment := make([]byte, length)
+		if _, err := io.ReadFull(r, segment); err != nil {
+			return 1
+		}
+		if marker[1] == 0xE1 && bytes.HasPrefix(segment, []byte("Exif\x00\x00")) {
+			return exifOrientation(segment[6:])
+		}
+	}
+}
+
+// exifOrientation reads the orientation tag (0x0112) from IFD0 of a TIFF block.
+func exifOrientation(tiff []byte) int {
+	if len(tiff) < 8 {
+		return 1
+	}
+	var order binary.ByteOrder
+	switch string(tiff[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return 1
+	}
+	offset := int(order.Uint32(tiff[4:]))
+	if offset < 8 || offset+2 > len(tiff) {
+		return 1
+	}
+	count := int(order.Uint16(tiff[offset:]))
+	for i := 0; i < count; i++ {
+		entry := offset + 2 + i*12
+		if entry+12 > len(tiff) {
+			return 1
+		}
+		if order.Uint16(tiff[entry:]) == 0x0112 {
+			if v := int(order.Uint16(tiff[entry+8:])); v >= 1 && v <= 8 {
+				return v
+			}
+			return 1
+		}
+	}
+	return 1
+}
@@ -0,0 +1,332 @@
+package services
+
+import (
+	"archive/zip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"go-boilerplate/config"
+	"go-boilerplate/logger"
+	"go-boilerplate/models"
+	"go-boilerplate/models/response"
+	repoInterfaces "go-boilerplate/repository/interfaces"
+	serviceInterfaces "go-boilerplate/services/interfaces"
+	"go-boilerplate/storage"
+	"go-boilerplate/tenant"
+	"go-boilerplate/utilities"
+
+	"github.com/redis/go-redis/v9"
+	"gorm.io/gorm"
+)
+
+const (
+	erasedUserName = "Erased User"
+	// erasedPassword is not a valid bcrypt hash, so no password can ever match it.
+	erasedPassword = "!"
+)
+
+type gdprService struct {
+	userRepo        repoInterfaces.UserRepository
+	orgRepo         repoInterfaces.OrganizationRepository
+	loginEventRepo  repoInterfaces.LoginEventRepository
+	outboxRepo      repoInterfaces.OutboxRepository
+	webhookRepo     repoInterfaces.WebhookRepository
+	transactor      repoInterfaces.Transactor
+	auditService    serviceInterfaces.AuditService
+	settingsService serviceInterfaces.SettingsService
+	redisService    serviceInterfaces.RedisService
+	userCache       *UserCache
+	userListCache   *UserListCache
+	authService     serviceInterfaces.AuthService
+	mailService     serviceInterfaces.MailService
+	store           storage.BlobStore
+	gracePeriod     time.Duration
+}
+
+func NewGDPRService(userRepo repoInterfaces.UserRepository, orgRepo repoInterfaces.OrganizationRepository, loginEventRepo repoInterfaces.LoginEventRepository, outboxRepo repoInterfaces.OutboxRepository, webhookRepo repoInterfaces.WebhookRepository, transactor repoInterfaces.Transactor, auditService serviceInterfaces.AuditService, settingsService serviceInterfaces.SettingsService, redisService serviceInterfaces.RedisService, userCache *UserCache, userListCache *UserListCache, authService serviceInterfaces.AuthService, mailService serviceInterfaces.MailService, store storage.BlobStore, cfg *config.Config) serviceInterfaces.GDPRService {
+	return &gdprService{
+		userRepo:        userRepo,
+		orgRepo:         orgRepo,
+		loginEventRepo:  loginEventRepo,
+		outboxRepo:      outboxRepo,
+		webhookRepo:     webhookRepo,
+		transactor:      transactor,
+		auditService:    auditService,
+		settingsService: settingsService,
+		redisService:    redisService,
+		userCache:       userCache,
+		userListCache:   userListCache,
+		authService:     authService,
+		mailService:     mailService,
+		store:           store,
+		gracePeriod:     cfg.GDPR.ErasureGracePeriod,
+	}
+}
+
+func (s *gdprService) ExportUserData(ctx context.Context, actor models.Actor, userID uint, w io.Writer) error {
+	logger.Info(ctx, "GDPRService.ExportUserData start", map[string]any{"user_id": userID, "actor_id": actor.UserID})
+	user, err := s.getUser(ctx, "ExportUserData", userID)
+	if err != nil {
+		return err
+	}
+
+	sessions, err := s.exportSessions(ctx, userID)
+	if err != nil {
+		return err
+	}
+	settings, err := s.settingsService.Get(ctx, userID)
+	if err != nil {
+		return err
+	}
+	events, err := s.auditService.ListForUser(ctx, userID)
+	if err != nil {
+		return err
+	}
+	logins, err := s.loginEventRepo.ListAllByUser(ctx, userID)
+	if err != nil {
+		logger.Error(ctx, "ExportUserData: login history lookup failed", map[string]any{"user_id": userID, "error": err.Error()})
+		return err
+	}
+
+	files := []struct {
+		name string
+		data any
+	}{
+		{"profile.json", utilities.ToUserResponse(user)},
+		{"settings.json", settings},
+		{"sessions.json", sessions},
+		{"audit_events.json", events},
+		{"login_history.json", logins},
+	}
+	zw := zip.NewWriter(w)
+	for _, file := range files {
+		entry, err := zw.Create(file.name)
+		if err != nil {
+			return err
+		}
+		enc := json.NewEncoder(entry)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(file.data); err != nil {
+			logger.Error(ctx, "ExportUserData: encode failed", map[string]any{"user_id": userID, "file": file.name, "error": err.Error()})
+			return err
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return err
+	}
+
+	if err := s.auditService.Record(ctx, actor, models.AuditActionDataExport, models.AuditEntityUser, userID, map[string]any{
+		"sessions":     len(sessions),
+		"audit_events": len(events),
+		"logins":       len(logins),
+	}); err != nil {
+		return err
+	}
+	logger.Info(ctx, "GDPRService.ExportUserData success", map[string]any{"user_id": userID})
+	return nil
+}
+
+// exportSessions describes the user's active session, if any.
+func (s *gdprService) exportSessions(ctx context.Context, userID uint) ([]response.SessionExport, error) {
+	sessions := []response.SessionExport{}
+	token, err := s.redisService.GetUserSession(ctx, userID)
+	if errors.Is(err, redis.Nil) {
+		return sessions, nil
+	}
+	if err != nil {
+		logger.Error(ctx, "exportSessions: session lookup failed", map[string]any{"user_id": userID, "error": err.Error()})
+		return nil, err
+	}
+
+	sum := sha256.Sum256([]byte(token))
+	session := response.SessionExport{TokenSHA256: hex.EncodeToString(sum[:])}
+	if tok, err := s.authService.ValidateToken(token); err == nil {
+		if claims, ok := tok.Claims.(*Claims); ok {
+			if claims.IssuedAt != nil {
+				session.IssuedAt = &claims.IssuedAt.Time
+			}
+			if claims.ExpiresAt != nil {
+				session.ExpiresAt = &claims.ExpiresAt.Time
+			}
+		}
+	}
+	return append(sessions, session), nil
+}
+
+func (s *gdprService) RequestErasure(ctx context.Context, actor models.Actor, userID uint) (*response.UserResponse, error) {
+	logger.Info(ctx, "GDPRService.RequestErasure start", map[string]any{"user_id": userID, "actor_id": actor.UserID})
+	user, err := s.getUser(ctx, "RequestErasure", userID)
+	if err != nil {
+		return nil, err
+	}
+	// Repeating the request keeps the original schedule.
+	if user.ErasureScheduledAt != nil {
+		return utilities.ToUserResponse(user), nil
+	}
+
+	scheduledAt := time.Now().UTC().Add(s.gracePeriod)
+	user.ErasureScheduledAt = &scheduledAt
+	if err := s.updateUser(ctx, "RequestErasure", user); err != nil {
+		return nil, err
+	}
+	if err := s.auditService.Record(ctx, actor, models.AuditActionErasureRequested, models.AuditEntityUser, userID, map[string]any{
+		"scheduled_at": scheduledAt,
+	}); err != nil {
+		return nil, err
+	}
+
+	body := fmt.Sprintf("Hello %s,\n\nWe received a request to erase your account. Your personal data will be "+
+		"permanently anonymized on %s.\n\nIf you did not request this, sign in and cancel the request before then.\n",
+		user.Name, scheduledAt.Format(time.RFC1123))
+	if err := s.mailService.Send(ctx, user.Email, "Your account is scheduled for erasure", body); err != nil {
+		logger.Warn(ctx, "RequestErasure: notification mail failed", map[string]any{"user_id": userID, "error": err.Error()})
+	}
+
+	logger.Info(ctx, "GDPRService.RequestErasure success", map[string]any{"user_id": userID, "scheduled_at": scheduledAt})
+	return utilities.ToUserResponse(user), nil
+}
+
+func (s *gdprService) CancelErasure(ctx context.Context, actor models.Actor, userID uint) (*response.UserResponse, error) {
+	logger.Info(ctx, "GDPRService.CancelErasure start", map[string]any{"user_id": userID, "actor_id": actor.UserID})
+	user, err := s.getUser(ctx, "CancelErasure", userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.ErasureScheduledAt == nil {
+		logger.Warn(ctx, "CancelErasure: no pending request", map[string]any{"user_id": userID})
+		return nil, models.ErrNotFound
+	}
+
+	user.ErasureScheduledAt = nil
+	if err := s.updateUser(ctx, "CancelErasure", user); err != nil {
+		return nil, err
+	}
+	if err := s.auditService.Record(ctx, actor, models.AuditActionErasureCancelled, models.AuditEntityUser, userID, nil); err != nil {
+		return nil, err
+	}
+
+	logger.Info(ctx, "GDPRService.CancelErasure success", map[string]any{"user_id": userID})
+	return utilities.ToUserResponse(user), nil
+}
+
+func (s *gdprService) ProcessDueErasures(ctx context.Context) (int, error) {
+	users, err := s.userRepo.GetDueErasures(ctx, time.Now())
+	if err != nil {
+		logger.Error(ctx, "ProcessDueErasures: repo query failed", map[string]any{"error": err.Error()})
+		return 0, err
+	}
+
+	erased := 0
+	for _, user := range users {
+		if err := s.erase(ctx, user); err != nil {
+			// Keep going; the user is picked up again on the next run.
+			logger.Error(ctx, "ProcessDueErasures: erase failed", map[string]any{"user_id": user.ID, "error": err.Error()})
+			continue
+		}
+		erased++
+	}
+	if erased > 0 {
+		logger.Info(ctx, "GDPRService.ProcessDueErasures success", map[string]any{"erased": erased})
+	}
+	return erased, nil
+}
+
+// erase anonymizes the personal data in a user row, removes the avatar files and settings, and
+// clears the user's Redis keys. The row itself is kept so references stay valid. Copies of the
+// name and email in outbox events and webhook deliveries are replaced as well. Audit events
+// cannot be changed and only hold keyed hashes of email addresses (see auditedUserService);
+// entries already published to the event streams are left until the streams are trimmed.
+func (s *gdprService) erase(ctx context.Context, user *models.User) error {
+	avatar := user.Avatar
+	erasedAt := time.Now().UTC()
+	user.Name = erasedUserName
+	user.Email = fmt.Sprintf("erased-%d@erased.invalid", user.ID)
+	user.PendingEmail = ""
+	user.Password = erasedPassword
+	user.Avatar = nil
+	user.ErasureScheduledAt = nil
+	user.ErasedAt = &erasedAt
+
+	// ErasedAt is only committed together with every other database step, so
+	// a failed erasure leaves the user due and is retried on the next run.
+	return s.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		// Consumers of the UserUpdated event drop their copies of the personal data.
+		if err := s.saveUser(ctx, user); err != nil {
+			return err
+		}
+		if err := s.settingsService.Delete(ctx, user.ID); err != nil {
+			return err
+		}
+		// Login events hold IP addresses and user agents.
+		if _, err := s.loginEventRepo.DeleteByUser(ctx, user.ID); err != nil {
+			return err
+		}
+		redacted := map[string]any{"name": user.Name, "email": user.Email, "pending_email": nil}
+		if _, err := s.outboxRepo.RedactPayloads(ctx, models.AggregateUser, user.ID, redacted); err != nil {
+			return err
+		}
+		// Deliveries belong to every organization the user was a member of.
+		if _, err := s.webhookRepo.RedactUserDeliveries(tenant.WithoutScope(ctx), user.ID, redacted); err != nil {
+			return err
+		}
+		if err := s.auditService.Record(ctx, models.Actor{}, models.AuditActionErasureCompleted, models.AuditEntityUser, user.ID, nil); err != nil {
+			return err
+		}
+
+		s.transactor.AfterCommit(ctx, func(ctx context.Context) {
+			deleteAvatarFiles(ctx, s.store, avatar)
+			invalidateUserCache(ctx, s.userCache, s.userListCache, s.orgRepo, "erase", user.ID)
+			if err := s.redisService.DeleteUserSession(ctx, user.ID); err != nil {
+				logger.Warn(ctx, "erase: session delete failed", map[string]any{"user_id": user.ID, "error": err.Error()})
+			}
+		})
+		return nil
+	})
+}
+
+func (s *gdprService) getUser(ctx context.Context, op string, id uint) (*models.User, error) {
+	user, err := s.userRepo.GetByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			logger.Warn(ctx, op+": not found", map[string]any{"user_id": id})
+			return nil, models.ErrUserNotFound
+		}
+		logger.Error(ctx, op+": repo get failed", map[string]any{"user_id": id, "error": err.Error()})
+		return nil, err
+	}
+	if user.ErasedAt != nil {
+		logger.Warn(ctx, op+": user already erased", map[string]any{"user_id": id})
+		return nil, models.ErrUserNotFound
+	}
+	return user, nil
+}
+
+// updateUser saves the user and refreshes the cached copy.
+func (s *gdprService) updateUser(ctx context.Context, op string, user *models.User) error {
+	if err := s.saveUser(ctx, user); err != nil {
+		if errors.Is(err, models.ErrVersionConflict) {
+			logger.Warn(ctx, op+": concurrent modification", map[string]any{"user_id": user.ID, "version": user.Version})
+			return err
+		}
+		logger.Error(ctx, op+": repo update failed", map[string]any{"user_id": user.ID, "error": err.Error()})
+		return err
+	}
+	refreshUserCache(ctx, s.userCache, s.userListCache, s.orgRepo, op, utilities.ToUserResponse(user))
+	return nil
+}
+
+// saveUser saves the user and stores a UserUpdated event in the same transaction.
+func (s *gdprService) saveUser(ctx context.Context, user *models.User) error {
+	return s.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := s.userRepo.Update(ctx, user); err != nil {
+			return err
+		}
+		return s.outboxRepo.Add(ctx, userEvent(ctx, models.EventUserUpdated, user))
+	})
+}
@@ -0,0 +1,22 @@
+package interfaces
+
+import (
+	"context"
+
+	"go-boilerplate/models"
+	"go-boilerplate/models/request"
+	"go-boilerplate/models/response"
+)
+
+type AuditService interface {
+	// Record appends an audit event for an action by actor on an entity.
+	Record(ctx context.Context, actor models.Actor, action, entityType string, entityID uint, details map[string]any) error
+	// RecordChange appends an audit event for a data change, attributed to the
+	// actor in ctx (see audit.WithActor).
+	RecordChange(ctx context.Context, action, entityType string, entityID uint, changes map[string]models.FieldChange) error
+	ListForUser(ctx context.Context, userID uint) ([]*models.AuditEvent, error)
+	// Search returns a page of events matching filter, newest first.
+	Search(ctx context.Context, page, perPage int, filter *request.AuditFilter) (*response.PaginationResponse, error)
+	// VerifyChain recomputes the hash chain and reports the first event that does not match.
+	VerifyChain(ctx context.Context) (*response.AuditVerifyResponse, error)
+}
@@ -1,9 +1,21 @@
 package interfaces
 
-import "github.com/golang-jwt/jwt/v5"
+import (
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
 
 type AuthService interface {
 	GenerateToken(userID uint) (string, error)
+	// GenerateTenantToken issues a session token bound to an organization.
+	GenerateTenantToken(userID, tenantID uint) (string, error)
 	ValidateToken(tokenString string) (*jwt.Token, error)
 	GetUserIDFromToken(token *jwt.Token) (uint, error)
+	// GetTenantIDFromToken returns the organization a token is bound to, or 0.
+	GetTenantIDFromToken(token *jwt.Token) uint
+
+	// Action tokens are short-lived, single-purpose signed tokens (e.g. email confirmation links).
+	GenerateActionToken(purpose string, data map[string]string, ttl time.Duration) (string, error)
+	ParseActionToken(purpose string, tokenString string) (map[string]string, error)
 }
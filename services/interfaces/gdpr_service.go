@@ -0,0 +1,21 @@
+package interfaces
+
+import (
+	"context"
+	"io"
+
+	"go-boilerplate/models"
+	"go-boilerplate/models/response"
+)
+
+// GDPRService implements data subject requests: data export and right to erasure.
+// Every request is recorded in the audit log.
+type GDPRService interface {
+	// ExportUserData writes a ZIP archive with JSON files for all data held about the user.
+	ExportUserData(ctx context.Context, actor models.Actor, userID uint, w io.Writer) error
+	// RequestErasure schedules the user's anonymization after the configured grace period.
+	RequestErasure(ctx context.Context, actor models.Actor, userID uint) (*response.UserResponse, error)
+	CancelErasure(ctx context.Context, actor models.Actor, userID uint) (*response.UserResponse, error)
+	// ProcessDueErasures anonymizes users whose grace period has ended.
+	ProcessDueErasures(ctx context.Context) (int, error)
+}
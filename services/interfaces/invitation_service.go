@@ -0,0 +1,23 @@
+package interfaces
+
+import (
+	"context"
+
+	"go-boilerplate/models/request"
+	"go-boilerplate/models/response"
+)
+
+// InvitationService invites people to organizations by email. Managing
+// invitations requires the owner or admin role in the organization.
+type InvitationService interface {
+	// CreateInvitation stores an invitation and emails a signed link to the address.
+	CreateInvitation(ctx context.Context, orgID, actorID uint, req *request.CreateInvitationRequest) (*response.InvitationResponse, error)
+	ListPendingInvitations(ctx context.Context, orgID, actorID uint) ([]*response.InvitationResponse, error)
+	RevokeInvitation(ctx context.Context, orgID, invitationID, actorID uint) error
+	// PreviewInvitation describes the invitation behind a token without accepting it.
+	PreviewInvitation(ctx context.Context, token string) (*response.InvitationPreviewResponse, error)
+	// AcceptInvitation links the invited address's account to the organization,
+	// creating the account first if there is none. Accepting as an existing
+	// member fails with models.ErrAlreadyMember and creates no account.
+	AcceptInvitation(ctx context.Context, req *request.AcceptInvitationRequest) (*response.AcceptInvitationResponse, error)
+}
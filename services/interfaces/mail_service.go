@@ -0,0 +1,8 @@
+package interfaces
+
+import "context"
+
+// MailService sends transactional emails.
+type MailService interface {
+	Send(ctx context.Context, to, subject, body string) error
+}
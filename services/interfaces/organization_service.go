@@ -0,0 +1,21 @@
+package interfaces
+
+import (
+	"context"
+
+	"go-boilerplate/models/request"
+	"go-boilerplate/models/response"
+)
+
+type OrganizationService interface {
+	// CreateOrganization creates an organization owned by userID.
+	CreateOrganization(ctx context.Context, userID uint, req *request.CreateOrganizationRequest) (*response.OrganizationResponse, error)
+	// ListOrganizations returns the organizations userID belongs to, with the user's role in each.
+	ListOrganizations(ctx context.Context, userID uint) ([]*response.OrganizationResponse, error)
+	// IsMember reports whether userID belongs to the organization. Results are cached briefly.
+	IsMember(ctx context.Context, orgID, userID uint) (bool, error)
+	// IssueTenantToken returns a session token bound to an organization the user belongs to.
+	IssueTenantToken(ctx context.Context, orgID, userID uint) (*response.TenantTokenResponse, error)
+	// ListMembers lists the organization's members. actorID must be a member.
+	ListMembers(ctx context.Context, orgID, actorID uint) ([]*response.MemberResponse, error)
+}
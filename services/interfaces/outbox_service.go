@@ -0,0 +1,16 @@
+package interfaces
+
+import (
+	"context"
+	"time"
+)
+
+// OutboxService relays outbox events to Redis Streams.
+type OutboxService interface {
+	// Relay publishes pending events in order until none are left and returns
+	// how many were published. An event that fails to publish stops the relay
+	// and is retried, with the events after it, on the next call.
+	Relay(ctx context.Context) (int, error)
+	// PurgePublished removes events published longer than retention ago.
+	PurgePublished(ctx context.Context, retention time.Duration) (int64, error)
+}
@@ -3,6 +3,8 @@ package interfaces
 import (
 	"context"
 	"time"
+
+	"github.com/redis/go-redis/v9"
 )
 
 // RedisService defines higher-level Redis operations used by services/handlers.
@@ -15,6 +17,63 @@ type RedisService interface {
 	Exists(ctx context.Context, keys ...string) (int64, error)
 	Expire(ctx context.Context, key string, expiration time.Duration) (bool, error)
 	Incr(ctx context.Context, key string) (int64, error)
+	// SetJSONTagged is SetJSON that also records key under each tag, so that
+	// InvalidateTags can delete it. expiration must be positive.
+	SetJSONTagged(ctx context.Context, key string, value interface{}, expiration time.Duration, tags []string) error
+	// InvalidateTags deletes every key recorded under the tags and returns how many there were.
+	InvalidateTags(ctx context.Context, tags ...string) (int64, error)
+	// SetNX sets key only if it does not exist and reports whether it was set.
+	SetNX(ctx context.Context, key string, value string, expiration time.Duration) (bool, error)
+
+	// Hashes, sets and sorted sets; see repository/interfaces.RedisRepository.
+	HSet(ctx context.Context, key string, values map[string]any) (int64, error)
+	HGet(ctx context.Context, key, field string) (string, error)
+	HGetAll(ctx context.Context, key string) (map[string]string, error)
+	HDel(ctx context.Context, key string, fields ...string) (int64, error)
+	HIncrBy(ctx context.Context, key, field string, incr int64) (int64, error)
+	SAdd(ctx context.Context, key string, members ...string) (int64, error)
+	SRem(ctx context.Context, key string, members ...string) (int64, error)
+	SMembers(ctx context.Context, key string) ([]string, error)
+	SIsMember(ctx context.Context, key, member string) (bool, error)
+	SCard(ctx context.Context, key string) (int64, error)
+	ZAdd(ctx context.Context, key string, members ...redis.Z) (int64, error)
+	ZRem(ctx context.Context, key string, members ...string) (int64, error)
+	ZIncrBy(ctx context.Context, key string, incr float64, member string) (float64, error)
+	ZScore(ctx context.Context, key, member string) (float64, error)
+	ZCard(ctx context.Context, key string) (int64, error)
+	ZRange(ctx context.Context, key string, start, stop int64) ([]redis.Z, error)
+	ZRangeByScore(ctx context.Context, key, min, max string, offset, count int64) ([]redis.Z, error)
+	ZRemRangeByScore(ctx context.Context, key, min, max string) (int64, error)
+
+	// Scan calls fn with batches of keys matching the glob pattern and
+	// returns how many keys it reported.
+	Scan(ctx context.Context, match string, fn func(keys []string) error) (int64, error)
+
+	// Pipelined and TxPipelined send the commands fn queues in one round
+	// trip, the latter inside MULTI/EXEC.
+	Pipelined(ctx context.Context, fn func(redis.Pipeliner) error) ([]redis.Cmder, error)
+	TxPipelined(ctx context.Context, fn func(redis.Pipeliner) error) ([]redis.Cmder, error)
+	// Watch runs fn with keys under WATCH and runs it again, up to attempts
+	// times in all, while a watched key changes before EXEC. It then returns
+	// redis.TxFailedErr.
+	Watch(ctx context.Context, attempts int, fn func(*redis.Tx) error, keys ...string) error
+
+	// LoadScripts preloads scripts so that RunScript's EVALSHA finds them.
+	LoadScripts(ctx context.Context, scripts ...*redis.Script) error
+	// RunScript runs script with EVALSHA, falling back to EVAL.
+	RunScript(ctx context.Context, script *redis.Script, keys []string, args ...any) *redis.Cmd
+
+	// Streams; see repository/interfaces.RedisRepository.
+	XAdd(ctx context.Context, args *redis.XAddArgs) (string, error)
+	XGroupCreateMkStream(ctx context.Context, stream, group, start string) error
+	XReadGroup(ctx context.Context, args *redis.XReadGroupArgs) ([]redis.XStream, error)
+	XAck(ctx context.Context, stream, group string, ids ...string) (int64, error)
+	XAutoClaim(ctx context.Context, args *redis.XAutoClaimArgs) ([]redis.XMessage, string, error)
+
+	Publish(ctx context.Context, channel, message string) (int64, error)
+	// Subscribe calls fn for each message on channels until ctx is cancelled.
+	// It returns an error if the subscription fails or is closed.
+	Subscribe(ctx context.Context, fn func(context.Context, *redis.Message), channels ...string) error
 
 	// Typed helpers
 	CacheUserSession(ctx context.Context, userID uint, token string, ttl time.Duration) error
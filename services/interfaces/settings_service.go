@@ -0,0 +1,15 @@
+package interfaces
+
+import (
+	"context"
+
+	"go-boilerplate/models/response"
+)
+
+type SettingsService interface {
+	Get(ctx context.Context, userID uint) (*response.SettingsResponse, error)
+	// Update applies a JSON merge patch of setting keys to values; null resets a key to its default.
+	Update(ctx context.Context, userID uint, patch map[string]any) (*response.SettingsResponse, error)
+	// Delete removes all stored settings, e.g. when the user's data is erased.
+	Delete(ctx context.Context, userID uint) error
+}
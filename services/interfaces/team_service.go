@@ -0,0 +1,18 @@
+package interfaces
+
+import (
+	"context"
+
+	"go-boilerplate/models/request"
+	"go-boilerplate/models/response"
+)
+
+// TeamService manages teams within an organization. actorID is the caller,
+// who must be a member; changes require the owner or admin role.
+type TeamService interface {
+	CreateTeam(ctx context.Context, orgID, actorID uint, req *request.CreateTeamRequest) (*response.TeamResponse, error)
+	ListTeams(ctx context.Context, orgID, actorID uint) ([]*response.TeamResponse, error)
+	// AddTeamMember adds a member of the organization to the team, or changes their team role.
+	AddTeamMember(ctx context.Context, orgID, teamID, actorID uint, req *request.AddTeamMemberRequest) (*response.MemberResponse, error)
+	ListTeamMembers(ctx context.Context, orgID, teamID, actorID uint) ([]*response.MemberResponse, error)
+}
@@ -0,0 +1,28 @@
+package interfaces
+
+import (
+	"context"
+	"io"
+
+	"go-boilerplate/models"
+)
+
+// TusCompletionHook is called once an upload has received all of its bytes.
+// path is the local file holding the data; it is removed when the upload
+// expires, so a hook that needs to keep the data must move or copy it.
+type TusCompletionHook func(ctx context.Context, upload *models.TusUpload, path string) error
+
+// TusService implements the storage side of the tus 1.0 resumable upload protocol.
+// Uploads are private to the user that created them; other users get models.ErrNotFound.
+type TusService interface {
+	Create(ctx context.Context, userID uint, length int64, metadata map[string]string) (*models.TusUpload, error)
+	Get(ctx context.Context, userID uint, id string) (*models.TusUpload, error)
+	// WriteChunk appends r at offset, which must equal the current offset.
+	// Bytes received before a read error are kept so the client can resume.
+	WriteChunk(ctx context.Context, userID uint, id string, offset int64, r io.Reader) (*models.TusUpload, error)
+	// Terminate discards an upload. Like WriteChunk it returns
+	// models.ErrLocked while a chunk is being written.
+	Terminate(ctx context.Context, userID uint, id string) error
+	// CleanupExpired removes files whose upload state has expired.
+	CleanupExpired(ctx context.Context) (int, error)
+}
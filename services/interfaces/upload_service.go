@@ -0,0 +1,19 @@
+package interfaces
+
+import (
+	"context"
+	"io"
+	"time"
+
+	"go-boilerplate/models/response"
+	"go-boilerplate/storage"
+)
+
+type UploadService interface {
+	// Upload stores r under a content-addressed key after enforcing size and MIME limits.
+	Upload(ctx context.Context, r io.Reader) (*response.UploadResponse, error)
+	// Open verifies a signed download URL and opens the object. The caller must close the reader.
+	Open(ctx context.Context, key string, expires int64, signature string) (io.ReadCloser, *storage.ObjectInfo, error)
+	// SignedURL returns an expiring download URL for key.
+	SignedURL(key string) (string, time.Time)
+}
@@ -0,0 +1,13 @@
+package interfaces
+
+import (
+	"context"
+	"io"
+
+	"go-boilerplate/models/request"
+)
+
+type UserExportService interface {
+	// ExportUsers streams every user matching the request's filter to w in the requested format.
+	ExportUsers(ctx context.Context, w io.Writer, req *request.UserExportRequest) error
+}
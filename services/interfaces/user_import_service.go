@@ -0,0 +1,16 @@
+package interfaces
+
+import (
+	"context"
+	"io"
+
+	"go-boilerplate/models/response"
+)
+
+type UserImportService interface {
+	// ImportUsers streams rows from r and imports them synchronously.
+	ImportUsers(ctx context.Context, format string, r io.Reader, dryRun bool) (*response.ImportReport, error)
+	// StartImportJob spools r and imports it in the background; poll with GetImportJob.
+	StartImportJob(ctx context.Context, format string, r io.Reader, dryRun bool) (*response.ImportJobResponse, error)
+	GetImportJob(ctx context.Context, jobID string) (*response.ImportJobResponse, error)
+}
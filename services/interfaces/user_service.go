@@ -1,16 +1,46 @@
 package interfaces
 
 import (
+	"context"
+	"io"
+	"time"
+
+	"go-boilerplate/models"
 	"go-boilerplate/models/request"
 	"go-boilerplate/models/response"
+	"go-boilerplate/storage"
 )
 
 type UserService interface {
-	CreateUser(req *request.CreateUserRequest) (*response.UserResponse, error)
-	GetUserByID(id uint) (*response.UserResponse, error)
-	GetUsers(page, perPage int) (*response.PaginationResponse, error)
-	UpdateUser(id uint, req *request.UpdateUserRequest) (*response.UserResponse, error)
-	DeleteUser(id uint) error
-	Login(req *request.LoginRequest) (*response.LoginResponse, error)
-	Logout(userID uint) error
+	CreateUser(ctx context.Context, req *request.CreateUserRequest) (*response.UserResponse, error)
+	GetUserByID(ctx context.Context, id uint) (*response.UserResponse, error)
+	// GetUserRole reads the user's role from the database rather than the
+	// cache, so that authorization never acts on a revoked role.
+	GetUserRole(ctx context.Context, id uint) (string, error)
+	GetUsers(ctx context.Context, page, perPage int, filter *request.UserFilter) (*response.PaginationResponse, error)
+	UpdateUser(ctx context.Context, id uint, versions models.VersionMatch, req *request.UpdateUserRequest) (*response.UserResponse, error)
+	DeleteUser(ctx context.Context, id uint, versions models.VersionMatch) error
+	// Login checks the credentials and records the attempt, successful or not, in the login history.
+	Login(ctx context.Context, req *request.LoginRequest, client models.ClientInfo) (*response.LoginResponse, error)
+	Logout(ctx context.Context, userID uint) error
+	ConfirmEmailChange(ctx context.Context, token string) (*response.UserResponse, error)
+
+	// Avatars
+	SetAvatar(ctx context.Context, id uint, versions models.VersionMatch, r io.Reader) (*response.UserResponse, error)
+	RemoveAvatar(ctx context.Context, id uint, versions models.VersionMatch) (*response.UserResponse, error)
+	// OpenAvatar opens an avatar thumbnail by key. The caller must close the reader.
+	OpenAvatar(ctx context.Context, key string) (io.ReadCloser, *storage.ObjectInfo, error)
+
+	// Activity
+	// GetLoginHistory returns a page of the user's login events, newest first.
+	GetLoginHistory(ctx context.Context, userID uint, page, perPage int) (*response.PaginationResponse, error)
+	// TouchLastSeen updates the user's last_seen_at, at most once per LAST_SEEN_INTERVAL.
+	TouchLastSeen(ctx context.Context, userID uint)
+	// PurgeLoginHistory removes login events older than retention.
+	PurgeLoginHistory(ctx context.Context, retention time.Duration) (int64, error)
+
+	// Trash
+	GetDeletedUsers(ctx context.Context, page, perPage int) (*response.PaginationResponse, error)
+	RestoreUser(ctx context.Context, id uint) (*response.UserResponse, error)
+	PurgeDeletedUsers(ctx context.Context, retention time.Duration) (int64, error)
 }
@@ -0,0 +1,34 @@
+package interfaces
+
+import (
+	"context"
+	"time"
+
+	"go-boilerplate/models"
+	"go-boilerplate/models/request"
+	"go-boilerplate/models/response"
+	"go-boilerplate/streams"
+)
+
+// WebhookService manages an organization's webhook subscriptions and delivers
+// events to them. actorID is the caller, who must be an owner or admin.
+type WebhookService interface {
+	CreateSubscription(ctx context.Context, orgID, actorID uint, req *request.CreateWebhookRequest) (*response.WebhookResponse, error)
+	ListSubscriptions(ctx context.Context, orgID, actorID uint) ([]*response.WebhookResponse, error)
+	GetSubscription(ctx context.Context, orgID, subID, actorID uint) (*response.WebhookResponse, error)
+	UpdateSubscription(ctx context.Context, orgID, subID, actorID uint, req *request.UpdateWebhookRequest) (*response.WebhookResponse, error)
+	DeleteSubscription(ctx context.Context, orgID, subID, actorID uint) error
+	// ListDeliveries returns the subscription's delivery log, newest first,
+	// optionally limited to one status.
+	ListDeliveries(ctx context.Context, orgID, subID, actorID uint, page, perPage int, status string) (*response.PaginationResponse, error)
+	// Redeliver queues a delivery to be sent again right away, whatever its state.
+	Redeliver(ctx context.Context, orgID, subID, deliveryID, actorID uint) (*models.WebhookDelivery, error)
+
+	// HandleEvent queues deliveries of a domain event for the matching
+	// subscriptions. It is a streams.Handler.
+	HandleEvent(ctx context.Context, msg streams.Message) error
+	// DeliverDue sends the deliveries that are due and returns how many were attempted.
+	DeliverDue(ctx context.Context) (int, error)
+	// PurgeDeliveries removes finished deliveries last updated longer than retention ago.
+	PurgeDeliveries(ctx context.Context, retention time.Duration) (int64, error)
+}
@@ -0,0 +1,306 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+
+	"go-boilerplate/config"
+	"go-boilerplate/logger"
+	"go-boilerplate/models"
+	"go-boilerplate/models/request"
+	"go-boilerplate/models/response"
+	repoInterfaces "go-boilerplate/repository/interfaces"
+	serviceInterfaces "go-boilerplate/services/interfaces"
+	"go-boilerplate/tenant"
+	"go-boilerplate/utilities"
+
+	"gorm.io/gorm"
+)
+
+// invitationPurpose scopes action tokens used in invitation links.
+const invitationPurpose = "invitation"
+
+type invitationService struct {
+	invitationRepo repoInterfaces.InvitationRepository
+	orgRepo        repoInterfaces.OrganizationRepository
+	teamRepo       repoInterfaces.TeamRepository
+	userRepo       repoInterfaces.UserRepository
+	userService    serviceInterfaces.UserService
+	transactor     repoInterfaces.Transactor
+	authService    serviceInterfaces.AuthService
+	redisService   serviceInterfaces.RedisService
+	mailService    serviceInterfaces.MailService
+	cfg            *config.Config
+}
+
+func NewInvitationService(invitationRepo repoInterfaces.InvitationRepository, orgRepo repoInterfaces.OrganizationRepository, teamRepo repoInterfaces.TeamRepository, userRepo repoInterfaces.UserRepository, userService serviceInterfaces.UserService, transactor repoInterfaces.Transactor, authService serviceInterfaces.AuthService, redisService serviceInterfaces.RedisService, mailService serviceInterfaces.MailService, cfg *config.Config) serviceInterfaces.InvitationService {
+	return &invitationService{
+		invitationRepo: invitationRepo,
+		orgRepo:        orgRepo,
+		teamRepo:       teamRepo,
+		userRepo:       userRepo,
+		userService:    userService,
+		transactor:     transactor,
+		authService:    authService,
+		redisService:   redisService,
+		mailService:    mailService,
+		cfg:            cfg,
+	}
+}
+
+func (s *invitationService) CreateInvitation(ctx context.Context, orgID, actorID uint, req *request.CreateInvitationRequest) (*response.InvitationResponse, error) {
+	logger.Info(ctx, "InvitationService.CreateInvitation start", map[string]any{"organization_id": orgID, "actor_id": actorID, "email": req.Email})
+	if _, err := authorizeMember(ctx, s.orgRepo, orgID, actorID, models.MembershipRoleOwner, models.MembershipRoleAdmin); err != nil {
+		return nil, err
+	}
+	ctx = tenant.WithID(ctx, orgID)
+
+	org, err := s.orgRepo.GetByID(ctx, orgID)
+	if err != nil {
+		logger.Error(ctx, "CreateInvitation: organization get failed", map[string]any{"organization_id": orgID, "error": err.Error()})
+		return nil, err
+	}
+
+	var team *models.Team
+	if req.TeamID != nil {
+		if team, err = s.teamRepo.GetByID(ctx, orgID, *req.TeamID); err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				logger.Warn(ctx, "CreateInvitation: team not found", map[string]any{"organization_id": orgID, "team_id": *req.TeamID})
+				return nil, models.ErrNotFound
+			}
+			logger.Error(ctx, "CreateInvitation: team get failed", map[string]any{"team_id": *req.TeamID, "error": err.Error()})
+			return nil, err
+		}
+	}
+
+	// Within the tenant, only members are found.
+	if _, err := s.userRepo.GetByEmail(ctx, req.Email); err == nil {
+		logger.Warn(ctx, "CreateInvitation: already a member", map[string]any{"organization_id": orgID, "email": req.Email})
+		return nil, models.ErrAlreadyMember
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		logger.Error(ctx, "CreateInvitation: user get failed", map[string]any{"email": req.Email, "error": err.Error()})
+		return nil, err
+	}
+
+	role := req.Role
+	if role == "" {
+		role = models.MembershipRoleMember
+	}
+	invitation := &models.Invitation{
+		OrganizationID: orgID,
+		Organization:   org,
+		TeamID:         req.TeamID,
+		Team:           team,
+		Email:          req.Email,
+		Role:           role,
+		InvitedByID:    &actorID,
+		ExpiresAt:      time.Now().Add(s.cfg.JWT.InvitationTTL),
+	}
+	if err := s.invitationRepo.Create(ctx, invitation); err != nil {
+		logger.Error(ctx, "CreateInvitation: repo create failed", map[string]any{"organization_id": orgID, "error": err.Error()})
+		return nil, err
+	}
+
+	if err := s.sendInvitationMail(ctx, invitation); err != nil {
+		logger.Warn(ctx, "CreateInvitation: invitation mail failed", map[string]any{"invitation_id": invitation.ID, "error": err.Error()})
+	}
+
+	logger.Info(ctx, "InvitationService.CreateInvitation success", map[string]any{"organization_id": orgID, "invitation_id": invitation.ID})
+	return toInvitationResponse(invitation, time.Now()), nil
+}
+
+func (s *invitationService) ListPendingInvitations(ctx context.Context, orgID, actorID uint) ([]*response.InvitationResponse, error) {
+	if _, err := authorizeMember(ctx, s.orgRepo, orgID, actorID, models.MembershipRoleOwner, models.MembershipRoleAdmin); err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	invitations, err := s.invitationRepo.ListPending(tenant.WithID(ctx, orgID), orgID, now)
+	if err != nil {
+		logger.Error(ctx, "InvitationService.ListPendingInvitations failed", map[string]any{"organization_id": orgID, "error": err.Error()})
+		return nil, err
+	}
+	res := make([]*response.InvitationResponse, len(invitations))
+	for i, invitation := range invitations {
+		res[i] = toInvitationResponse(invitation, now)
+	}
+	return res, nil
+}
+
+func (s *invitationService) RevokeInvitation(ctx context.Context, orgID, invitationID, actorID uint) error {
+	logger.Info(ctx, "InvitationService.RevokeInvitation start", map[string]any{"organization_id": orgID, "invitation_id": invitationID, "actor_id": actorID})
+	if _, err := authorizeMember(ctx, s.orgRepo, orgID, actorID, models.MembershipRoleOwner, models.MembershipRoleAdmin); err != nil {
+		return err
+	}
+	if err := s.invitationRepo.Revoke(tenant.WithID(ctx, orgID), orgID, invitationID, time.Now()); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			logger.Warn(ctx, "RevokeInvitation: no pending invitation", map[string]any{"invitation_id": invitationID})
+			return models.ErrNotFound
+		}
+		logger.Error(ctx, "RevokeInvitation: repo revoke failed", map[string]any{"invitation_id": invitationID, "error": err.Error()})
+		return err
+	}
+
+	logger.Info(ctx, "InvitationService.RevokeInvitation success", map[string]any{"invitation_id": invitationID})
+	return nil
+}
+
+func (s *invitationService) PreviewInvitation(ctx context.Context, token string) (*response.InvitationPreviewResponse, error) {
+	// The signed link is the authorization; the invitee is not a member yet.
+	ctx = tenant.WithoutScope(ctx)
+	invitation, err := s.invitationFromToken(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = s.userRepo.GetByEmail(ctx, invitation.Email)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		logger.Error(ctx, "PreviewInvitation: user get failed", map[string]any{"invitation_id": invitation.ID, "error": err.Error()})
+		return nil, err
+	}
+	return &response.InvitationPreviewResponse{
+		InvitationResponse: *toInvitationResponse(invitation, time.Now()),
+		AccountExists:      err == nil,
+	}, nil
+}
+
+func (s *invitationService) AcceptInvitation(ctx context.Context, req *request.AcceptInvitationRequest) (*response.AcceptInvitationResponse, error) {
+	logger.Info(ctx, "InvitationService.AcceptInvitation start", nil)
+	// The signed link is the authorization; the invitee is not a member yet.
+	ctx = tenant.WithoutScope(ctx)
+	invitation, err := s.invitationFromToken(ctx, req.Token)
+	if err != nil {
+		return nil, err
+	}
+
+	// Holding the link proves control of the address, so an existing account
+	// with that address is linked without signing in. A new account is only
+	// kept if the invitation can still be claimed.
+	var userResponse *response.UserResponse
+	created := false
+	err = s.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		user, err := s.userRepo.GetByEmail(ctx, invitation.Email)
+		switch {
+		case err == nil:
+			userResponse = utilities.ToUserResponse(user)
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			if req.Name == "" || req.Password == "" {
+				logger.Warn(ctx, "AcceptInvitation: account details missing", map[string]any{"invitation_id": invitation.ID})
+				return models.ErrAccountDetailsRequired
+			}
+			createReq := &request.CreateUserRequest{Name: req.Name, Email: invitation.Email, Password: req.Password}
+			if userResponse, err = s.userService.CreateUser(ctx, createReq); err != nil {
+				return err
+			}
+			created = true
+		default:
+			logger.Error(ctx, "AcceptInvitation: user get failed", map[string]any{"invitation_id": invitation.ID, "error": err.Error()})
+			return err
+		}
+
+		if err := s.invitationRepo.Accept(ctx, invitation, userResponse.ID, time.Now()); err != nil {
+			switch {
+			case errors.Is(err, gorm.ErrRecordNotFound):
+				logger.Warn(ctx, "AcceptInvitation: no longer pending", map[string]any{"invitation_id": invitation.ID})
+				return models.ErrInvalidToken
+			case errors.Is(err, models.ErrAlreadyMember):
+				logger.Warn(ctx, "AcceptInvitation: already a member", map[string]any{"invitation_id": invitation.ID, "user_id": userResponse.ID})
+				return err
+			}
+			logger.Error(ctx, "AcceptInvitation: repo accept failed", map[string]any{"invitation_id": invitation.ID, "error": err.Error()})
+			return err
+		}
+
+		// IsMember may have cached that the user was not a member.
+		s.transactor.AfterCommit(ctx, func(ctx context.Context) {
+			if err := s.redisService.Delete(ctx, utilities.MembershipCacheKey(invitation.OrganizationID, userResponse.ID)); err != nil {
+				logger.Warn(ctx, "AcceptInvitation: membership cache delete failed", map[string]any{"user_id": userResponse.ID, "error": err.Error()})
+			}
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	logger.Info(ctx, "InvitationService.AcceptInvitation success", map[string]any{"invitation_id": invitation.ID, "user_id": userResponse.ID, "account_created": created})
+	return &response.AcceptInvitationResponse{
+		Organization:   toOrganizationResponse(invitation.Organization, invitation.Role),
+		User:           userResponse,
+		AccountCreated: created,
+	}, nil
+}
+
+// invitationFromToken resolves a signed invitation link to its invitation and
+// returns models.ErrInvalidToken unless the invitation is still pending.
+func (s *invitationService) invitationFromToken(ctx context.Context, token string) (*models.Invitation, error) {
+	data, err := s.authService.ParseActionToken(invitationPurpose, token)
+	if err != nil {
+		return nil, models.ErrInvalidToken
+	}
+	id, err := strconv.ParseUint(data["invitation_id"], 10, 32)
+	if err != nil {
+		logger.Warn(ctx, "invitationFromToken: malformed token data", nil)
+		return nil, models.ErrInvalidToken
+	}
+
+	invitation, err := s.invitationRepo.GetByID(ctx, uint(id))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			logger.Warn(ctx, "invitationFromToken: not found", map[string]any{"invitation_id": id})
+			return nil, models.ErrInvalidToken
+		}
+		logger.Error(ctx, "invitationFromToken: repo get failed", map[string]any{"invitation_id": id, "error": err.Error()})
+		return nil, err
+	}
+	// Deleted organizations are not preloaded.
+	if invitation.Email != data["email"] || invitation.Organization == nil || invitation.Status(time.Now()) != models.InvitationStatusPending {
+		logger.Warn(ctx, "invitationFromToken: not pending", map[string]any{"invitation_id": id})
+		return nil, models.ErrInvalidToken
+	}
+	return invitation, nil
+}
+
+func (s *invitationService) sendInvitationMail(ctx context.Context, invitation *models.Invitation) error {
+	ttl := time.Until(invitation.ExpiresAt)
+	token, err := s.authService.GenerateActionToken(invitationPurpose, map[string]string{
+		"invitation_id": strconv.FormatUint(uint64(invitation.ID), 10),
+		"email":         invitation.Email,
+	}, ttl)
+	if err != nil {
+		return err
+	}
+
+	target := invitation.Organization.Name
+	if invitation.Team != nil {
+		target = fmt.Sprintf("the %s team at %s", invitation.Team.Name, invitation.Organization.Name)
+	}
+	link := fmt.Sprintf("%s/api/v1/invitations?token=%s", s.cfg.BaseURL, url.QueryEscape(token))
+	body := fmt.Sprintf("Hi,\n\nYou have been invited to join %s. Open the link below to accept the invitation. It expires in %s.\n\n%s\n\nIf you were not expecting this invitation, ignore this email.\n",
+		target, s.cfg.JWT.InvitationTTL, link)
+	return s.mailService.Send(ctx, invitation.Email, "You have been invited to join "+invitation.Organization.Name, body)
+}
+
+func toInvitationResponse(invitation *models.Invitation, now time.Time) *response.InvitationResponse {
+	res := &response.InvitationResponse{
+		ID:             invitation.ID,
+		OrganizationID: invitation.OrganizationID,
+		TeamID:         invitation.TeamID,
+		Email:          invitation.Email,
+		Role:           invitation.Role,
+		Status:         invitation.Status(now),
+		ExpiresAt:      invitation.ExpiresAt,
+		AcceptedAt:     invitation.AcceptedAt,
+		CreatedAt:      invitation.CreatedAt,
+	}
+	if invitation.Organization != nil {
+		res.OrganizationName = invitation.Organization.Name
+	}
+	if invitation.Team != nil {
+		res.TeamName = invitation.Team.Name
+	}
+	return res
+}
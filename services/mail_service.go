@@ -0,0 +1,52 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"net/smtp"
+	"strings"
+
+	"go-boilerplate/config"
+	"go-boilerplate/logger"
+	"go-boilerplate/services/interfaces"
+)
+
+// mailService delivers plain-text emails over SMTP. When no SMTP host is
+// configured it only logs the message, which is convenient for local development.
+type mailService struct {
+	cfg config.MailConfig
+}
+
+func NewMailService(cfg *config.Config) interfaces.MailService {
+	return &mailService{cfg: cfg.Mail}
+}
+
+func (s *mailService) Send(ctx context.Context, to, subject, body string) error {
+	if s.cfg.Host == "" {
+		logger.Info(ctx, "MailService.Send (no SMTP host, logging only)", map[string]any{"to": to, "subject": subject, "body": body})
+		return nil
+	}
+
+	msg := strings.Join([]string{
+		"From: " + s.cfg.From,
+		"To: " + to,
+		"Subject: " + subject,
+		"MIME-Version: 1.0",
+		"Content-Type: text/plain; charset=UTF-8",
+		"",
+		body,
+	}, "\r\n")
+
+	var auth smtp.Auth
+	if s.cfg.Username != "" {
+		auth = smtp.PlainAuth("", s.cfg.Username, s.cfg.Password, s.cfg.Host)
+	}
+
+	addr := fmt.Sprintf("%s:%s", s.cfg.Host, s.cfg.Port)
+	if err := smtp.SendMail(addr, auth, s.cfg.From, []string{to}, []byte(msg)); err != nil {
+		logger.Error(ctx, "MailService.Send failed", map[string]any{"to": to, "subject": subject, "error": err.Error()})
+		return err
+	}
+	logger.Info(ctx, "MailService.Send success", map[string]any{"to": to, "subject": subject})
+	return nil
+}
@@ -0,0 +1,178 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"time"
+
+	"go-boilerplate/logger"
+	"go-boilerplate/models"
+	"go-boilerplate/models/request"
+	"go-boilerplate/models/response"
+	repoInterfaces "go-boilerplate/repository/interfaces"
+	serviceInterfaces "go-boilerplate/services/interfaces"
+	"go-boilerplate/utilities"
+
+	"gorm.io/gorm"
+)
+
+// membershipCacheTTL bounds how long a removed member can keep using a tenant.
+const membershipCacheTTL = 5 * time.Minute
+
+type organizationService struct {
+	orgRepo      repoInterfaces.OrganizationRepository
+	authService  serviceInterfaces.AuthService
+	redisService serviceInterfaces.RedisService
+}
+
+func NewOrganizationService(orgRepo repoInterfaces.OrganizationRepository, authService serviceInterfaces.AuthService, redisService serviceInterfaces.RedisService) serviceInterfaces.OrganizationService {
+	return &organizationService{
+		orgRepo:      orgRepo,
+		authService:  authService,
+		redisService: redisService,
+	}
+}
+
+func (s *organizationService) CreateOrganization(ctx context.Context, userID uint, req *request.CreateOrganizationRequest) (*response.OrganizationResponse, error) {
+	logger.Info(ctx, "OrganizationService.CreateOrganization start", map[string]any{"user_id": userID, "slug": req.Slug})
+	org := &models.Organization{Name: req.Name, Slug: req.Slug}
+	if err := s.orgRepo.Create(ctx, org, userID); err != nil {
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			logger.Warn(ctx, "CreateOrganization: slug already taken", map[string]any{"slug": req.Slug})
+			return nil, models.ErrSlugTaken
+		}
+		logger.Error(ctx, "CreateOrganization: repo create failed", map[string]any{"slug": req.Slug, "error": err.Error()})
+		return nil, err
+	}
+
+	logger.Info(ctx, "OrganizationService.CreateOrganization success", map[string]any{"organization_id": org.ID, "user_id": userID})
+	return toOrganizationResponse(org, models.MembershipRoleOwner), nil
+}
+
+func (s *organizationService) ListOrganizations(ctx context.Context, userID uint) ([]*response.OrganizationResponse, error) {
+	memberships, err := s.orgRepo.ListForUser(ctx, userID)
+	if err != nil {
+		logger.Error(ctx, "OrganizationService.ListOrganizations failed", map[string]any{"user_id": userID, "error": err.Error()})
+		return nil, err
+	}
+	orgs := make([]*response.OrganizationResponse, 0, len(memberships))
+	for _, membership := range memberships {
+		// Memberships of deleted organizations come back without one.
+		if membership.Organization == nil || membership.Organization.ID == 0 {
+			continue
+		}
+		orgs = append(orgs, toOrganizationResponse(membership.Organization, membership.Role))
+	}
+	return orgs, nil
+}
+
+func (s *organizationService) IsMember(ctx context.Context, orgID, userID uint) (bool, error) {
+	key := utilities.MembershipCacheKey(orgID, userID)
+	var member bool
+	if err := s.redisService.GetJSON(ctx, key, &member); err == nil {
+		return member, nil
+	}
+
+	_, err := s.orgRepo.GetMembership(ctx, orgID, userID)
+	switch {
+	case err == nil:
+		member = true
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		member = false
+	default:
+		logger.Error(ctx, "IsMember: repo get failed", map[string]any{"organization_id": orgID, "user_id": userID, "error": err.Error()})
+		return false, err
+	}
+
+	if err := s.redisService.SetJSON(ctx, key, member, membershipCacheTTL); err != nil {
+		logger.Warn(ctx, "IsMember: cache set failed", map[string]any{"organization_id": orgID, "user_id": userID, "error": err.Error()})
+	}
+	return member, nil
+}
+
+func (s *organizationService) IssueTenantToken(ctx context.Context, orgID, userID uint) (*response.TenantTokenResponse, error) {
+	logger.Info(ctx, "OrganizationService.IssueTenantToken start", map[string]any{"organization_id": orgID, "user_id": userID})
+	member, err := s.IsMember(ctx, orgID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !member {
+		logger.Warn(ctx, "IssueTenantToken: not a member", map[string]any{"organization_id": orgID, "user_id": userID})
+		return nil, models.ErrNotFound
+	}
+
+	token, err := s.authService.GenerateTenantToken(userID, orgID)
+	if err != nil {
+		logger.Error(ctx, "IssueTenantToken: token generation failed", map[string]any{"user_id": userID, "error": err.Error()})
+		return nil, err
+	}
+	if err := s.redisService.CacheUserSession(ctx, userID, token, 24*time.Hour); err != nil {
+		logger.Error(ctx, "IssueTenantToken: cache session failed", map[string]any{"user_id": userID, "error": err.Error()})
+		return nil, err
+	}
+
+	logger.Info(ctx, "OrganizationService.IssueTenantToken success", map[string]any{"organization_id": orgID, "user_id": userID})
+	return &response.TenantTokenResponse{Token: token, OrganizationID: orgID}, nil
+}
+
+func (s *organizationService) ListMembers(ctx context.Context, orgID, actorID uint) ([]*response.MemberResponse, error) {
+	if _, err := authorizeMember(ctx, s.orgRepo, orgID, actorID); err != nil {
+		return nil, err
+	}
+	memberships, err := s.orgRepo.ListMembers(ctx, orgID)
+	if err != nil {
+		logger.Error(ctx, "OrganizationService.ListMembers failed", map[string]any{"organization_id": orgID, "error": err.Error()})
+		return nil, err
+	}
+	members := make([]*response.MemberResponse, 0, len(memberships))
+	for _, membership := range memberships {
+		// Memberships of deleted users come back without one.
+		if membership.User == nil || membership.User.ID == 0 {
+			continue
+		}
+		members = append(members, toMemberResponse(membership.User, membership.Role, membership.CreatedAt))
+	}
+	return members, nil
+}
+
+// authorizeMember returns userID's membership in the organization. It returns
+// models.ErrNotFound if the user is not a member, so that organizations are not
+// revealed to outsiders, and models.ErrForbidden if roles are given and the
+// member has none of them.
+func authorizeMember(ctx context.Context, orgRepo repoInterfaces.OrganizationRepository, orgID, userID uint, roles ...string) (*models.Membership, error) {
+	membership, err := orgRepo.GetMembership(ctx, orgID, userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			logger.Warn(ctx, "authorizeMember: not a member", map[string]any{"organization_id": orgID, "user_id": userID})
+			return nil, models.ErrNotFound
+		}
+		logger.Error(ctx, "authorizeMember: repo get failed", map[string]any{"organization_id": orgID, "user_id": userID, "error": err.Error()})
+		return nil, err
+	}
+	if len(roles) > 0 && !slices.Contains(roles, membership.Role) {
+		logger.Warn(ctx, "authorizeMember: role not allowed", map[string]any{"organization_id": orgID, "user_id": userID, "role": membership.Role})
+		return nil, models.ErrForbidden
+	}
+	return membership, nil
+}
+
+func toMemberResponse(user *models.User, role string, joinedAt time.Time) *response.MemberResponse {
+	return &response.MemberResponse{
+		UserID:   user.ID,
+		Name:     user.Name,
+		Email:    user.Email,
+		Role:     role,
+		JoinedAt: joinedAt,
+	}
+}
+
+func toOrganizationResponse(org *models.Organization, role string) *response.OrganizationResponse {
+	return &response.OrganizationResponse{
+		ID:        org.ID,
+		Name:      org.Name,
+		Slug:      org.Slug,
+		Role:      role,
+		CreatedAt: org.CreatedAt,
+	}
+}
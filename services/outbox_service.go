@@ -0,0 +1,97 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"go-boilerplate/config"
+	"go-boilerplate/logger"
+	"go-boilerplate/models"
+	repoInterfaces "go-boilerplate/repository/interfaces"
+	serviceInterfaces "go-boilerplate/services/interfaces"
+	"go-boilerplate/streams"
+)
+
+type outboxService struct {
+	outboxRepo repoInterfaces.OutboxRepository
+	publisher  *streams.Publisher
+	cfg        config.OutboxConfig
+}
+
+func NewOutboxService(outboxRepo repoInterfaces.OutboxRepository, publisher *streams.Publisher, cfg *config.Config) serviceInterfaces.OutboxService {
+	outboxCfg := cfg.Outbox
+	if outboxCfg.BatchSize < 1 {
+		outboxCfg.BatchSize = 100
+	}
+	return &outboxService{
+		outboxRepo: outboxRepo,
+		publisher:  publisher,
+		cfg:        outboxCfg,
+	}
+}
+
+// Relay publishes events while the transaction that selected them is open and
+// marks them only after Redis accepted them. If the commit fails, the events
+// are published again on the next run, so consumers see each event at least once.
+func (s *outboxService) Relay(ctx context.Context) (int, error) {
+	total := 0
+	for {
+		published, err := s.outboxRepo.PublishPending(ctx, s.cfg.BatchSize, func(events []*models.OutboxEvent) (int, error) {
+			for i, event := range events {
+				if err := s.publish(ctx, event); err != nil {
+					logger.Warn(ctx, "Relay: publish failed", map[string]any{"event_id": event.ID, "event_type": event.EventType, "error": err.Error()})
+					return i, err
+				}
+			}
+			return len(events), nil
+		})
+		total += published
+		if err != nil {
+			logger.Error(ctx, "OutboxService.Relay failed", map[string]any{"published": total, "error": err.Error()})
+			return total, err
+		}
+		if published < s.cfg.BatchSize {
+			break
+		}
+	}
+	if total > 0 {
+		logger.Info(ctx, "OutboxService.Relay success", map[string]any{"published": total})
+	}
+	return total, nil
+}
+
+func (s *outboxService) publish(ctx context.Context, event *models.OutboxEvent) error {
+	payload, err := json.Marshal(event.Payload)
+	if err != nil {
+		return err
+	}
+	msg := &streams.Message{
+		EventID:       streams.FormatID(event.ID),
+		Type:          event.EventType,
+		AggregateType: event.AggregateType,
+		AggregateID:   streams.FormatID(event.AggregateID),
+		Payload:       payload,
+		TraceID:       event.TraceID,
+		OccurredAt:    event.CreatedAt,
+	}
+	if event.TenantID != nil {
+		msg.TenantID = streams.FormatID(*event.TenantID)
+	}
+	_, err = s.publisher.Publish(ctx, s.cfg.StreamPrefix+event.AggregateType, msg)
+	return err
+}
+
+func (s *outboxService) PurgePublished(ctx context.Context, retention time.Duration) (int64, error) {
+	cutoff := time.Now().Add(-retention)
+	logger.Debug(ctx, "OutboxService.PurgePublished start", map[string]any{"cutoff": cutoff})
+	purged, err := s.outboxRepo.DeletePublished(ctx, cutoff)
+	if err != nil {
+		logger.Error(ctx, "PurgePublished: repo delete failed", map[string]any{"cutoff": cutoff, "error": err.Error()})
+		return 0, err
+	}
+	if purged > 0 {
+		logger.Info(ctx, "OutboxService.PurgePublished success", map[string]any{"purged": purged, "cutoff": cutoff})
+	}
+	return purged, nil
+}
@@ -3,21 +3,29 @@ package services
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"time"
 
+	"go-boilerplate/config"
 	"go-boilerplate/logger"
+	"go-boilerplate/models"
 	repoif "go-boilerplate/repository/interfaces"
 	serviceif "go-boilerplate/services/interfaces"
+	"go-boilerplate/utilities"
+
+	"github.com/redis/go-redis/v9"
 )
 
 // redisService implements service-level Redis operations using a RedisRepository.
 type redisService struct {
 	repo repoif.RedisRepository
+	// sessionPolicy decides what session helpers do when Redis fails.
+	sessionPolicy string
 }
 
-func NewRedisService(repo repoif.RedisRepository) serviceif.RedisService {
-	return &redisService{repo: repo}
+func NewRedisService(repo repoif.RedisRepository, cfg *config.Config) serviceif.RedisService {
+	return &redisService{repo: repo, sessionPolicy: cfg.Redis.SessionPolicy}
 }
 
 func (s *redisService) SetJSON(ctx context.Context, key string, value interface{}, expiration time.Duration) error {
@@ -34,6 +42,30 @@ func (s *redisService) SetJSON(ctx context.Context, key string, value interface{
 	return nil
 }
 
+func (s *redisService) SetJSONTagged(ctx context.Context, key string, value interface{}, expiration time.Duration, tags []string) error {
+	b, err := json.Marshal(value)
+	if err != nil {
+		logger.Error(ctx, "RedisService.SetJSONTagged marshal failed", map[string]any{"key": key, "error": err.Error()})
+		return err
+	}
+	if err := s.repo.SetTagged(ctx, key, string(b), expiration, tags); err != nil {
+		logger.Error(ctx, "RedisService.SetJSONTagged set failed", map[string]any{"key": key, "tags": tags, "error": err.Error()})
+		return err
+	}
+	logger.Debug(ctx, "RedisService.SetJSONTagged success", map[string]any{"key": key, "tags": tags})
+	return nil
+}
+
+func (s *redisService) InvalidateTags(ctx context.Context, tags ...string) (int64, error) {
+	n, err := s.repo.InvalidateTags(ctx, tags...)
+	if err != nil {
+		logger.Warn(ctx, "RedisService.InvalidateTags failed", map[string]any{"tags": tags, "error": err.Error()})
+		return 0, err
+	}
+	logger.Debug(ctx, "RedisService.InvalidateTags success", map[string]any{"tags": tags, "deleted": n})
+	return n, nil
+}
+
 func (s *redisService) GetJSON(ctx context.Context, key string, dest interface{}) error {
 	val, err := s.repo.Get(ctx, key)
 	if err != nil {
@@ -87,19 +119,384 @@ func (s *redisService) Incr(ctx context.Context, key string) (int64, error) {
 	return v, nil
 }
 
+func (s *redisService) SetNX(ctx context.Context, key string, value string, expiration time.Duration) (bool, error) {
+	ok, err := s.repo.SetNX(ctx, key, value, expiration)
+	if err != nil {
+		logger.Warn(ctx, "RedisService.SetNX failed", map[string]any{"key": key, "error": err.Error()})
+		return false, err
+	}
+	logger.Debug(ctx, "RedisService.SetNX success", map[string]any{"key": key, "set": ok})
+	return ok, nil
+}
+
+func (s *redisService) HSet(ctx context.Context, key string, values map[string]any) (int64, error) {
+	n, err := s.repo.HSet(ctx, key, values)
+	if err != nil {
+		logger.Warn(ctx, "RedisService.HSet failed", map[string]any{"key": key, "error": err.Error()})
+		return 0, err
+	}
+	logger.Debug(ctx, "RedisService.HSet success", map[string]any{"key": key, "fields": len(values), "added": n})
+	return n, nil
+}
+
+func (s *redisService) HGet(ctx context.Context, key, field string) (string, error) {
+	val, err := s.repo.HGet(ctx, key, field)
+	if err != nil {
+		logMiss(ctx, "RedisService.HGet", err, map[string]any{"key": key, "field": field})
+		return "", err
+	}
+	logger.Debug(ctx, "RedisService.HGet success", map[string]any{"key": key, "field": field})
+	return val, nil
+}
+
+func (s *redisService) HGetAll(ctx context.Context, key string) (map[string]string, error) {
+	fields, err := s.repo.HGetAll(ctx, key)
+	if err != nil {
+		logger.Warn(ctx, "RedisService.HGetAll failed", map[string]any{"key": key, "error": err.Error()})
+		return nil, err
+	}
+	logger.Debug(ctx, "RedisService.HGetAll success", map[string]any{"key": key, "fields": len(fields)})
+	return fields, nil
+}
+
+func (s *redisService) HDel(ctx context.Context, key string, fields ...string) (int64, error) {
+	n, err := s.repo.HDel(ctx, key, fields...)
+	if err != nil {
+		logger.Warn(ctx, "RedisService.HDel failed", map[string]any{"key": key, "fields": fields, "error": err.Error()})
+		return 0, err
+	}
+	logger.Debug(ctx, "RedisService.HDel success", map[string]any{"key": key, "fields": fields, "deleted": n})
+	return n, nil
+}
+
+func (s *redisService) HIncrBy(ctx context.Context, key, field string, incr int64) (int64, error) {
+	v, err := s.repo.HIncrBy(ctx, key, field, incr)
+	if err != nil {
+		logger.Warn(ctx, "RedisService.HIncrBy failed", map[string]any{"key": key, "field": field, "error": err.Error()})
+		return 0, err
+	}
+	logger.Debug(ctx, "RedisService.HIncrBy success", map[string]any{"key": key, "field": field, "value": v})
+	return v, nil
+}
+
+func (s *redisService) SAdd(ctx context.Context, key string, members ...string) (int64, error) {
+	n, err := s.repo.SAdd(ctx, key, members...)
+	if err != nil {
+		logger.Warn(ctx, "RedisService.SAdd failed", map[string]any{"key": key, "error": err.Error()})
+		return 0, err
+	}
+	logger.Debug(ctx, "RedisService.SAdd success", map[string]any{"key": key, "added": n})
+	return n, nil
+}
+
+func (s *redisService) SRem(ctx context.Context, key string, members ...string) (int64, error) {
+	n, err := s.repo.SRem(ctx, key, members...)
+	if err != nil {
+		logger.Warn(ctx, "RedisService.SRem failed", map[string]any{"key": key, "error": err.Error()})
+		return 0, err
+	}
+	logger.Debug(ctx, "RedisService.SRem success", map[string]any{"key": key, "removed": n})
+	return n, nil
+}
+
+func (s *redisService) SMembers(ctx context.Context, key string) ([]string, error) {
+	members, err := s.repo.SMembers(ctx, key)
+	if err != nil {
+		logger.Warn(ctx, "RedisService.SMembers failed", map[string]any{"key": key, "error": err.Error()})
+		return nil, err
+	}
+	logger.Debug(ctx, "RedisService.SMembers success", map[string]any{"key": key, "count": len(members)})
+	return members, nil
+}
+
+func (s *redisService) SIsMember(ctx context.Context, key, member string) (bool, error) {
+	ok, err := s.repo.SIsMember(ctx, key, member)
+	if err != nil {
+		logger.Warn(ctx, "RedisService.SIsMember failed", map[string]any{"key": key, "error": err.Error()})
+		return false, err
+	}
+	logger.Debug(ctx, "RedisService.SIsMember success", map[string]any{"key": key, "member": ok})
+	return ok, nil
+}
+
+func (s *redisService) SCard(ctx context.Context, key string) (int64, error) {
+	n, err := s.repo.SCard(ctx, key)
+	if err != nil {
+		logger.Warn(ctx, "RedisService.SCard failed", map[string]any{"key": key, "error": err.Error()})
+		return 0, err
+	}
+	logger.Debug(ctx, "RedisService.SCard success", map[string]any{"key": key, "count": n})
+	return n, nil
+}
+
+func (s *redisService) ZAdd(ctx context.Context, key string, members ...redis.Z) (int64, error) {
+	n, err := s.repo.ZAdd(ctx, key, members...)
+	if err != nil {
+		logger.Warn(ctx, "RedisService.ZAdd failed", map[string]any{"key": key, "error": err.Error()})
+		return 0, err
+	}
+	logger.Debug(ctx, "RedisService.ZAdd success", map[string]any{"key": key, "added": n})
+	return n, nil
+}
+
+func (s *redisService) ZRem(ctx context.Context, key string, members ...string) (int64, error) {
+	n, err := s.repo.ZRem(ctx, key, members...)
+	if err != nil {
+		logger.Warn(ctx, "RedisService.ZRem failed", map[string]any{"key": key, "error": err.Error()})
+		return 0, err
+	}
+	logger.Debug(ctx, "RedisService.ZRem success", map[string]any{"key": key, "removed": n})
+	return n, nil
+}
+
+func (s *redisService) ZIncrBy(ctx context.Context, key string, incr float64, member string) (float64, error) {
+	score, err := s.repo.ZIncrBy(ctx, key, incr, member)
+	if err != nil {
+		logger.Warn(ctx, "RedisService.ZIncrBy failed", map[string]any{"key": key, "member": member, "error": err.Error()})
+		return 0, err
+	}
+	logger.Debug(ctx, "RedisService.ZIncrBy success", map[string]any{"key": key, "member": member, "score": score})
+	return score, nil
+}
+
+func (s *redisService) ZScore(ctx context.Context, key, member string) (float64, error) {
+	score, err := s.repo.ZScore(ctx, key, member)
+	if err != nil {
+		logMiss(ctx, "RedisService.ZScore", err, map[string]any{"key": key, "member": member})
+		return 0, err
+	}
+	logger.Debug(ctx, "RedisService.ZScore success", map[string]any{"key": key, "member": member, "score": score})
+	return score, nil
+}
+
+func (s *redisService) ZCard(ctx context.Context, key string) (int64, error) {
+	n, err := s.repo.ZCard(ctx, key)
+	if err != nil {
+		logger.Warn(ctx, "RedisService.ZCard failed", map[string]any{"key": key, "error": err.Error()})
+		return 0, err
+	}
+	logger.Debug(ctx, "RedisService.ZCard success", map[string]any{"key": key, "count": n})
+	return n, nil
+}
+
+func (s *redisService) ZRange(ctx context.Context, key string, start, stop int64) ([]redis.Z, error) {
+	members, err := s.repo.ZRange(ctx, key, start, stop)
+	if err != nil {
+		logger.Warn(ctx, "RedisService.ZRange failed", map[string]any{"key": key, "error": err.Error()})
+		return nil, err
+	}
+	logger.Debug(ctx, "RedisService.ZRange success", map[string]any{"key": key, "count": len(members)})
+	return members, nil
+}
+
+func (s *redisService) ZRangeByScore(ctx context.Context, key, min, max string, offset, count int64) ([]redis.Z, error) {
+	members, err := s.repo.ZRangeByScore(ctx, key, min, max, offset, count)
+	if err != nil {
+		logger.Warn(ctx, "RedisService.ZRangeByScore failed", map[string]any{"key": key, "min": min, "max": max, "error": err.Error()})
+		return nil, err
+	}
+	logger.Debug(ctx, "RedisService.ZRangeByScore success", map[string]any{"key": key, "min": min, "max": max, "count": len(members)})
+	return members, nil
+}
+
+func (s *redisService) ZRemRangeByScore(ctx context.Context, key, min, max string) (int64, error) {
+	n, err := s.repo.ZRemRangeByScore(ctx, key, min, max)
+	if err != nil {
+		logger.Warn(ctx, "RedisService.ZRemRangeByScore failed", map[string]any{"key": key, "min": min, "max": max, "error": err.Error()})
+		return 0, err
+	}
+	logger.Debug(ctx, "RedisService.ZRemRangeByScore success", map[string]any{"key": key, "min": min, "max": max, "removed": n})
+	return n, nil
+}
+
+// scanCount is the COUNT hint sent with each SCAN.
+const scanCount = 500
+
+func (s *redisService) Scan(ctx context.Context, match string, fn func(keys []string) error) (int64, error) {
+	var seen int64
+	err := s.repo.Scan(ctx, match, scanCount, func(keys []string) error {
+		seen += int64(len(keys))
+		return fn(keys)
+	})
+	if err != nil {
+		logger.Warn(ctx, "RedisService.Scan failed", map[string]any{"match": match, "keys": seen, "error": err.Error()})
+		return seen, err
+	}
+	logger.Debug(ctx, "RedisService.Scan success", map[string]any{"match": match, "keys": seen})
+	return seen, nil
+}
+
+func (s *redisService) Pipelined(ctx context.Context, fn func(redis.Pipeliner) error) ([]redis.Cmder, error) {
+	cmds, err := s.repo.Pipelined(ctx, fn)
+	if err != nil {
+		logger.Warn(ctx, "RedisService.Pipelined failed", map[string]any{"commands": len(cmds), "error": err.Error()})
+		return cmds, err
+	}
+	logger.Debug(ctx, "RedisService.Pipelined success", map[string]any{"commands": len(cmds)})
+	return cmds, nil
+}
+
+func (s *redisService) TxPipelined(ctx context.Context, fn func(redis.Pipeliner) error) ([]redis.Cmder, error) {
+	cmds, err := s.repo.TxPipelined(ctx, fn)
+	if err != nil {
+		logger.Warn(ctx, "RedisService.TxPipelined failed", map[string]any{"commands": len(cmds), "error": err.Error()})
+		return cmds, err
+	}
+	logger.Debug(ctx, "RedisService.TxPipelined success", map[string]any{"commands": len(cmds)})
+	return cmds, nil
+}
+
+func (s *redisService) Watch(ctx context.Context, attempts int, fn func(*redis.Tx) error, keys ...string) error {
+	var err error
+	for attempt := 1; attempt <= max(attempts, 1); attempt++ {
+		err = s.repo.Watch(ctx, fn, keys...)
+		if !errors.Is(err, redis.TxFailedErr) {
+			break
+		}
+		logger.Debug(ctx, "RedisService.Watch conflict, retrying", map[string]any{"keys": keys, "attempt": attempt})
+	}
+	if err != nil {
+		logger.Warn(ctx, "RedisService.Watch failed", map[string]any{"keys": keys, "error": err.Error()})
+		return err
+	}
+	logger.Debug(ctx, "RedisService.Watch success", map[string]any{"keys": keys})
+	return nil
+}
+
+func (s *redisService) LoadScripts(ctx context.Context, scripts ...*redis.Script) error {
+	for _, script := range scripts {
+		if err := s.repo.LoadScript(ctx, script); err != nil {
+			logger.Warn(ctx, "RedisService.LoadScripts failed", map[string]any{"sha": script.Hash(), "error": err.Error()})
+			return err
+		}
+	}
+	logger.Debug(ctx, "RedisService.LoadScripts success", map[string]any{"scripts": len(scripts)})
+	return nil
+}
+
+func (s *redisService) RunScript(ctx context.Context, script *redis.Script, keys []string, args ...any) *redis.Cmd {
+	cmd := s.repo.RunScript(ctx, script, keys, args...)
+	if err := cmd.Err(); err != nil && !errors.Is(err, redis.Nil) {
+		logger.Warn(ctx, "RedisService.RunScript failed", map[string]any{"sha": script.Hash(), "keys": keys, "error": err.Error()})
+		return cmd
+	}
+	logger.Debug(ctx, "RedisService.RunScript success", map[string]any{"sha": script.Hash(), "keys": keys})
+	return cmd
+}
+
+func (s *redisService) XAdd(ctx context.Context, args *redis.XAddArgs) (string, error) {
+	id, err := s.repo.XAdd(ctx, args)
+	if err != nil {
+		logger.Warn(ctx, "RedisService.XAdd failed", map[string]any{"stream": args.Stream, "error": err.Error()})
+		return "", err
+	}
+	logger.Debug(ctx, "RedisService.XAdd success", map[string]any{"stream": args.Stream, "id": id})
+	return id, nil
+}
+
+func (s *redisService) XGroupCreateMkStream(ctx context.Context, stream, group, start string) error {
+	if err := s.repo.XGroupCreateMkStream(ctx, stream, group, start); err != nil {
+		// An existing group is left to the caller.
+		if !redis.HasErrorPrefix(err, "BUSYGROUP") {
+			logger.Warn(ctx, "RedisService.XGroupCreateMkStream failed", map[string]any{"stream": stream, "group": group, "error": err.Error()})
+		}
+		return err
+	}
+	logger.Debug(ctx, "RedisService.XGroupCreateMkStream success", map[string]any{"stream": stream, "group": group})
+	return nil
+}
+
+func (s *redisService) XReadGroup(ctx context.Context, args *redis.XReadGroupArgs) ([]redis.XStream, error) {
+	streams, err := s.repo.XReadGroup(ctx, args)
+	if err != nil {
+		// An empty read and a read cut short by shutdown are expected.
+		if !errors.Is(err, redis.Nil) && ctx.Err() == nil {
+			logger.Warn(ctx, "RedisService.XReadGroup failed", map[string]any{"streams": args.Streams, "group": args.Group, "error": err.Error()})
+		}
+		return nil, err
+	}
+	logger.Debug(ctx, "RedisService.XReadGroup success", map[string]any{"streams": args.Streams, "group": args.Group})
+	return streams, nil
+}
+
+func (s *redisService) XAck(ctx context.Context, stream, group string, ids ...string) (int64, error) {
+	n, err := s.repo.XAck(ctx, stream, group, ids...)
+	if err != nil {
+		logger.Warn(ctx, "RedisService.XAck failed", map[string]any{"stream": stream, "group": group, "error": err.Error()})
+		return 0, err
+	}
+	logger.Debug(ctx, "RedisService.XAck success", map[string]any{"stream": stream, "group": group, "acked": n})
+	return n, nil
+}
+
+func (s *redisService) XAutoClaim(ctx context.Context, args *redis.XAutoClaimArgs) ([]redis.XMessage, string, error) {
+	messages, next, err := s.repo.XAutoClaim(ctx, args)
+	if err != nil {
+		logger.Warn(ctx, "RedisService.XAutoClaim failed", map[string]any{"stream": args.Stream, "group": args.Group, "error": err.Error()})
+		return nil, "", err
+	}
+	logger.Debug(ctx, "RedisService.XAutoClaim success", map[string]any{"stream": args.Stream, "group": args.Group, "claimed": len(messages)})
+	return messages, next, nil
+}
+
+func (s *redisService) Publish(ctx context.Context, channel, message string) (int64, error) {
+	n, err := s.repo.Publish(ctx, channel, message)
+	if err != nil {
+		logger.Warn(ctx, "RedisService.Publish failed", map[string]any{"channel": channel, "error": err.Error()})
+		return 0, err
+	}
+	logger.Debug(ctx, "RedisService.Publish success", map[string]any{"channel": channel, "receivers": n})
+	return n, nil
+}
+
+func (s *redisService) Subscribe(ctx context.Context, fn func(context.Context, *redis.Message), channels ...string) error {
+	sub := s.repo.Subscribe(ctx, channels...)
+	defer sub.Close()
+	if _, err := sub.Receive(ctx); err != nil {
+		logger.Warn(ctx, "RedisService.Subscribe failed", map[string]any{"channels": channels, "error": err.Error()})
+		return err
+	}
+	logger.Info(ctx, "RedisService.Subscribe subscribed", map[string]any{"channels": channels})
+
+	messages := sub.Channel()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case msg, ok := <-messages:
+			if !ok {
+				logger.Warn(ctx, "RedisService.Subscribe closed", map[string]any{"channels": channels})
+				return errors.New("redis: subscription closed")
+			}
+			fn(ctx, msg)
+		}
+	}
+}
+
+// logMiss logs a failed lookup: at debug level when the key or field is
+// missing, as a warning otherwise.
+func logMiss(ctx context.Context, op string, err error, fields map[string]any) {
+	fields["error"] = err.Error()
+	if errors.Is(err, redis.Nil) {
+		logger.Debug(ctx, op+" miss", fields)
+		return
+	}
+	logger.Warn(ctx, op+" failed", fields)
+}
+
 // Helpers
 func (s *redisService) CacheUserSession(ctx context.Context, userID uint, token string, ttl time.Duration) error {
-	key := fmt.Sprintf("user_session:%d", userID)
+	key := utilities.UserSessionKey(userID)
 	if err := s.repo.Set(ctx, key, token, ttl); err != nil {
-		logger.Warn(ctx, "RedisService.CacheUserSession failed", map[string]any{"user_id": userID, "error": err.Error()})
-		return err
+		logger.Warn(ctx, "RedisService.CacheUserSession failed", map[string]any{"user_id": userID, "policy": s.sessionPolicy, "error": err.Error()})
+		return s.sessionError(err)
 	}
 	logger.Debug(ctx, "RedisService.CacheUserSession success", map[string]any{"user_id": userID})
 	return nil
 }
 
 func (s *redisService) GetUserSession(ctx context.Context, userID uint) (string, error) {
-	key := fmt.Sprintf("user_session:%d", userID)
+	key := utilities.UserSessionKey(userID)
 	val, err := s.repo.Get(ctx, key)
 	if err != nil {
 		logger.Debug(ctx, "RedisService.GetUserSession miss", map[string]any{"user_id": userID, "error": err.Error()})
@@ -110,11 +507,20 @@ func (s *redisService) GetUserSession(ctx context.Context, userID uint) (string,
 }
 
 func (s *redisService) DeleteUserSession(ctx context.Context, userID uint) error {
-	key := fmt.Sprintf("user_session:%d", userID)
+	key := utilities.UserSessionKey(userID)
 	if _, err := s.repo.Del(ctx, key); err != nil {
-		logger.Warn(ctx, "RedisService.DeleteUserSession failed", map[string]any{"user_id": userID, "error": err.Error()})
-		return err
+		logger.Warn(ctx, "RedisService.DeleteUserSession failed", map[string]any{"user_id": userID, "policy": s.sessionPolicy, "error": err.Error()})
+		return s.sessionError(err)
 	}
 	logger.Debug(ctx, "RedisService.DeleteUserSession success", map[string]any{"user_id": userID})
 	return nil
 }
+
+// sessionError applies the session policy to a failed Redis call: fail-open
+// drops the error, fail-closed reports the session store as unavailable.
+func (s *redisService) sessionError(err error) error {
+	if s.sessionPolicy == config.RedisFailClosed {
+		return fmt.Errorf("%w: %v", models.ErrUnavailable, err)
+	}
+	return nil
+}
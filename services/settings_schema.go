@@ -0,0 +1,159 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"time"
+	_ "time/tzdata" // timezone validation must not depend on the host's zoneinfo
+
+	"go-boilerplate/models"
+)
+
+// settingsSchemaVersion is the version of settingsSchema. Bump it when a key is
+// renamed or its meaning changes, and register a migration for the old version.
+const settingsSchemaVersion = 1
+
+// settingDefinition describes a known setting. validate checks a decoded JSON
+// value and returns it in canonical form.
+type settingDefinition struct {
+	defaultValue any
+	validate     func(value any) (any, error)
+}
+
+// settingsSchema registers every setting a user can store. Keys are flat; dots
+// only group related settings.
+var settingsSchema = map[string]settingDefinition{
+	"locale":                  stringSetting("en", validateLocale),
+	"timezone":                stringSetting("UTC", validateTimezone),
+	"theme":                   enumSetting("system", "light", "dark"),
+	"notifications.email":     boolSetting(true),
+	"notifications.security":  boolSetting(true),
+	"notifications.marketing": boolSetting(false),
+}
+
+// settingsMigrations upgrade stored values, keyed by the schema version they
+// upgrade from. Each migration rewrites values to the next version.
+var settingsMigrations = map[int]func(values map[string]any){}
+
+// upgradeSettings brings stored values to the current schema. Keys that are no
+// longer known and values that no longer validate are dropped, so the default applies.
+func upgradeSettings(settings *models.UserSettings) {
+	if settings.Values == nil {
+		settings.Values = map[string]any{}
+	}
+	for v := settings.SchemaVersion; v < settingsSchemaVersion; v++ {
+		if migrate, ok := settingsMigrations[v]; ok {
+			migrate(settings.Values)
+		}
+	}
+	settings.SchemaVersion = settingsSchemaVersion
+
+	for key, value := range settings.Values {
+		def, ok := settingsSchema[key]
+		if !ok {
+			delete(settings.Values, key)
+			continue
+		}
+		if _, err := def.validate(value); err != nil {
+			delete(settings.Values, key)
+		}
+	}
+}
+
+// applySettingsPatch merges a JSON merge patch (RFC 7396) into values. A null
+// resets a setting to its default. Nothing is changed if any entry is invalid.
+func applySettingsPatch(values map[string]any, patch map[string]any) error {
+	validated := make(map[string]any, len(patch))
+	for key, value := range patch {
+		def, ok := settingsSchema[key]
+		if !ok {
+			return fmt.Errorf("%w: unknown key %q", models.ErrInvalidSetting, key)
+		}
+		if value == nil {
+			validated[key] = nil
+			continue
+		}
+		canonical, err := def.validate(value)
+		if err != nil {
+			return fmt.Errorf("%w: %s: %v", models.ErrInvalidSetting, key, err)
+		}
+		validated[key] = canonical
+	}
+
+	for key, value := range validated {
+		if value == nil || value == settingsSchema[key].defaultValue {
+			delete(values, key)
+			continue
+		}
+		values[key] = value
+	}
+	return nil
+}
+
+// effectiveSettings returns every known setting with defaults filled in.
+func effectiveSettings(values map[string]any) map[string]any {
+	settings := make(map[string]any, len(settingsSchema))
+	for key, def := range settingsSchema {
+		settings[key] = def.defaultValue
+		if value, ok := values[key]; ok {
+			settings[key] = value
+		}
+	}
+	return settings
+}
+
+func boolSetting(def bool) settingDefinition {
+	return settingDefinition{defaultValue: def, validate: func(value any) (any, error) {
+		b, ok := value.(bool)
+		if !ok {
+			return nil, errors.New("must be a boolean")
+		}
+		return b, nil
+	}}
+}
+
+func stringSetting(def string, check func(string) error) settingDefinition {
+	return settingDefinition{defaultValue: def, validate: func(value any) (any, error) {
+		s, ok := value.(string)
+		if !ok {
+			return nil, errors.New("must be a string")
+		}
+		if err := check(s); err != nil {
+			return nil, err
+		}
+		return s, nil
+	}}
+}
+
+func enumSetting(def string, others ...string) settingDefinition {
+	allowed := append([]string{def}, others...)
+	return stringSetting(def, func(s string) error {
+		if !slices.Contains(allowed, s) {
+			return fmt.Errorf("must be one of %v", allowed)
+		}
+		return nil
+	})
+}
+
+// localePattern accepts BCP 47 tags of the form language[-Script][-REGION], e.g. "en", "pt-BR", "zh-Hant-TW".
+var localePattern = regexp.MustCompile(`^[a-z]{2,3}(-[A-Z][a-z]{3})?(-([A-Z]{2}|[0-9]{3}))?$`)
+
+func validateLocale(s string) error {
+	if !localePattern.MatchString(s) {
+		return errors.New("must be a BCP 47 language tag such as \"en\" or \"pt-BR\"")
+	}
+	return nil
+}
+
+func validateTimezone(s string) error {
+	// LoadLocation also accepts "" and "Local", which mean nothing to a client.
+	if s == "" || s == "Local" {
+		return errors.New("must be an IANA time zone name")
+	}
+	if _, err := time.LoadLocation(s); err != nil {
+		return errors.New("must be an IANA time zone name")
+	}
+	return nil
+}
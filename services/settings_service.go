@@ -0,0 +1,103 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go-boilerplate/logger"
+	"go-boilerplate/models"
+	"go-boilerplate/models/response"
+	repoInterfaces "go-boilerplate/repository/interfaces"
+	serviceInterfaces "go-boilerplate/services/interfaces"
+	"go-boilerplate/utilities"
+
+	"gorm.io/gorm"
+)
+
+type settingsService struct {
+	settingsRepo repoInterfaces.SettingsRepository
+	transactor   repoInterfaces.Transactor
+	redisService serviceInterfaces.RedisService
+}
+
+func NewSettingsService(settingsRepo repoInterfaces.SettingsRepository, transactor repoInterfaces.Transactor, redisService serviceInterfaces.RedisService) serviceInterfaces.SettingsService {
+	return &settingsService{settingsRepo: settingsRepo, transactor: transactor, redisService: redisService}
+}
+
+func (s *settingsService) Get(ctx context.Context, userID uint) (*response.SettingsResponse, error) {
+	logger.Debug(ctx, "SettingsService.Get start", map[string]any{"user_id": userID})
+	// Entries written under an older schema are rebuilt from the database.
+	var cached response.SettingsResponse
+	if err := s.redisService.GetJSON(ctx, utilities.UserSettingsCacheKey(userID), &cached); err == nil && cached.SchemaVersion == settingsSchemaVersion {
+		logger.Debug(ctx, "Get: cache hit", map[string]any{"user_id": userID})
+		return &cached, nil
+	}
+
+	settings, err := s.settingsRepo.GetByUserID(ctx, userID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		settings = &models.UserSettings{UserID: userID}
+	} else if err != nil {
+		logger.Error(ctx, "Get: repo get failed", map[string]any{"user_id": userID, "error": err.Error()})
+		return nil, err
+	}
+	upgradeSettings(settings)
+
+	settingsResponse := toSettingsResponse(settings)
+	s.cache(ctx, "Get", userID, settingsResponse)
+	return settingsResponse, nil
+}
+
+func (s *settingsService) Update(ctx context.Context, userID uint, patch map[string]any) (*response.SettingsResponse, error) {
+	logger.Info(ctx, "SettingsService.Update start", map[string]any{"user_id": userID, "keys": len(patch)})
+	settings, err := s.settingsRepo.Update(ctx, userID, func(settings *models.UserSettings) error {
+		upgradeSettings(settings)
+		return applySettingsPatch(settings.Values, patch)
+	})
+	if err != nil {
+		if errors.Is(err, models.ErrInvalidSetting) {
+			logger.Warn(ctx, "Update: invalid settings", map[string]any{"user_id": userID, "error": err.Error()})
+			return nil, err
+		}
+		logger.Error(ctx, "Update: repo update failed", map[string]any{"user_id": userID, "error": err.Error()})
+		return nil, err
+	}
+
+	settingsResponse := toSettingsResponse(settings)
+	s.cache(ctx, "Update", userID, settingsResponse)
+	logger.Info(ctx, "SettingsService.Update success", map[string]any{"user_id": userID})
+	return settingsResponse, nil
+}
+
+func (s *settingsService) Delete(ctx context.Context, userID uint) error {
+	if err := s.settingsRepo.Delete(ctx, userID); err != nil {
+		logger.Error(ctx, "SettingsService.Delete failed", map[string]any{"user_id": userID, "error": err.Error()})
+		return err
+	}
+	// Within a transaction, e.g. an erasure, the cached copy goes once the
+	// row is gone for good.
+	s.transactor.AfterCommit(ctx, func(ctx context.Context) {
+		if err := s.redisService.Delete(ctx, utilities.UserSettingsCacheKey(userID)); err != nil {
+			logger.Warn(ctx, "Delete: cache delete failed", map[string]any{"user_id": userID, "error": err.Error()})
+		}
+	})
+	return nil
+}
+
+func (s *settingsService) cache(ctx context.Context, op string, userID uint, settings *response.SettingsResponse) {
+	if err := s.redisService.SetJSON(ctx, utilities.UserSettingsCacheKey(userID), settings, 30*time.Minute); err != nil {
+		logger.Warn(ctx, op+": cache set failed", map[string]any{"user_id": userID, "error": err.Error()})
+	}
+}
+
+func toSettingsResponse(settings *models.UserSettings) *response.SettingsResponse {
+	settingsResponse := &response.SettingsResponse{
+		SchemaVersion: settings.SchemaVersion,
+		Settings:      effectiveSettings(settings.Values),
+	}
+	if !settings.UpdatedAt.IsZero() {
+		updatedAt := settings.UpdatedAt
+		settingsResponse.UpdatedAt = &updatedAt
+	}
+	return settingsResponse
+}
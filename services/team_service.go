@@ -0,0 +1,154 @@
+package services
+
+import (
+	"context"
+	"errors"
+
+	"go-boilerplate/logger"
+	"go-boilerplate/models"
+	"go-boilerplate/models/request"
+	"go-boilerplate/models/response"
+	repoInterfaces "go-boilerplate/repository/interfaces"
+	serviceInterfaces "go-boilerplate/services/interfaces"
+	"go-boilerplate/tenant"
+
+	"gorm.io/gorm"
+)
+
+type teamService struct {
+	teamRepo repoInterfaces.TeamRepository
+	orgRepo  repoInterfaces.OrganizationRepository
+}
+
+func NewTeamService(teamRepo repoInterfaces.TeamRepository, orgRepo repoInterfaces.OrganizationRepository) serviceInterfaces.TeamService {
+	return &teamService{
+		teamRepo: teamRepo,
+		orgRepo:  orgRepo,
+	}
+}
+
+func (s *teamService) CreateTeam(ctx context.Context, orgID, actorID uint, req *request.CreateTeamRequest) (*response.TeamResponse, error) {
+	logger.Info(ctx, "TeamService.CreateTeam start", map[string]any{"organization_id": orgID, "actor_id": actorID})
+	if _, err := authorizeMember(ctx, s.orgRepo, orgID, actorID, models.MembershipRoleOwner, models.MembershipRoleAdmin); err != nil {
+		return nil, err
+	}
+
+	team := &models.Team{OrganizationID: orgID, Name: req.Name}
+	if err := s.teamRepo.Create(tenant.WithID(ctx, orgID), team); err != nil {
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			logger.Warn(ctx, "CreateTeam: name already taken", map[string]any{"organization_id": orgID, "name": req.Name})
+			return nil, models.ErrTeamExists
+		}
+		logger.Error(ctx, "CreateTeam: repo create failed", map[string]any{"organization_id": orgID, "error": err.Error()})
+		return nil, err
+	}
+
+	logger.Info(ctx, "TeamService.CreateTeam success", map[string]any{"organization_id": orgID, "team_id": team.ID})
+	return toTeamResponse(team), nil
+}
+
+func (s *teamService) ListTeams(ctx context.Context, orgID, actorID uint) ([]*response.TeamResponse, error) {
+	if _, err := authorizeMember(ctx, s.orgRepo, orgID, actorID); err != nil {
+		return nil, err
+	}
+	teams, err := s.teamRepo.ListByOrganization(tenant.WithID(ctx, orgID), orgID)
+	if err != nil {
+		logger.Error(ctx, "TeamService.ListTeams failed", map[string]any{"organization_id": orgID, "error": err.Error()})
+		return nil, err
+	}
+	res := make([]*response.TeamResponse, len(teams))
+	for i, team := range teams {
+		res[i] = toTeamResponse(team)
+	}
+	return res, nil
+}
+
+func (s *teamService) AddTeamMember(ctx context.Context, orgID, teamID, actorID uint, req *request.AddTeamMemberRequest) (*response.MemberResponse, error) {
+	logger.Info(ctx, "TeamService.AddTeamMember start", map[string]any{"organization_id": orgID, "team_id": teamID, "user_id": req.UserID})
+	if _, err := authorizeMember(ctx, s.orgRepo, orgID, actorID, models.MembershipRoleOwner, models.MembershipRoleAdmin); err != nil {
+		return nil, err
+	}
+	ctx = tenant.WithID(ctx, orgID)
+	if _, err := s.getTeam(ctx, orgID, teamID); err != nil {
+		return nil, err
+	}
+
+	// Only members of the organization can join its teams.
+	if _, err := s.orgRepo.GetMembership(ctx, orgID, req.UserID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			logger.Warn(ctx, "AddTeamMember: user is not a member", map[string]any{"organization_id": orgID, "user_id": req.UserID})
+			return nil, models.ErrUserNotFound
+		}
+		logger.Error(ctx, "AddTeamMember: membership get failed", map[string]any{"organization_id": orgID, "user_id": req.UserID, "error": err.Error()})
+		return nil, err
+	}
+
+	role := req.Role
+	if role == "" {
+		role = models.TeamRoleMember
+	}
+	member := &models.TeamMember{TeamID: teamID, UserID: req.UserID, Role: role}
+	if err := s.teamRepo.AddMember(ctx, member); err != nil {
+		logger.Error(ctx, "AddTeamMember: repo add failed", map[string]any{"team_id": teamID, "user_id": req.UserID, "error": err.Error()})
+		return nil, err
+	}
+
+	added, err := s.teamRepo.GetMember(ctx, teamID, req.UserID)
+	if err != nil {
+		logger.Error(ctx, "AddTeamMember: repo get failed", map[string]any{"team_id": teamID, "user_id": req.UserID, "error": err.Error()})
+		return nil, err
+	}
+	if added.User == nil || added.User.ID == 0 {
+		return nil, models.ErrUserNotFound
+	}
+
+	logger.Info(ctx, "TeamService.AddTeamMember success", map[string]any{"team_id": teamID, "user_id": req.UserID, "role": role})
+	return toMemberResponse(added.User, added.Role, added.CreatedAt), nil
+}
+
+func (s *teamService) ListTeamMembers(ctx context.Context, orgID, teamID, actorID uint) ([]*response.MemberResponse, error) {
+	if _, err := authorizeMember(ctx, s.orgRepo, orgID, actorID); err != nil {
+		return nil, err
+	}
+	ctx = tenant.WithID(ctx, orgID)
+	if _, err := s.getTeam(ctx, orgID, teamID); err != nil {
+		return nil, err
+	}
+
+	teamMembers, err := s.teamRepo.ListMembers(ctx, teamID)
+	if err != nil {
+		logger.Error(ctx, "TeamService.ListTeamMembers failed", map[string]any{"team_id": teamID, "error": err.Error()})
+		return nil, err
+	}
+	members := make([]*response.MemberResponse, 0, len(teamMembers))
+	for _, member := range teamMembers {
+		if member.User == nil || member.User.ID == 0 {
+			continue
+		}
+		members = append(members, toMemberResponse(member.User, member.Role, member.CreatedAt))
+	}
+	return members, nil
+}
+
+// getTeam loads a team of the organization, mapping a missing team to models.ErrNotFound.
+func (s *teamService) getTeam(ctx context.Context, orgID, teamID uint) (*models.Team, error) {
+	team, err := s.teamRepo.GetByID(ctx, orgID, teamID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			logger.Warn(ctx, "getTeam: not found", map[string]any{"organization_id": orgID, "team_id": teamID})
+			return nil, models.ErrNotFound
+		}
+		logger.Error(ctx, "getTeam: repo get failed", map[string]any{"team_id": teamID, "error": err.Error()})
+		return nil, err
+	}
+	return team, nil
+}
+
+func toTeamResponse(team *models.Team) *response.TeamResponse {
+	return &response.TeamResponse{
+		ID:             team.ID,
+		OrganizationID: team.OrganizationID,
+		Name:           team.Name,
+		CreatedAt:      team.CreatedAt,
+	}
+}
@@ -0,0 +1,396 @@
+package services
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"go-boilerplate/config"
+	"go-boilerplate/logger"
+	"go-boilerplate/models"
+	repoInterfaces "go-boilerplate/repository/interfaces"
+	serviceInterfaces "go-boilerplate/services/interfaces"
+	"go-boilerplate/utilities"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// tusLockTTL is how long the lock of an upload outlives a request that stopped
+// extending it, e.g. because its instance died. A PATCH extends the lock every
+// third of it for as long as it copies, so chunks may take longer.
+const tusLockTTL = time.Minute
+
+// errTusLockLost stops a chunk whose lock could not be extended, since
+// another request may have taken the upload over.
+var errTusLockLost = errors.New("tus: upload lock lost")
+
+// tusUnlockScript deletes the lock KEYS[1] if it still holds the token ARGV[1],
+// so that a lock that expired and was taken by another request stays in place.
+var tusUnlockScript = redis.NewScript(`
+if redis.call('GET', KEYS[1]) == ARGV[1] then
+	return redis.call('DEL', KEYS[1])
+end
+return 0
+`)
+
+// tusExtendScript resets the TTL of the lock KEYS[1] to ARGV[2] milliseconds
+// if it still holds the token ARGV[1].
+var tusExtendScript = redis.NewScript(`
+if redis.call('GET', KEYS[1]) == ARGV[1] then
+	return redis.call('PEXPIRE', KEYS[1], ARGV[2])
+end
+return 0
+`)
+
+type tusService struct {
+	redisRepo  repoInterfaces.RedisRepository
+	dir        string
+	maxSize    int64
+	expiration time.Duration
+	onComplete serviceInterfaces.TusCompletionHook
+}
+
+// NewTusService creates the tus upload service. A nil hook only logs completed uploads.
+func NewTusService(redisRepo repoInterfaces.RedisRepository, cfg *config.Config, onComplete serviceInterfaces.TusCompletionHook) (serviceInterfaces.TusService, error) {
+	if err := os.MkdirAll(cfg.Tus.Dir, 0o755); err != nil {
+		return nil, fmt.Errorf("tus: create upload dir: %w", err)
+	}
+	if onComplete == nil {
+		onComplete = logTusCompletion
+	}
+	return &tusService{
+		redisRepo:  redisRepo,
+		dir:        cfg.Tus.Dir,
+		maxSize:    cfg.Tus.MaxSize,
+		expiration: cfg.Tus.Expiration,
+		onComplete: onComplete,
+	}, nil
+}
+
+func (s *tusService) Create(ctx context.Context, userID uint, length int64, metadata map[string]string) (*models.TusUpload, error) {
+	logger.Info(ctx, "TusService.Create start", map[string]any{"user_id": userID, "length": length})
+	if length < 0 {
+		return nil, fmt.Errorf("invalid upload length %d", length)
+	}
+	if length > s.maxSize {
+		logger.Warn(ctx, "Create: upload too large", map[string]any{"user_id": userID, "length": length, "max": s.maxSize})
+		return nil, models.ErrFileTooLarge
+	}
+
+	now := time.Now().UTC()
+	upload := &models.TusUpload{
+		ID:        utilities.NewRandomID(),
+		UserID:    userID,
+		Length:    length,
+		Metadata:  metadata,
+		CreatedAt: now,
+		ExpiresAt: now.Add(s.expiration),
+	}
+	f, err := os.OpenFile(s.path(upload.ID), os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o644)
+	if err != nil {
+		logger.Error(ctx, "Create: file create failed", map[string]any{"upload_id": upload.ID, "error": err.Error()})
+		return nil, err
+	}
+	if err := f.Close(); err != nil {
+		return nil, err
+	}
+
+	if err := s.save(ctx, upload); err != nil {
+		logger.Error(ctx, "Create: state save failed", map[string]any{"upload_id": upload.ID, "error": err.Error()})
+		_ = os.Remove(s.path(upload.ID))
+		return nil, err
+	}
+
+	// An empty upload is complete as soon as it exists.
+	if length == 0 {
+		s.complete(ctx, upload)
+	}
+
+	logger.Info(ctx, "TusService.Create success", map[string]any{"upload_id": upload.ID, "user_id": userID})
+	return upload, nil
+}
+
+func (s *tusService) Get(ctx context.Context, userID uint, id string) (*models.TusUpload, error) {
+	upload, err := s.load(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if upload.UserID != userID {
+		logger.Warn(ctx, "Get: upload owned by another user", map[string]any{"upload_id": id, "user_id": userID})
+		return nil, models.ErrNotFound
+	}
+	return upload, nil
+}
+
+func (s *tusService) WriteChunk(ctx context.Context, userID uint, id string, offset int64, r io.Reader) (*models.TusUpload, error) {
+	logger.Debug(ctx, "TusService.WriteChunk start", map[string]any{"upload_id": id, "offset": offset})
+	if !isTusUploadID(id) {
+		return nil, models.ErrNotFound
+	}
+
+	token, err := s.lock(ctx, "WriteChunk", id)
+	if err != nil {
+		return nil, err
+	}
+	// The request context is cancelled when the client drops mid-chunk, which
+	// is when recording the bytes received and releasing the lock matter most.
+	recordCtx := context.WithoutCancel(ctx)
+	defer s.unlock(recordCtx, id, token)
+
+	upload, err := s.Get(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+	if offset != upload.Offset {
+		logger.Warn(ctx, "WriteChunk: offset mismatch", map[string]any{"upload_id": id, "offset": offset, "current": upload.Offset})
+		return nil, models.ErrOffsetMismatch
+	}
+
+	f, err := os.OpenFile(s.path(id), os.O_WRONLY, 0)
+	if err != nil {
+		logger.Error(ctx, "WriteChunk: file open failed", map[string]any{"upload_id": id, "error": err.Error()})
+		return nil, err
+	}
+	if _, err := f.Seek(offset, io.SeekStart); err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	remaining := upload.Length - upload.Offset
+	lost, stop := s.keepLocked(recordCtx, id, token)
+	written, copyErr := io.Copy(f, io.LimitReader(&tusLockedReader{r: r, lost: lost}, remaining))
+	stop()
+	if closeErr := f.Close(); copyErr == nil {
+		copyErr = closeErr
+	}
+	if errors.Is(copyErr, errTusLockLost) {
+		// The state belongs to whoever holds the lock now.
+		logger.Error(ctx, "WriteChunk: lock lost while copying", map[string]any{"upload_id": id, "written": written})
+		return nil, models.ErrLocked
+	}
+	if copyErr == nil && written == remaining {
+		// Anything beyond the declared length is a client error.
+		if n, _ := r.Read(make([]byte, 1)); n > 0 {
+			copyErr = models.ErrFileTooLarge
+		}
+	}
+
+	// Record what was received even if the connection dropped mid-chunk.
+	upload.Offset += written
+	upload.ExpiresAt = time.Now().UTC().Add(s.expiration)
+	if err := s.save(recordCtx, upload); err != nil {
+		logger.Error(ctx, "WriteChunk: state save failed", map[string]any{"upload_id": id, "error": err.Error()})
+		return nil, err
+	}
+	if errors.Is(copyErr, models.ErrFileTooLarge) {
+		logger.Warn(ctx, "WriteChunk: chunk exceeds upload length", map[string]any{"upload_id": id, "length": upload.Length})
+		return upload, copyErr
+	}
+
+	// A connection that drops right after the last byte still completes the upload.
+	if upload.Offset == upload.Length && !upload.Completed {
+		s.complete(recordCtx, upload)
+	}
+	if copyErr != nil {
+		logger.Warn(ctx, "WriteChunk: chunk interrupted", map[string]any{"upload_id": id, "written": written, "error": copyErr.Error()})
+		return upload, copyErr
+	}
+
+	logger.Debug(ctx, "TusService.WriteChunk success", map[string]any{"upload_id": id, "offset": upload.Offset, "length": upload.Length})
+	return upload, nil
+}
+
+func (s *tusService) Terminate(ctx context.Context, userID uint, id string) error {
+	logger.Info(ctx, "TusService.Terminate start", map[string]any{"upload_id": id, "user_id": userID})
+	if !isTusUploadID(id) {
+		return models.ErrNotFound
+	}
+
+	// Holding the write lock keeps a concurrent PATCH from saving the state
+	// again after it has been deleted.
+	token, err := s.lock(ctx, "Terminate", id)
+	if err != nil {
+		return err
+	}
+	defer s.unlock(context.WithoutCancel(ctx), id, token)
+
+	if _, err := s.Get(ctx, userID, id); err != nil {
+		return err
+	}
+	if _, err := s.redisRepo.Del(ctx, utilities.TusUploadCacheKey(id)); err != nil {
+		logger.Error(ctx, "Terminate: state delete failed", map[string]any{"upload_id": id, "error": err.Error()})
+		return err
+	}
+	if err := os.Remove(s.path(id)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		logger.Warn(ctx, "Terminate: file remove failed", map[string]any{"upload_id": id, "error": err.Error()})
+	}
+	logger.Info(ctx, "TusService.Terminate success", map[string]any{"upload_id": id})
+	return nil
+}
+
+func (s *tusService) CleanupExpired(ctx context.Context) (int, error) {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return 0, err
+	}
+
+	removed := 0
+	cutoff := time.Now().Add(-s.expiration)
+	for _, entry := range entries {
+		if entry.IsDir() || !isTusUploadID(entry.Name()) {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil || info.ModTime().After(cutoff) {
+			continue
+		}
+		exists, err := s.redisRepo.Exists(ctx, utilities.TusUploadCacheKey(entry.Name()))
+		if err != nil {
+			return removed, err
+		}
+		if exists > 0 {
+			continue
+		}
+		if err := os.Remove(filepath.Join(s.dir, entry.Name())); err != nil && !errors.Is(err, os.ErrNotExist) {
+			logger.Warn(ctx, "CleanupExpired: file remove failed", map[string]any{"upload_id": entry.Name(), "error": err.Error()})
+			continue
+		}
+		removed++
+	}
+	if removed > 0 {
+		logger.Info(ctx, "TusService.CleanupExpired success", map[string]any{"removed": removed})
+	}
+	return removed, nil
+}
+
+// complete marks an upload as finished and hands it to the completion hook.
+// Hook failures are logged; the data stays in place until the upload expires.
+func (s *tusService) complete(ctx context.Context, upload *models.TusUpload) {
+	upload.Completed = true
+	if err := s.save(ctx, upload); err != nil {
+		logger.Error(ctx, "complete: state save failed", map[string]any{"upload_id": upload.ID, "error": err.Error()})
+	}
+	if err := s.onComplete(ctx, upload, s.path(upload.ID)); err != nil {
+		logger.Error(ctx, "complete: completion hook failed", map[string]any{"upload_id": upload.ID, "error": err.Error()})
+	}
+}
+
+func (s *tusService) load(ctx context.Context, id string) (*models.TusUpload, error) {
+	if !isTusUploadID(id) {
+		return nil, models.ErrNotFound
+	}
+	raw, err := s.redisRepo.Get(ctx, utilities.TusUploadCacheKey(id))
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return nil, models.ErrNotFound
+		}
+		logger.Error(ctx, "load: state get failed", map[string]any{"upload_id": id, "error": err.Error()})
+		return nil, err
+	}
+	var upload models.TusUpload
+	if err := json.Unmarshal([]byte(raw), &upload); err != nil {
+		return nil, err
+	}
+	return &upload, nil
+}
+
+func (s *tusService) save(ctx context.Context, upload *models.TusUpload) error {
+	raw, err := json.Marshal(upload)
+	if err != nil {
+		return err
+	}
+	return s.redisRepo.Set(ctx, utilities.TusUploadCacheKey(upload.ID), string(raw), time.Until(upload.ExpiresAt))
+}
+
+// lock takes the write lock of an upload for op and returns the token that
+// releases it. It returns models.ErrLocked while another request holds it.
+func (s *tusService) lock(ctx context.Context, op, id string) (string, error) {
+	token := utilities.NewRandomID()
+	locked, err := s.redisRepo.SetNX(ctx, utilities.TusUploadLockKey(id), token, tusLockTTL)
+	if err != nil {
+		logger.Error(ctx, op+": lock failed", map[string]any{"upload_id": id, "error": err.Error()})
+		return "", err
+	}
+	if !locked {
+		logger.Warn(ctx, op+": upload is locked", map[string]any{"upload_id": id})
+		return "", models.ErrLocked
+	}
+	return token, nil
+}
+
+// unlock releases the write lock if it is still held by token.
+func (s *tusService) unlock(ctx context.Context, id, token string) {
+	if err := s.redisRepo.RunScript(ctx, tusUnlockScript, []string{utilities.TusUploadLockKey(id)}, token).Err(); err != nil {
+		logger.Warn(ctx, "unlock: lock release failed", map[string]any{"upload_id": id, "error": err.Error()})
+	}
+}
+
+// keepLocked extends the write lock every third of tusLockTTL until stop is
+// called. lost is closed if the lock could not be extended.
+func (s *tusService) keepLocked(ctx context.Context, id, token string) (lost <-chan struct{}, stop func()) {
+	lostCh, done := make(chan struct{}), make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(tusLockTTL / 3)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+			}
+			n, err := s.redisRepo.RunScript(ctx, tusExtendScript, []string{utilities.TusUploadLockKey(id)}, token, tusLockTTL.Milliseconds()).Int64()
+			if err != nil || n == 0 {
+				fields := map[string]any{"upload_id": id}
+				if err != nil {
+					fields["error"] = err.Error()
+				}
+				logger.Warn(ctx, "keepLocked: lock extension failed", fields)
+				close(lostCh)
+				return
+			}
+		}
+	}()
+	var once sync.Once
+	return lostCh, func() { once.Do(func() { close(done) }) }
+}
+
+// tusLockedReader fails reads once the upload's lock has been lost.
+type tusLockedReader struct {
+	r    io.Reader
+	lost <-chan struct{}
+}
+
+func (r *tusLockedReader) Read(p []byte) (int, error) {
+	select {
+	case <-r.lost:
+		return 0, errTusLockLost
+	default:
+		return r.r.Read(p)
+	}
+}
+
+func (s *tusService) path(id string) string {
+	return filepath.Join(s.dir, id)
+}
+
+// isTusUploadID reports whether id has the shape of utilities.NewRandomID, which
+// also keeps it safe to use as a file name.
+func isTusUploadID(id string) bool {
+	if len(id) != 32 {
+		return false
+	}
+	_, err := hex.DecodeString(id)
+	return err == nil
+}
+
+func logTusCompletion(ctx context.Context, upload *models.TusUpload, path string) error {
+	logger.Info(ctx, "Tus upload completed", map[string]any{"upload_id": upload.ID, "user_id": upload.UserID, "length": upload.Length, "path": path})
+	return nil
+}
@@ -0,0 +1,178 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+
+	"go-boilerplate/config"
+	"go-boilerplate/logger"
+	"go-boilerplate/models"
+	"go-boilerplate/models/response"
+	serviceInterfaces "go-boilerplate/services/interfaces"
+	"go-boilerplate/storage"
+)
+
+// uploadExtensions maps sniffed MIME types to the extension used in object keys.
+var uploadExtensions = map[string]string{
+	"image/jpeg":      ".jpg",
+	"image/png":       ".png",
+	"image/gif":       ".gif",
+	"image/webp":      ".webp",
+	"application/pdf": ".pdf",
+	"text/plain":      ".txt",
+}
+
+type uploadService struct {
+	store        storage.BlobStore
+	signer       *storage.URLSigner
+	baseURL      string
+	urlTTL       time.Duration
+	maxBytes     int64
+	allowedTypes map[string]bool
+}
+
+func NewUploadService(store storage.BlobStore, cfg *config.Config) serviceInterfaces.UploadService {
+	allowed := make(map[string]bool, len(cfg.Storage.AllowedTypes))
+	for _, t := range cfg.Storage.AllowedTypes {
+		allowed[t] = true
+	}
+	return &uploadService{
+		store:        store,
+		signer:       storage.NewURLSigner(cfg.Storage.SigningSecret),
+		baseURL:      cfg.BaseURL,
+		urlTTL:       cfg.Storage.URLTTL,
+		maxBytes:     cfg.Storage.MaxUploadBytes,
+		allowedTypes: allowed,
+	}
+}
+
+func (s *uploadService) Upload(ctx context.Context, r io.Reader) (*response.UploadResponse, error) {
+	logger.Info(ctx, "UploadService.Upload start", nil)
+	spool, err := spoolUpload(r, s.maxBytes, s.allowedTypes)
+	if err != nil {
+		logger.Warn(ctx, "Upload: rejected", map[string]any{"error": err.Error()})
+		return nil, err
+	}
+	defer spool.Close()
+
+	key := fmt.Sprintf("files/%s/%s/%s%s", spool.SHA256[:2], spool.SHA256[2:4], spool.SHA256, uploadExtensions[spool.ContentType])
+
+	// Identical content maps to the same key, so an existing object can be reused.
+	if _, err := s.store.Stat(ctx, key); errors.Is(err, storage.ErrNotFound) {
+		if err := s.store.Put(ctx, key, spool.File, spool.Size, spool.ContentType); err != nil {
+			logger.Error(ctx, "Upload: store put failed", map[string]any{"key": key, "error": err.Error()})
+			return nil, err
+		}
+	} else if err != nil {
+		logger.Error(ctx, "Upload: store stat failed", map[string]any{"key": key, "error": err.Error()})
+		return nil, err
+	} else {
+		logger.Debug(ctx, "Upload: deduplicated", map[string]any{"key": key})
+	}
+
+	downloadURL, expiresAt := s.SignedURL(key)
+	logger.Info(ctx, "UploadService.Upload success", map[string]any{"key": key, "size": spool.Size, "content_type": spool.ContentType})
+	return &response.UploadResponse{
+		Key:         key,
+		Size:        spool.Size,
+		ContentType: spool.ContentType,
+		SHA256:      spool.SHA256,
+		URL:         downloadURL,
+		ExpiresAt:   expiresAt,
+	}, nil
+}
+
+func (s *uploadService) Open(ctx context.Context, key string, expires int64, signature string) (io.ReadCloser, *storage.ObjectInfo, error) {
+	logger.Debug(ctx, "UploadService.Open start", map[string]any{"key": key})
+	if !s.signer.Verify(key, expires, signature) {
+		logger.Warn(ctx, "Open: invalid or expired signature", map[string]any{"key": key})
+		return nil, nil, models.ErrInvalidToken
+	}
+	rc, info, err := s.store.Get(ctx, key)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) || errors.Is(err, storage.ErrInvalidKey) {
+			return nil, nil, models.ErrNotFound
+		}
+		logger.Error(ctx, "Open: store get failed", map[string]any{"key": key, "error": err.Error()})
+		return nil, nil, err
+	}
+	return rc, info, nil
+}
+
+func (s *uploadService) SignedURL(key string) (string, time.Time) {
+	expires, signature := s.signer.Sign(key, s.urlTTL)
+	q := url.Values{}
+	q.Set("expires", fmt.Sprint(expires))
+	q.Set("signature", signature)
+	return fmt.Sprintf("%s/api/v1/files/%s?%s", s.baseURL, key, q.Encode()), time.Unix(expires, 0).UTC()
+}
+
+// spooledUpload is an upload buffered to a temp file, positioned at the start.
+type spooledUpload struct {
+	File        *os.File
+	Size        int64
+	SHA256      string
+	ContentType string
+}
+
+// Close removes the temp file.
+func (u *spooledUpload) Close() {
+	_ = u.File.Close()
+	_ = os.Remove(u.File.Name())
+}
+
+// spoolUpload copies r to a temp file while hashing it. The content type is
+// sniffed from the data itself (never trusted from the client) and checked
+// against allowed; more than maxBytes bytes fails with models.ErrFileTooLarge.
+func spoolUpload(r io.Reader, maxBytes int64, allowed map[string]bool) (*spooledUpload, error) {
+	head := make([]byte, 512)
+	n, err := io.ReadFull(r, head)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+	head = head[:n]
+	if n == 0 {
+		return nil, errors.New("empty upload")
+	}
+
+	contentType, _, _ := mime.ParseMediaType(http.DetectContentType(head))
+	if !allowed[contentType] {
+		return nil, fmt.Errorf("%w: %s", models.ErrUnsupportedMediaType, contentType)
+	}
+
+	f, err := os.CreateTemp("", "upload-*")
+	if err != nil {
+		return nil, err
+	}
+	upload := &spooledUpload{File: f, ContentType: contentType}
+
+	hasher := sha256.New()
+	w := io.MultiWriter(f, hasher)
+	size, err := io.Copy(w, io.LimitReader(io.MultiReader(bytes.NewReader(head), r), maxBytes+1))
+	if err != nil {
+		upload.Close()
+		return nil, err
+	}
+	if size > maxBytes {
+		upload.Close()
+		return nil, models.ErrFileTooLarge
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		upload.Close()
+		return nil, err
+	}
+
+	upload.Size = size
+	upload.SHA256 = hex.EncodeToString(hasher.Sum(nil))
+	return upload, nil
+}
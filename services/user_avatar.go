@@ -0,0 +1,145 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"go-boilerplate/logger"
+	"go-boilerplate/models"
+	"go-boilerplate/models/response"
+	"go-boilerplate/storage"
+	"go-boilerplate/utilities"
+
+	"gorm.io/gorm"
+)
+
+func (s *userService) SetAvatar(ctx context.Context, id uint, versions models.VersionMatch, r io.Reader) (*response.UserResponse, error) {
+	logger.Info(ctx, "UserService.SetAvatar start", map[string]any{"user_id": id, "versions": versions})
+	user, err := s.getUserForWrite(ctx, "SetAvatar", id, versions)
+	if err != nil {
+		return nil, err
+	}
+
+	spool, err := spoolUpload(r, s.cfg.Avatar.MaxBytes, avatarTypes)
+	if err != nil {
+		logger.Warn(ctx, "SetAvatar: rejected", map[string]any{"user_id": id, "error": err.Error()})
+		return nil, err
+	}
+	defer spool.Close()
+
+	thumbs, ext, err := makeAvatarThumbnails(spool.File, spool.ContentType, s.cfg.Avatar.Sizes)
+	if err != nil {
+		logger.Warn(ctx, "SetAvatar: image processing failed", map[string]any{"user_id": id, "content_type": spool.ContentType, "error": err.Error()})
+		return nil, err
+	}
+
+	avatar := &models.Avatar{
+		Prefix: fmt.Sprintf("%s%d/%s", models.AvatarKeyPrefix, id, utilities.NewRandomID()),
+		Ext:    ext,
+	}
+	for _, thumb := range thumbs {
+		if err := s.store.Put(ctx, avatar.Key(thumb.Size), bytes.NewReader(thumb.Data), int64(len(thumb.Data)), thumb.ContentType); err != nil {
+			logger.Error(ctx, "SetAvatar: store put failed", map[string]any{"user_id": id, "key": avatar.Key(thumb.Size), "error": err.Error()})
+			deleteAvatarFiles(ctx, s.store, avatar)
+			return nil, err
+		}
+		avatar.Sizes = append(avatar.Sizes, thumb.Size)
+	}
+
+	previous := user.Avatar
+	user.Avatar = avatar
+	if err := s.updateWithEvent(ctx, user); err != nil {
+		deleteAvatarFiles(ctx, s.store, avatar)
+		if errors.Is(err, models.ErrVersionConflict) {
+			logger.Warn(ctx, "SetAvatar: concurrent modification", map[string]any{"user_id": id, "version": user.Version})
+			return nil, err
+		}
+		logger.Error(ctx, "SetAvatar: repo update failed", map[string]any{"user_id": id, "error": err.Error()})
+		return nil, err
+	}
+	deleteAvatarFiles(ctx, s.store, previous)
+
+	userResponse := utilities.ToUserResponse(user)
+	refreshUserCache(ctx, s.userCache, s.userListCache, s.orgRepo, "SetAvatar", userResponse)
+
+	logger.Info(ctx, "UserService.SetAvatar success", map[string]any{"user_id": id, "prefix": avatar.Prefix, "sizes": avatar.Sizes})
+	return userResponse, nil
+}
+
+func (s *userService) RemoveAvatar(ctx context.Context, id uint, versions models.VersionMatch) (*response.UserResponse, error) {
+	logger.Info(ctx, "UserService.RemoveAvatar start", map[string]any{"user_id": id, "versions": versions})
+	user, err := s.getUserForWrite(ctx, "RemoveAvatar", id, versions)
+	if err != nil {
+		return nil, err
+	}
+
+	if user.Avatar != nil {
+		previous := user.Avatar
+		user.Avatar = nil
+		if err := s.updateWithEvent(ctx, user); err != nil {
+			if errors.Is(err, models.ErrVersionConflict) {
+				logger.Warn(ctx, "RemoveAvatar: concurrent modification", map[string]any{"user_id": id, "version": user.Version})
+				return nil, err
+			}
+			logger.Error(ctx, "RemoveAvatar: repo update failed", map[string]any{"user_id": id, "error": err.Error()})
+			return nil, err
+		}
+		deleteAvatarFiles(ctx, s.store, previous)
+	}
+
+	userResponse := utilities.ToUserResponse(user)
+	refreshUserCache(ctx, s.userCache, s.userListCache, s.orgRepo, "RemoveAvatar", userResponse)
+
+	logger.Info(ctx, "UserService.RemoveAvatar success", map[string]any{"user_id": id})
+	return userResponse, nil
+}
+
+func (s *userService) OpenAvatar(ctx context.Context, key string) (io.ReadCloser, *storage.ObjectInfo, error) {
+	if !strings.HasPrefix(key, models.AvatarKeyPrefix) {
+		return nil, nil, models.ErrNotFound
+	}
+	rc, info, err := s.store.Get(ctx, key)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) || errors.Is(err, storage.ErrInvalidKey) {
+			return nil, nil, models.ErrNotFound
+		}
+		logger.Error(ctx, "OpenAvatar: store get failed", map[string]any{"key": key, "error": err.Error()})
+		return nil, nil, err
+	}
+	return rc, info, nil
+}
+
+// getUserForWrite loads a live user and checks that its version is accepted.
+func (s *userService) getUserForWrite(ctx context.Context, op string, id uint, versions models.VersionMatch) (*models.User, error) {
+	user, err := s.userRepo.GetByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			logger.Warn(ctx, op+": not found", map[string]any{"user_id": id})
+			return nil, models.ErrUserNotFound
+		}
+		logger.Error(ctx, op+": repo get failed", map[string]any{"user_id": id, "error": err.Error()})
+		return nil, err
+	}
+	if !versions.Matches(user.Version) {
+		logger.Warn(ctx, op+": version mismatch", map[string]any{"user_id": id, "expected": versions, "current": user.Version})
+		return nil, models.ErrVersionConflict
+	}
+	return user, nil
+}
+
+// deleteAvatarFiles removes an avatar's thumbnails. Failures are only logged:
+// a leftover file is harmless, while failing the request would not be.
+func deleteAvatarFiles(ctx context.Context, store storage.BlobStore, avatar *models.Avatar) {
+	if avatar == nil {
+		return
+	}
+	for _, key := range avatar.Keys() {
+		if err := store.Delete(ctx, key); err != nil {
+			logger.Warn(ctx, "deleteAvatarFiles: store delete failed", map[string]any{"key": key, "error": err.Error()})
+		}
+	}
+}
@@ -0,0 +1,92 @@
+package services
+
+import (
+	"context"
+
+	"go-boilerplate/cache"
+	"go-boilerplate/config"
+	"go-boilerplate/logger"
+	"go-boilerplate/models"
+	"go-boilerplate/models/response"
+	repoInterfaces "go-boilerplate/repository/interfaces"
+	serviceInterfaces "go-boilerplate/services/interfaces"
+	"go-boilerplate/utilities"
+)
+
+// UserCache caches user responses under utilities.UserCacheKey.
+type UserCache = cache.Cache[*response.UserResponse]
+
+// NewUserCache builds the user cache shared by the services that read or
+// change users. Unknown IDs are cached as models.ErrUserNotFound.
+func NewUserCache(redisService serviceInterfaces.RedisService, manager *cache.Manager, cfg *config.Config) *UserCache {
+	return cache.New[*response.UserResponse](redisService, cache.Options{
+		Name:        "user",
+		TTL:         cfg.Cache.UserTTL,
+		Jitter:      cfg.Cache.Jitter,
+		NotFound:    models.ErrUserNotFound,
+		NegativeTTL: cfg.Cache.NegativeTTL,
+		StaleTTL:    cfg.Cache.StaleTTL,
+		LockTTL:     cfg.Cache.LockTTL,
+		LockWait:    cfg.Cache.LockWait,
+		LocalSize:   cfg.Cache.LocalSize,
+		LocalTTL:    cfg.Cache.LocalTTL,
+		Manager:     manager,
+	})
+}
+
+// UserPage is a cached page of the user list.
+type UserPage struct {
+	Users []*response.UserResponse `json:"users"`
+	Total int64                    `json:"total"`
+}
+
+// UserListCache caches pages of the user list under utilities.UserListTag.
+type UserListCache = cache.Cache[*UserPage]
+
+// NewUserListCache builds the cache of user list pages.
+func NewUserListCache(redisService serviceInterfaces.RedisService, manager *cache.Manager, cfg *config.Config) *UserListCache {
+	return cache.New[*UserPage](redisService, cache.Options{
+		Name:      "user_list",
+		TTL:       cfg.Cache.UserListTTL,
+		Jitter:    cfg.Cache.Jitter,
+		StaleTTL:  cfg.Cache.StaleTTL,
+		LockTTL:   cfg.Cache.LockTTL,
+		LockWait:  cfg.Cache.LockWait,
+		LocalSize: cfg.Cache.LocalSize,
+		LocalTTL:  cfg.Cache.LocalTTL,
+		Manager:   manager,
+	})
+}
+
+// refreshUserCache stores a changed user under the current tenant's key and
+// drops the copies cached for the user's other organizations, which would
+// otherwise be served stale.
+func refreshUserCache(ctx context.Context, users *UserCache, lists *UserListCache, orgRepo repoInterfaces.OrganizationRepository, op string, user *response.UserResponse) {
+	invalidateUserCache(ctx, users, lists, orgRepo, op, user.ID)
+	if err := users.Set(ctx, utilities.UserCacheKey(ctx, user.ID), user); err != nil {
+		logger.Warn(ctx, op+": cache set failed", map[string]any{"user_id": user.ID, "error": err.Error()})
+	}
+}
+
+// invalidateUserCache drops a user from the cache in every tenant namespace,
+// along with every cached page of the user list.
+func invalidateUserCache(ctx context.Context, users *UserCache, lists *UserListCache, orgRepo repoInterfaces.OrganizationRepository, op string, userID uint) {
+	invalidateUserLists(ctx, lists, op)
+	tenantIDs, err := orgRepo.ListOrganizationIDs(ctx, userID)
+	if err != nil {
+		logger.Warn(ctx, op+": membership lookup failed", map[string]any{"user_id": userID, "error": err.Error()})
+	}
+	keys := append(utilities.UserCacheKeys(userID, tenantIDs), utilities.UserCacheKey(ctx, userID))
+	for _, key := range keys {
+		if err := users.Delete(ctx, key); err != nil {
+			logger.Warn(ctx, op+": cache delete failed", map[string]any{"user_id": userID, "key": key, "error": err.Error()})
+		}
+	}
+}
+
+// invalidateUserLists drops every cached page of the user list.
+func invalidateUserLists(ctx context.Context, lists *UserListCache, op string) {
+	if err := lists.InvalidateTags(ctx, utilities.UserListTag); err != nil {
+		logger.Warn(ctx, op+": list cache invalidation failed", map[string]any{"error": err.Error()})
+	}
+}
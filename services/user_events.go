@@ -0,0 +1,36 @@
+package services
+
+import (
+	"context"
+
+	"go-boilerplate/logger"
+	"go-boilerplate/models"
+	"go-boilerplate/tenant"
+)
+
+// userEvent builds the outbox event for a change to user. The payload carries
+// the user's version so consumers can tell stale events from current ones.
+func userEvent(ctx context.Context, eventType string, user *models.User) *models.OutboxEvent {
+	payload := map[string]any{
+		"id":      user.ID,
+		"name":    user.Name,
+		"email":   user.Email,
+		"role":    user.Role,
+		"version": user.Version,
+	}
+	if user.PendingEmail != "" {
+		payload["pending_email"] = user.PendingEmail
+	}
+
+	event := &models.OutboxEvent{
+		AggregateType: models.AggregateUser,
+		AggregateID:   user.ID,
+		EventType:     eventType,
+		Payload:       payload,
+		TraceID:       logger.TraceID(ctx),
+	}
+	if tenantID, ok := tenant.ID(ctx); ok {
+		event.TenantID = &tenantID
+	}
+	return event
+}
@@ -0,0 +1,158 @@
+package services
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"go-boilerplate/logger"
+	"go-boilerplate/models"
+	"go-boilerplate/models/request"
+	repoInterfaces "go-boilerplate/repository/interfaces"
+	serviceInterfaces "go-boilerplate/services/interfaces"
+)
+
+type userExportService struct {
+	userRepo repoInterfaces.UserRepository
+}
+
+func NewUserExportService(userRepo repoInterfaces.UserRepository) serviceInterfaces.UserExportService {
+	return &userExportService{userRepo: userRepo}
+}
+
+// exportRowWriter is implemented by each export format.
+type exportRowWriter interface {
+	WriteRow(values []any) error
+	Close() error
+}
+
+func (s *userExportService) ExportUsers(ctx context.Context, w io.Writer, req *request.UserExportRequest) error {
+	columns, err := req.ColumnList()
+	if err != nil {
+		return err
+	}
+	logger.Info(ctx, "UserExportService.ExportUsers start", map[string]any{"format": req.Format, "columns": columns})
+
+	var out exportRowWriter
+	switch req.Format {
+	case request.ExportFormatCSV:
+		out, err = newCSVExportWriter(w, columns)
+	case request.ExportFormatNDJSON:
+		out = newNDJSONExportWriter(w, columns)
+	case request.ExportFormatXLSX:
+		out = newXLSXWriter(w, columns)
+	default:
+		return models.ErrUnsupportedFormat
+	}
+	if err != nil {
+		return err
+	}
+
+	count := 0
+	values := make([]any, len(columns))
+	err = s.userRepo.Stream(ctx, &req.UserFilter, columns, func(user *models.User) error {
+		for i, col := range columns {
+			values[i] = userExportValue(user, col)
+		}
+		count++
+		return out.WriteRow(values)
+	})
+	if err != nil {
+		logger.Error(ctx, "ExportUsers: stream failed", map[string]any{"format": req.Format, "rows": count, "error": err.Error()})
+		return err
+	}
+	if err := out.Close(); err != nil {
+		logger.Error(ctx, "ExportUsers: finalize failed", map[string]any{"format": req.Format, "rows": count, "error": err.Error()})
+		return err
+	}
+
+	logger.Info(ctx, "UserExportService.ExportUsers success", map[string]any{"format": req.Format, "rows": count})
+	return nil
+}
+
+// userExportValue returns the exported value of a column from request.UserExportColumns.
+func userExportValue(user *models.User, column string) any {
+	switch column {
+	case "id":
+		return user.ID
+	case "name":
+		return user.Name
+	case "email":
+		return user.Email
+	case "role":
+		return user.Role
+	case "version":
+		return user.Version
+	case "created_at":
+		return user.CreatedAt.UTC().Format(time.RFC3339)
+	case "updated_at":
+		return user.UpdatedAt.UTC().Format(time.RFC3339)
+	default:
+		return nil
+	}
+}
+
+type csvExportWriter struct {
+	w *csv.Writer
+}
+
+func newCSVExportWriter(w io.Writer, columns []string) (*csvExportWriter, error) {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(columns); err != nil {
+		return nil, err
+	}
+	return &csvExportWriter{w: cw}, nil
+}
+
+func (c *csvExportWriter) WriteRow(values []any) error {
+	record := make([]string, len(values))
+	for i, v := range values {
+		record[i] = fmt.Sprint(v)
+	}
+	return c.w.Write(record)
+}
+
+func (c *csvExportWriter) Close() error {
+	c.w.Flush()
+	return c.w.Error()
+}
+
+// ndjsonExportWriter writes one JSON object per row with keys in column order.
+type ndjsonExportWriter struct {
+	w       *bufio.Writer
+	columns [][]byte
+}
+
+func newNDJSONExportWriter(w io.Writer, columns []string) *ndjsonExportWriter {
+	keys := make([][]byte, len(columns))
+	for i, col := range columns {
+		keys[i], _ = json.Marshal(col)
+	}
+	return &ndjsonExportWriter{w: bufio.NewWriter(w), columns: keys}
+}
+
+func (n *ndjsonExportWriter) WriteRow(values []any) error {
+	n.w.WriteByte('{')
+	for i, v := range values {
+		if i > 0 {
+			n.w.WriteByte(',')
+		}
+		b, err := json.Marshal(v)
+		if err != nil {
+			return err
+		}
+		n.w.Write(n.columns[i])
+		n.w.WriteByte(':')
+		n.w.Write(b)
+	}
+	_, err := n.w.WriteString("}\n")
+	return err
+}
+
+func (n *ndjsonExportWriter) Close() error {
+	return n.w.Flush()
+}
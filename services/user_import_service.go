@@ -0,0 +1,453 @@
+package services
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	"go-boilerplate/config"
+	"go-boilerplate/logger"
+	"go-boilerplate/models"
+	"go-boilerplate/models/request"
+	"go-boilerplate/models/response"
+	repoInterfaces "go-boilerplate/repository/interfaces"
+	serviceInterfaces "go-boilerplate/services/interfaces"
+	"go-boilerplate/tenant"
+	"go-boilerplate/utilities"
+
+	"github.com/redis/go-redis/v9"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+type userImportService struct {
+	userRepo     repoInterfaces.UserRepository
+	outboxRepo   repoInterfaces.OutboxRepository
+	transactor   repoInterfaces.Transactor
+	redisService serviceInterfaces.RedisService
+	lists        *UserListCache
+	cfg          config.ImportConfig
+}
+
+func NewUserImportService(userRepo repoInterfaces.UserRepository, outboxRepo repoInterfaces.OutboxRepository, transactor repoInterfaces.Transactor, redisService serviceInterfaces.RedisService, lists *UserListCache, cfg *config.Config) serviceInterfaces.UserImportService {
+	importCfg := cfg.Import
+	if importCfg.BatchSize < 1 {
+		importCfg.BatchSize = 500
+	}
+	return &userImportService{
+		userRepo:     userRepo,
+		outboxRepo:   outboxRepo,
+		transactor:   transactor,
+		redisService: redisService,
+		lists:        lists,
+		cfg:          importCfg,
+	}
+}
+
+func (s *userImportService) ImportUsers(ctx context.Context, format string, r io.Reader, dryRun bool) (*response.ImportReport, error) {
+	logger.Info(ctx, "UserImportService.ImportUsers start", map[string]any{"format": format, "dry_run": dryRun})
+	report, err := s.runImport(ctx, format, r, dryRun, nil)
+	if err != nil {
+		logger.Error(ctx, "ImportUsers failed", map[string]any{"format": format, "error": err.Error()})
+		return nil, err
+	}
+	logger.Info(ctx, "UserImportService.ImportUsers success", map[string]any{"total_rows": report.TotalRows, "imported": report.Imported, "failed": report.Failed})
+	return report, nil
+}
+
+func (s *userImportService) StartImportJob(ctx context.Context, format string, r io.Reader, dryRun bool) (*response.ImportJobResponse, error) {
+	logger.Info(ctx, "UserImportService.StartImportJob start", map[string]any{"format": format, "dry_run": dryRun})
+	if format != request.ImportFormatCSV && format != request.ImportFormatNDJSON {
+		return nil, models.ErrUnsupportedFormat
+	}
+
+	// The request body is gone once the handler returns, so spool it to disk first.
+	spool, err := os.CreateTemp("", "user-import-*")
+	if err != nil {
+		logger.Error(ctx, "StartImportJob: create spool failed", map[string]any{"error": err.Error()})
+		return nil, err
+	}
+	cleanup := func() {
+		_ = spool.Close()
+		_ = os.Remove(spool.Name())
+	}
+	if _, err := io.Copy(spool, r); err != nil {
+		cleanup()
+		logger.Error(ctx, "StartImportJob: spool upload failed", map[string]any{"error": err.Error()})
+		return nil, err
+	}
+	if _, err := spool.Seek(0, io.SeekStart); err != nil {
+		cleanup()
+		return nil, err
+	}
+
+	now := time.Now()
+	job := &response.ImportJobResponse{
+		ID:        utilities.NewRandomID(),
+		Status:    response.ImportJobQueued,
+		Format:    format,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if err := s.saveJob(ctx, job); err != nil {
+		cleanup()
+		logger.Error(ctx, "StartImportJob: save job failed", map[string]any{"job_id": job.ID, "error": err.Error()})
+		return nil, err
+	}
+
+	go func(job response.ImportJobResponse) {
+		defer cleanup()
+		jobCtx, _, _ := logger.StartSpan(tenant.Detach(ctx))
+		s.runJob(jobCtx, &job, spool, dryRun)
+	}(*job)
+
+	logger.Info(ctx, "UserImportService.StartImportJob success", map[string]any{"job_id": job.ID})
+	return job, nil
+}
+
+func (s *userImportService) GetImportJob(ctx context.Context, jobID string) (*response.ImportJobResponse, error) {
+	logger.Debug(ctx, "UserImportService.GetImportJob start", map[string]any{"job_id": jobID})
+	var job response.ImportJobResponse
+	if err := s.redisService.GetJSON(ctx, utilities.ImportJobCacheKey(ctx, jobID), &job); err != nil {
+		if errors.Is(err, redis.Nil) {
+			return nil, models.ErrNotFound
+		}
+		logger.Error(ctx, "GetImportJob: redis get failed", map[string]any{"job_id": jobID, "error": err.Error()})
+		return nil, err
+	}
+	return &job, nil
+}
+
+func (s *userImportService) runJob(ctx context.Context, job *response.ImportJobResponse, r io.Reader, dryRun bool) {
+	logger.Info(ctx, "UserImportService.runJob start", map[string]any{"job_id": job.ID})
+	job.Status = response.ImportJobRunning
+	s.updateJob(ctx, job)
+
+	report, err := s.runImport(ctx, job.Format, r, dryRun, func(progress *response.ImportReport) {
+		job.Report = progress
+		s.updateJob(ctx, job)
+	})
+	if err != nil {
+		logger.Error(ctx, "runJob: import failed", map[string]any{"job_id": job.ID, "error": err.Error()})
+		job.Status = response.ImportJobFailed
+		job.Error = err.Error()
+		s.updateJob(ctx, job)
+		return
+	}
+
+	job.Status = response.ImportJobCompleted
+	job.Report = report
+	s.updateJob(ctx, job)
+	logger.Info(ctx, "UserImportService.runJob success", map[string]any{"job_id": job.ID, "imported": report.Imported, "failed": report.Failed})
+}
+
+func (s *userImportService) updateJob(ctx context.Context, job *response.ImportJobResponse) {
+	job.UpdatedAt = time.Now()
+	if err := s.saveJob(ctx, job); err != nil {
+		logger.Warn(ctx, "UserImportService: save job failed", map[string]any{"job_id": job.ID, "error": err.Error()})
+	}
+}
+
+func (s *userImportService) saveJob(ctx context.Context, job *response.ImportJobResponse) error {
+	return s.redisService.SetJSON(ctx, utilities.ImportJobCacheKey(ctx, job.ID), job, s.cfg.JobTTL)
+}
+
+// importRow is a parsed input row along with its 1-based position in the data.
+type importRow struct {
+	num int
+	req request.CreateUserRequest
+}
+
+// runImport validates and inserts rows batch by batch. progress, if set, is
+// called after every batch. Per-row problems go into the report; only
+// unreadable input or database failures abort the import.
+func (s *userImportService) runImport(ctx context.Context, format string, r io.Reader, dryRun bool, progress func(*response.ImportReport)) (*response.ImportReport, error) {
+	rows, err := newImportRowReader(format, r)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &response.ImportReport{DryRun: dryRun, Errors: []response.ImportRowError{}}
+	seen := make(map[string]bool)
+	batch := make([]importRow, 0, s.cfg.BatchSize)
+
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		if err := s.importBatch(ctx, batch, dryRun, report); err != nil {
+			return err
+		}
+		batch = batch[:0]
+		if progress != nil {
+			progress(report)
+		}
+		return nil
+	}
+
+	for {
+		row, err := rows.next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		var parseErr *importRowError
+		if errors.As(err, &parseErr) {
+			report.TotalRows++
+			s.addRowError(report, parseErr.num, "", parseErr.Error())
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		report.TotalRows++
+		row.req.Email = strings.TrimSpace(row.req.Email)
+		if err := utilities.ValidateStruct(&row.req); err != nil {
+			s.addRowError(report, row.num, row.req.Email, err.Error())
+			continue
+		}
+		key := strings.ToLower(row.req.Email)
+		if seen[key] {
+			s.addRowError(report, row.num, row.req.Email, "duplicate email in import")
+			continue
+		}
+		seen[key] = true
+
+		batch = append(batch, row)
+		if len(batch) >= s.cfg.BatchSize {
+			if err := flush(); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if err := flush(); err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+// importBatch drops rows whose email already exists in any tenant and inserts the rest in one transaction.
+func (s *userImportService) importBatch(ctx context.Context, batch []importRow, dryRun bool, report *response.ImportReport) error {
+	emails := make([]string, len(batch))
+	for i, row := range batch {
+		emails[i] = row.req.Email
+	}
+	existing, err := s.userRepo.GetExistingEmails(tenant.WithoutScope(ctx), emails)
+	if err != nil {
+		logger.Error(ctx, "UserImportService: email lookup failed", map[string]any{"error": err.Error()})
+		return err
+	}
+
+	pending := make([]importRow, 0, len(batch))
+	for _, row := range batch {
+		if existing[row.req.Email] {
+			s.addRowError(report, row.num, row.req.Email, models.ErrEmailTaken.Error())
+			continue
+		}
+		pending = append(pending, row)
+	}
+
+	if dryRun {
+		report.Imported += len(pending)
+		return nil
+	}
+
+	users, err := hashImportRows(pending)
+	if err != nil {
+		logger.Error(ctx, "UserImportService: password hash failed", map[string]any{"error": err.Error()})
+		return err
+	}
+
+	// Each imported user gets a UserCreated event, stored with the batch.
+	err = s.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := s.userRepo.CreateBatch(ctx, users); err != nil {
+			return err
+		}
+		events := make([]*models.OutboxEvent, len(users))
+		for i, user := range users {
+			events[i] = userEvent(ctx, models.EventUserCreated, user)
+		}
+		return s.outboxRepo.Add(ctx, events...)
+	})
+	if err != nil {
+		// The whole transaction was rolled back, so every row in it failed.
+		msg := err.Error()
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			msg = models.ErrEmailTaken.Error()
+		}
+		logger.Warn(ctx, "UserImportService: batch insert failed", map[string]any{"rows": len(pending), "error": err.Error()})
+		for _, row := range pending {
+			s.addRowError(report, row.num, row.req.Email, "batch rejected: "+msg)
+		}
+		return nil
+	}
+
+	invalidateUserLists(ctx, s.lists, "UserImportService")
+	report.Imported += len(users)
+	logger.Debug(ctx, "UserImportService: batch imported", map[string]any{"rows": len(users)})
+	return nil
+}
+
+func (s *userImportService) addRowError(report *response.ImportReport, row int, email, msg string) {
+	report.Failed++
+	if s.cfg.MaxReportedErrors > 0 && len(report.Errors) >= s.cfg.MaxReportedErrors {
+		report.ErrorsTruncated = true
+		return
+	}
+	report.Errors = append(report.Errors, response.ImportRowError{Row: row, Email: email, Error: msg})
+}
+
+// hashImportRows builds users from rows, hashing passwords in parallel since
+// bcrypt dominates import time.
+func hashImportRows(rows []importRow) ([]*models.User, error) {
+	users := make([]*models.User, len(rows))
+	errs := make([]error, len(rows))
+	sem := make(chan struct{}, runtime.GOMAXPROCS(0))
+	var wg sync.WaitGroup
+	for i, row := range rows {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int, row importRow) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			hashed, err := bcrypt.GenerateFromPassword([]byte(row.req.Password), bcrypt.DefaultCost)
+			if err != nil {
+				errs[i] = err
+				return
+			}
+			users[i] = &models.User{
+				Name:     row.req.Name,
+				Email:    row.req.Email,
+				Password: string(hashed),
+			}
+		}(i, row)
+	}
+	wg.Wait()
+	return users, errors.Join(errs...)
+}
+
+// importRowError reports a row that could not be parsed; the import continues past it.
+type importRowError struct {
+	num int
+	err error
+}
+
+func (e *importRowError) Error() string { return e.err.Error() }
+
+type importRowReader interface {
+	// next returns the next row, an *importRowError for an unparsable row, or io.EOF.
+	next() (importRow, error)
+}
+
+func newImportRowReader(format string, r io.Reader) (importRowReader, error) {
+	switch format {
+	case request.ImportFormatCSV:
+		return newCSVRowReader(r)
+	case request.ImportFormatNDJSON:
+		return &ndjsonRowReader{r: bufio.NewReader(r)}, nil
+	default:
+		return nil, models.ErrUnsupportedFormat
+	}
+}
+
+// csvRowReader reads CSV with a header row naming the name, email and password columns.
+type csvRowReader struct {
+	r       *csv.Reader
+	columns map[string]int
+	num     int
+}
+
+func newCSVRowReader(r io.Reader) (*csvRowReader, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	cr.TrimLeadingSpace = true
+	cr.ReuseRecord = true
+
+	header, err := cr.Read()
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, errors.New("csv: missing header row")
+		}
+		return nil, fmt.Errorf("csv: invalid header row: %w", err)
+	}
+
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))
+		columns[name] = i
+	}
+	for _, required := range []string{"name", "email", "password"} {
+		if _, ok := columns[required]; !ok {
+			return nil, fmt.Errorf("csv: header is missing column %q", required)
+		}
+	}
+	return &csvRowReader{r: cr, columns: columns}, nil
+}
+
+func (c *csvRowReader) next() (importRow, error) {
+	record, err := c.r.Read()
+	if errors.Is(err, io.EOF) {
+		return importRow{}, io.EOF
+	}
+	c.num++
+	var parseErr *csv.ParseError
+	if errors.As(err, &parseErr) {
+		return importRow{}, &importRowError{num: c.num, err: err}
+	}
+	if err != nil {
+		return importRow{}, err
+	}
+
+	field := func(name string) string {
+		if i := c.columns[name]; i < len(record) {
+			return strings.TrimSpace(record[i])
+		}
+		return ""
+	}
+	return importRow{
+		num: c.num,
+		req: request.CreateUserRequest{
+			Name:     field("name"),
+			Email:    field("email"),
+			Password: field("password"),
+		},
+	}, nil
+}
+
+// ndjsonRowReader reads one JSON object per line; blank lines are skipped.
+type ndjsonRowReader struct {
+	r   *bufio.Reader
+	num int
+}
+
+func (n *ndjsonRowReader) next() (importRow, error) {
+	for {
+		line, err := n.r.ReadBytes('\n')
+		if len(bytes.TrimSpace(line)) == 0 {
+			if err != nil {
+				return importRow{}, err
+			}
+			continue
+		}
+		if err != nil && !errors.Is(err, io.EOF) {
+			return importRow{}, err
+		}
+
+		n.num++
+		row := importRow{num: n.num}
+		if jsonErr := json.Unmarshal(line, &row.req); jsonErr != nil {
+			return importRow{}, &importRowError{num: n.num, err: fmt.Errorf("invalid JSON: %w", jsonErr)}
+		}
+		return row, nil
+	}
+}
@@ -3,43 +3,69 @@ package services
 import (
 	"context"
 	"errors"
+	"fmt"
 	"math"
+	"net/url"
+	"strconv"
 	"time"
 
+	"go-boilerplate/config"
 	"go-boilerplate/logger"
 	"go-boilerplate/models"
 	"go-boilerplate/models/request"
 	"go-boilerplate/models/response"
 	repoInterfaces "go-boilerplate/repository/interfaces"
 	serviceInterfaces "go-boilerplate/services/interfaces"
+	"go-boilerplate/storage"
+	"go-boilerplate/tenant"
 	"go-boilerplate/utilities"
 
 	"golang.org/x/crypto/bcrypt"
 	"gorm.io/gorm"
 )
 
+// emailChangePurpose scopes action tokens used in email change confirmation links.
+const emailChangePurpose = "email_change"
+
 type userService struct {
-	userRepo     repoInterfaces.UserRepository
-	authService  serviceInterfaces.AuthService
-	redisService serviceInterfaces.RedisService
+	userRepo       repoInterfaces.UserRepository
+	orgRepo        repoInterfaces.OrganizationRepository
+	loginEventRepo repoInterfaces.LoginEventRepository
+	outboxRepo     repoInterfaces.OutboxRepository
+	transactor     repoInterfaces.Transactor
+	authService    serviceInterfaces.AuthService
+	redisService   serviceInterfaces.RedisService
+	userCache      *UserCache
+	userListCache  *UserListCache
+	mailService    serviceInterfaces.MailService
+	store          storage.BlobStore
+	cfg            *config.Config
 }
 
-func NewUserService(userRepo repoInterfaces.UserRepository, authService serviceInterfaces.AuthService, redisService serviceInterfaces.RedisService) serviceInterfaces.UserService {
+func NewUserService(userRepo repoInterfaces.UserRepository, orgRepo repoInterfaces.OrganizationRepository, loginEventRepo repoInterfaces.LoginEventRepository, outboxRepo repoInterfaces.OutboxRepository, transactor repoInterfaces.Transactor, authService serviceInterfaces.AuthService, redisService serviceInterfaces.RedisService, userCache *UserCache, userListCache *UserListCache, mailService serviceInterfaces.MailService, store storage.BlobStore, cfg *config.Config) serviceInterfaces.UserService {
 	return &userService{
-		userRepo:     userRepo,
-		authService:  authService,
-		redisService: redisService,
+		userRepo:       userRepo,
+		orgRepo:        orgRepo,
+		loginEventRepo: loginEventRepo,
+		outboxRepo:     outboxRepo,
+		transactor:     transactor,
+		authService:    authService,
+		redisService:   redisService,
+		userCache:      userCache,
+		userListCache:  userListCache,
+		mailService:    mailService,
+		store:          store,
+		cfg:            cfg,
 	}
 }
 
-func (s *userService) CreateUser(req *request.CreateUserRequest) (*response.UserResponse, error) {
-	ctx := context.Background()
+func (s *userService) CreateUser(ctx context.Context, req *request.CreateUserRequest) (*response.UserResponse, error) {
 	logger.Info(ctx, "UserService.CreateUser start", map[string]any{"email": req.Email})
-	// Check if user already exists
-	_, err := s.userRepo.GetByEmail(req.Email)
+	// Check if user already exists. Emails are unique across all tenants.
+	_, err := s.userRepo.GetByEmail(tenant.WithoutScope(ctx), req.Email)
 	if err == nil {
 		logger.Warn(ctx, "CreateUser: email already exists", map[string]any{"email": req.Email})
-		return nil, errors.New("user with this email already exists")
+		return nil, models.ErrEmailTaken
 	}
 
 	// Hash password
@@ -55,59 +81,80 @@ func (s *userService) CreateUser(req *request.CreateUserRequest) (*response.User
 		Password: string(hashedPassword),
 	}
 
-	if err := s.userRepo.Create(user); err != nil {
+	// The UserCreated event is stored in the same transaction as the user.
+	err = s.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := s.userRepo.Create(ctx, user); err != nil {
+			return err
+		}
+		return s.outboxRepo.Add(ctx, userEvent(ctx, models.EventUserCreated, user))
+	})
+	if err != nil {
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			logger.Warn(ctx, "CreateUser: email already exists", map[string]any{"email": req.Email})
+			return nil, models.ErrEmailTaken
+		}
 		logger.Error(ctx, "CreateUser: user repo create failed", map[string]any{"email": req.Email, "error": err.Error()})
 		return nil, err
 	}
 
 	userResponse := utilities.ToUserResponse(user)
 
-	// Cache the user
-	if err := s.redisService.SetJSON(ctx, utilities.UserCacheKey(user.ID), userResponse, 30*time.Minute); err != nil {
-		logger.Warn(ctx, "CreateUser: cache set failed", map[string]any{"user_id": user.ID, "error": err.Error()})
-	}
+	// Cache the user, replacing a negative entry left by earlier lookups,
+	// once a caller's transaction has committed it.
+	s.transactor.AfterCommit(ctx, func(ctx context.Context) {
+		if err := s.userCache.Set(ctx, utilities.UserCacheKey(ctx, user.ID), userResponse); err != nil {
+			logger.Warn(ctx, "CreateUser: cache set failed", map[string]any{"user_id": user.ID, "error": err.Error()})
+		}
+		invalidateUserLists(ctx, s.userListCache, "CreateUser")
+	})
 
 	logger.Info(ctx, "UserService.CreateUser success", map[string]any{"user_id": user.ID, "email": req.Email})
 	return userResponse, nil
 }
 
-func (s *userService) GetUserByID(id uint) (*response.UserResponse, error) {
-	ctx := context.Background()
+func (s *userService) GetUserByID(ctx context.Context, id uint) (*response.UserResponse, error) {
 	logger.Debug(ctx, "UserService.GetUserByID start", map[string]any{"user_id": id})
-	// Try to get from cache first
-	var cachedUser response.UserResponse
-	if err := s.redisService.GetJSON(ctx, utilities.UserCacheKey(id), &cachedUser); err == nil {
-		logger.Info(ctx, "GetUserByID: cache hit", map[string]any{"user_id": id})
-		return &cachedUser, nil
-	} else {
-		logger.Debug(ctx, "GetUserByID: cache miss", map[string]any{"user_id": id, "error": err.Error()})
-	}
-
-	// If not in cache, get from database
-	user, err := s.userRepo.GetByID(id)
+	userResponse, err := s.userCache.Get(ctx, utilities.UserCacheKey(ctx, id), func(ctx context.Context) (*response.UserResponse, error) {
+		user, err := s.userRepo.GetByID(ctx, id)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, models.ErrUserNotFound
+			}
+			return nil, err
+		}
+		return utilities.ToUserResponse(user), nil
+	})
 	if err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
+		if errors.Is(err, models.ErrUserNotFound) {
 			logger.Warn(ctx, "GetUserByID: not found", map[string]any{"user_id": id})
-			return nil, errors.New("user not found")
+			return nil, err
 		}
 		logger.Error(ctx, "GetUserByID: repo error", map[string]any{"user_id": id, "error": err.Error()})
 		return nil, err
 	}
 
-	userResponse := utilities.ToUserResponse(user)
+	logger.Info(ctx, "UserService.GetUserByID success", map[string]any{"user_id": id})
+	return userResponse, nil
+}
 
-	// Cache the user for future requests
-	if err := s.redisService.SetJSON(ctx, utilities.UserCacheKey(user.ID), userResponse, 30*time.Minute); err != nil {
-		logger.Warn(ctx, "GetUserByID: cache set failed", map[string]any{"user_id": user.ID, "error": err.Error()})
+func (s *userService) GetUserRole(ctx context.Context, id uint) (string, error) {
+	logger.Debug(ctx, "UserService.GetUserRole start", map[string]any{"user_id": id})
+	user, err := s.userRepo.GetByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			logger.Warn(ctx, "GetUserRole: not found", map[string]any{"user_id": id})
+			return "", models.ErrUserNotFound
+		}
+		logger.Error(ctx, "GetUserRole: repo error", map[string]any{"user_id": id, "error": err.Error()})
+		return "", err
 	}
 
-	logger.Info(ctx, "UserService.GetUserByID success", map[string]any{"user_id": id})
-	return userResponse, nil
+	logger.Debug(ctx, "UserService.GetUserRole success", map[string]any{"user_id": id, "role": user.Role})
+	return user.Role, nil
 }
 
-func (s *userService) GetUsers(page, perPage int) (*response.PaginationResponse, error) {
-	ctx := context.Background()
-	logger.Debug(ctx, "UserService.GetUsers start", map[string]any{"page": page, "per_page": perPage})
+func (s *userService) GetUsers(ctx context.Context, page, perPage int, filter *request.UserFilter) (*response.PaginationResponse, error) {
+	logger.Debug(ctx, "UserService.GetUsers start", map[string]any{"page": page, "per_page": perPage, "filter": filter})
 	if page < 1 {
 		page = 1
 	}
@@ -115,105 +162,348 @@ func (s *userService) GetUsers(page, perPage int) (*response.PaginationResponse,
 		perPage = 10
 	}
 
-	offset := (page - 1) * perPage
-	users, total, err := s.userRepo.GetAll(offset, perPage)
+	key := utilities.UserListCacheKey(ctx, page, perPage, filter)
+	result, err := s.userListCache.GetTagged(ctx, key, []string{utilities.UserListTag}, func(ctx context.Context) (*UserPage, error) {
+		offset := (page - 1) * perPage
+		users, total, err := s.userRepo.GetAll(ctx, offset, perPage, filter)
+		if err != nil {
+			return nil, err
+		}
+		userResponses := make([]*response.UserResponse, len(users))
+		for i, user := range users {
+			userResponses[i] = utilities.ToUserResponse(user)
+		}
+		return &UserPage{Users: userResponses, Total: total}, nil
+	})
 	if err != nil {
 		logger.Error(ctx, "GetUsers: repo error", map[string]any{"page": page, "per_page": perPage, "error": err.Error()})
 		return nil, err
 	}
 
-	userResponses := make([]*response.UserResponse, len(users))
-	for i, user := range users {
-		userResponses[i] = utilities.ToUserResponse(user)
-	}
-
-	totalPages := int(math.Ceil(float64(total) / float64(perPage)))
+	totalPages := int(math.Ceil(float64(result.Total) / float64(perPage)))
 
-	logger.Info(ctx, "UserService.GetUsers success", map[string]any{"count": len(userResponses), "total": total, "page": page, "per_page": perPage, "total_pages": totalPages})
+	logger.Info(ctx, "UserService.GetUsers success", map[string]any{"count": len(result.Users), "total": result.Total, "page": page, "per_page": perPage, "total_pages": totalPages})
 	return &response.PaginationResponse{
-		Data:       userResponses,
+		Data:       result.Users,
 		Page:       page,
 		PerPage:    perPage,
-		Total:      total,
+		Total:      result.Total,
 		TotalPages: totalPages,
 	}, nil
 }
 
-func (s *userService) UpdateUser(id uint, req *request.UpdateUserRequest) (*response.UserResponse, error) {
-	ctx := context.Background()
-	logger.Info(ctx, "UserService.UpdateUser start", map[string]any{"user_id": id})
-	user, err := s.userRepo.GetByID(id)
+func (s *userService) UpdateUser(ctx context.Context, id uint, versions models.VersionMatch, req *request.UpdateUserRequest) (*response.UserResponse, error) {
+	logger.Info(ctx, "UserService.UpdateUser start", map[string]any{"user_id": id, "versions": versions})
+	user, err := s.userRepo.GetByID(ctx, id)
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			logger.Warn(ctx, "UpdateUser: not found", map[string]any{"user_id": id})
-			return nil, errors.New("user not found")
+			return nil, models.ErrUserNotFound
 		}
 		logger.Error(ctx, "UpdateUser: repo get failed", map[string]any{"user_id": id, "error": err.Error()})
 		return nil, err
 	}
 
+	if !versions.Matches(user.Version) {
+		logger.Warn(ctx, "UpdateUser: version mismatch", map[string]any{"user_id": id, "expected": versions, "current": user.Version})
+		return nil, models.ErrVersionConflict
+	}
+
 	if req.Name != "" {
 		user.Name = req.Name
 	}
-	if req.Email != "" {
-		user.Email = req.Email
+
+	// A new email is only stored as pending until the owner confirms it.
+	emailChangeRequested := false
+	if req.Email != "" && req.Email != user.Email {
+		if existing, err := s.userRepo.GetByEmail(tenant.WithoutScope(ctx), req.Email); err == nil && existing.ID != user.ID {
+			logger.Warn(ctx, "UpdateUser: email already in use", map[string]any{"user_id": id})
+			return nil, models.ErrEmailTaken
+		} else if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			logger.Error(ctx, "UpdateUser: repo email lookup failed", map[string]any{"user_id": id, "error": err.Error()})
+			return nil, err
+		}
+		user.PendingEmail = req.Email
+		emailChangeRequested = true
+	} else if req.Email != "" && req.Email == user.Email {
+		// Re-submitting the current address cancels any pending change.
+		user.PendingEmail = ""
 	}
 
-	if err := s.userRepo.Update(user); err != nil {
+	if err := s.updateWithEvent(ctx, user); err != nil {
+		if errors.Is(err, models.ErrVersionConflict) {
+			logger.Warn(ctx, "UpdateUser: concurrent modification", map[string]any{"user_id": id, "version": user.Version})
+			return nil, err
+		}
 		logger.Error(ctx, "UpdateUser: repo update failed", map[string]any{"user_id": id, "error": err.Error()})
 		return nil, err
 	}
 
 	userResponse := utilities.ToUserResponse(user)
 
-	// Update cache
-	if err := s.redisService.SetJSON(ctx, utilities.UserCacheKey(user.ID), userResponse, 30*time.Minute); err != nil {
-		logger.Warn(ctx, "UpdateUser: cache set failed", map[string]any{"user_id": user.ID, "error": err.Error()})
-	}
+	// Update cache and send mails once the change is committed, which is
+	// later if the caller runs this in its own transaction.
+	s.transactor.AfterCommit(ctx, func(ctx context.Context) {
+		refreshUserCache(ctx, s.userCache, s.userListCache, s.orgRepo, "UpdateUser", userResponse)
+		if emailChangeRequested {
+			if err := s.sendEmailChangeMails(ctx, user); err != nil {
+				logger.Warn(ctx, "UpdateUser: email change mails failed", map[string]any{"user_id": user.ID, "error": err.Error()})
+			}
+		}
+	})
 
 	logger.Info(ctx, "UserService.UpdateUser success", map[string]any{"user_id": id})
 	return userResponse, nil
 }
 
-func (s *userService) DeleteUser(id uint) error {
-	ctx := context.Background()
-	logger.Info(ctx, "UserService.DeleteUser start", map[string]any{"user_id": id})
-	_, err := s.userRepo.GetByID(id)
+func (s *userService) DeleteUser(ctx context.Context, id uint, versions models.VersionMatch) error {
+	logger.Info(ctx, "UserService.DeleteUser start", map[string]any{"user_id": id, "versions": versions})
+	user, err := s.userRepo.GetByID(ctx, id)
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			logger.Warn(ctx, "DeleteUser: not found", map[string]any{"user_id": id})
-			return errors.New("user not found")
+			return models.ErrUserNotFound
 		}
 		logger.Error(ctx, "DeleteUser: repo get failed", map[string]any{"user_id": id, "error": err.Error()})
 		return err
 	}
 
-	// Delete from database
-	if err := s.userRepo.Delete(id); err != nil {
+	if !versions.Matches(user.Version) {
+		logger.Warn(ctx, "DeleteUser: version mismatch", map[string]any{"user_id": id, "expected": versions, "current": user.Version})
+		return models.ErrVersionConflict
+	}
+
+	// Delete from database, with the UserDeleted event. The delete only
+	// succeeds if the user is still at the version that was checked.
+	err = s.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := s.userRepo.Delete(ctx, id, user.Version); err != nil {
+			return err
+		}
+		return s.outboxRepo.Add(ctx, userEvent(ctx, models.EventUserDeleted, user))
+	})
+	if err != nil {
+		if errors.Is(err, models.ErrVersionConflict) {
+			logger.Warn(ctx, "DeleteUser: concurrent modification", map[string]any{"user_id": id, "version": user.Version})
+			return err
+		}
 		logger.Error(ctx, "DeleteUser: repo delete failed", map[string]any{"user_id": id, "error": err.Error()})
 		return err
 	}
 
 	// Remove from cache
-	if err := s.redisService.Delete(ctx, utilities.UserCacheKey(id)); err != nil {
-		logger.Warn(ctx, "DeleteUser: cache delete failed", map[string]any{"user_id": id, "error": err.Error()})
-	}
+	s.transactor.AfterCommit(ctx, func(ctx context.Context) {
+		invalidateUserCache(ctx, s.userCache, s.userListCache, s.orgRepo, "DeleteUser", id)
+	})
 
 	logger.Info(ctx, "UserService.DeleteUser success", map[string]any{"user_id": id})
 	return nil
 }
 
-func (s *userService) Login(req *request.LoginRequest) (*response.LoginResponse, error) {
-	ctx := context.Background()
+func (s *userService) ConfirmEmailChange(ctx context.Context, token string) (*response.UserResponse, error) {
+	logger.Info(ctx, "UserService.ConfirmEmailChange start", nil)
+	// The signed link is the authorization; it is opened outside of any tenant.
+	ctx = tenant.WithoutScope(ctx)
+	id, data, err := parseEmailChangeToken(ctx, s.authService, token)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := s.userRepo.GetByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			logger.Warn(ctx, "ConfirmEmailChange: not found", map[string]any{"user_id": id})
+			return nil, models.ErrUserNotFound
+		}
+		logger.Error(ctx, "ConfirmEmailChange: repo get failed", map[string]any{"user_id": id, "error": err.Error()})
+		return nil, err
+	}
+
+	// Only the link for the latest requested address is honoured.
+	if user.PendingEmail == "" || user.PendingEmail != data["email"] {
+		logger.Warn(ctx, "ConfirmEmailChange: token superseded", map[string]any{"user_id": id})
+		return nil, models.ErrInvalidToken
+	}
+
+	if existing, err := s.userRepo.GetByEmail(ctx, user.PendingEmail); err == nil && existing.ID != user.ID {
+		logger.Warn(ctx, "ConfirmEmailChange: email already in use", map[string]any{"user_id": id})
+		return nil, models.ErrEmailTaken
+	}
+
+	user.Email = user.PendingEmail
+	user.PendingEmail = ""
+	if err := s.updateWithEvent(ctx, user); err != nil {
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			logger.Warn(ctx, "ConfirmEmailChange: email already in use", map[string]any{"user_id": id})
+			return nil, models.ErrEmailTaken
+		}
+		logger.Error(ctx, "ConfirmEmailChange: repo update failed", map[string]any{"user_id": id, "error": err.Error()})
+		return nil, err
+	}
+
+	userResponse := utilities.ToUserResponse(user)
+
+	s.transactor.AfterCommit(ctx, func(ctx context.Context) {
+		refreshUserCache(ctx, s.userCache, s.userListCache, s.orgRepo, "ConfirmEmailChange", userResponse)
+	})
+
+	logger.Info(ctx, "UserService.ConfirmEmailChange success", map[string]any{"user_id": id})
+	return userResponse, nil
+}
+
+// parseEmailChangeToken verifies an email change link and returns the user it
+// is for along with its data.
+func parseEmailChangeToken(ctx context.Context, authService serviceInterfaces.AuthService, token string) (uint, map[string]string, error) {
+	data, err := authService.ParseActionToken(emailChangePurpose, token)
+	if err != nil {
+		return 0, nil, models.ErrInvalidToken
+	}
+	id, err := strconv.ParseUint(data["user_id"], 10, 32)
+	if err != nil {
+		logger.Warn(ctx, "ConfirmEmailChange: malformed token data", nil)
+		return 0, nil, models.ErrInvalidToken
+	}
+	return uint(id), data, nil
+}
+
+// updateWithEvent saves user and stores a UserUpdated event in the same transaction.
+func (s *userService) updateWithEvent(ctx context.Context, user *models.User) error {
+	return s.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := s.userRepo.Update(ctx, user); err != nil {
+			return err
+		}
+		return s.outboxRepo.Add(ctx, userEvent(ctx, models.EventUserUpdated, user))
+	})
+}
+
+// sendEmailChangeMails sends the confirmation link to the pending address and a
+// notice to the current one.
+func (s *userService) sendEmailChangeMails(ctx context.Context, user *models.User) error {
+	token, err := s.authService.GenerateActionToken(emailChangePurpose, map[string]string{
+		"user_id": strconv.FormatUint(uint64(user.ID), 10),
+		"email":   user.PendingEmail,
+	}, s.cfg.JWT.EmailChangeTTL)
+	if err != nil {
+		return err
+	}
+
+	link := fmt.Sprintf("%s/api/v1/auth/email/confirm?token=%s", s.cfg.BaseURL, url.QueryEscape(token))
+	confirmBody := fmt.Sprintf("Hi %s,\n\nPlease confirm your new email address by opening the link below. It expires in %s.\n\n%s\n\nIf you did not request this change, ignore this email.\n",
+		user.Name, s.cfg.JWT.EmailChangeTTL, link)
+	if err := s.mailService.Send(ctx, user.PendingEmail, "Confirm your new email address", confirmBody); err != nil {
+		return err
+	}
+
+	noticeBody := fmt.Sprintf("Hi %s,\n\nA request was made to change the email address on your account to %s. The change takes effect once the new address is confirmed.\n\nIf you did not request this change, please contact support.\n",
+		user.Name, user.PendingEmail)
+	return s.mailService.Send(ctx, user.Email, "Email change requested", noticeBody)
+}
+
+func (s *userService) GetDeletedUsers(ctx context.Context, page, perPage int) (*response.PaginationResponse, error) {
+	logger.Debug(ctx, "UserService.GetDeletedUsers start", map[string]any{"page": page, "per_page": perPage})
+	page, perPage, offset := utilities.CalculateOffset(page, perPage)
+
+	users, total, err := s.userRepo.GetDeleted(ctx, offset, perPage)
+	if err != nil {
+		logger.Error(ctx, "GetDeletedUsers: repo error", map[string]any{"page": page, "per_page": perPage, "error": err.Error()})
+		return nil, err
+	}
+
+	userResponses := make([]*response.UserResponse, len(users))
+	for i, user := range users {
+		userResponses[i] = utilities.ToUserResponse(user)
+	}
+
+	totalPages := utilities.TotalPages(int(total), perPage)
+
+	logger.Info(ctx, "UserService.GetDeletedUsers success", map[string]any{"count": len(userResponses), "total": total, "page": page, "per_page": perPage})
+	return &response.PaginationResponse{
+		Data:       userResponses,
+		Page:       page,
+		PerPage:    perPage,
+		Total:      total,
+		TotalPages: totalPages,
+	}, nil
+}
+
+func (s *userService) RestoreUser(ctx context.Context, id uint) (*response.UserResponse, error) {
+	logger.Info(ctx, "UserService.RestoreUser start", map[string]any{"user_id": id})
+	var user *models.User
+	err := s.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := s.userRepo.Restore(ctx, id); err != nil {
+			return err
+		}
+		var err error
+		if user, err = s.userRepo.GetByID(ctx, id); err != nil {
+			return err
+		}
+		return s.outboxRepo.Add(ctx, userEvent(ctx, models.EventUserUpdated, user))
+	})
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			logger.Warn(ctx, "RestoreUser: not in trash", map[string]any{"user_id": id})
+			return nil, models.ErrUserNotFound
+		}
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			logger.Warn(ctx, "RestoreUser: email reused by a live user", map[string]any{"user_id": id})
+			return nil, models.ErrEmailTaken
+		}
+		logger.Error(ctx, "RestoreUser: repo restore failed", map[string]any{"user_id": id, "error": err.Error()})
+		return nil, err
+	}
+
+	userResponse := utilities.ToUserResponse(user)
+
+	s.transactor.AfterCommit(ctx, func(ctx context.Context) {
+		refreshUserCache(ctx, s.userCache, s.userListCache, s.orgRepo, "RestoreUser", userResponse)
+	})
+
+	logger.Info(ctx, "UserService.RestoreUser success", map[string]any{"user_id": id})
+	return userResponse, nil
+}
+
+func (s *userService) PurgeDeletedUsers(ctx context.Context, retention time.Duration) (int64, error) {
+	cutoff := time.Now().Add(-retention)
+	logger.Debug(ctx, "UserService.PurgeDeletedUsers start", map[string]any{"cutoff": cutoff})
+	users, err := s.userRepo.PurgeDeleted(ctx, cutoff)
+	if err != nil {
+		logger.Error(ctx, "PurgeDeletedUsers: repo purge failed", map[string]any{"cutoff": cutoff, "error": err.Error()})
+		return 0, err
+	}
+	// Avatar files are kept while a user is in the trash so a restore brings them back.
+	for _, user := range users {
+		deleteAvatarFiles(ctx, s.store, user.Avatar)
+	}
+	purged := int64(len(users))
+	if purged > 0 {
+		logger.Info(ctx, "UserService.PurgeDeletedUsers success", map[string]any{"purged": purged, "cutoff": cutoff})
+	}
+	return purged, nil
+}
+
+func (s *userService) Login(ctx context.Context, req *request.LoginRequest, client models.ClientInfo) (*response.LoginResponse, error) {
 	logger.Info(ctx, "UserService.Login start", map[string]any{"email": req.Email})
-	user, err := s.userRepo.GetByEmail(req.Email)
+	event := &models.LoginEvent{
+		Email:      req.Email,
+		AuthMethod: models.AuthMethodPassword,
+		IP:         client.IP,
+		UserAgent:  client.UserAgent,
+		TraceID:    logger.TraceID(ctx),
+		CreatedAt:  time.Now(),
+	}
+
+	user, err := s.userRepo.GetByEmail(tenant.WithoutScope(ctx), req.Email)
 	if err != nil {
 		logger.Warn(ctx, "Login: user not found", map[string]any{"email": req.Email})
+		event.FailureReason = models.LoginFailureUnknownEmail
+		s.recordLogin(ctx, event)
 		return nil, errors.New("invalid email or password")
 	}
+	event.UserID = &user.ID
 
 	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(req.Password)); err != nil {
 		logger.Warn(ctx, "Login: password mismatch", map[string]any{"email": req.Email})
+		event.FailureReason = models.LoginFailureInvalidPassword
+		s.recordLogin(ctx, event)
 		return nil, errors.New("invalid email or password")
 	}
 
@@ -225,7 +515,18 @@ func (s *userService) Login(req *request.LoginRequest) (*response.LoginResponse,
 
 	// Cache user session
 	if err := s.redisService.CacheUserSession(ctx, user.ID, token, 24*time.Hour); err != nil {
-		logger.Warn(ctx, "Login: cache session failed", map[string]any{"user_id": user.ID, "error": err.Error()})
+		logger.Error(ctx, "Login: cache session failed", map[string]any{"user_id": user.ID, "error": err.Error()})
+		return nil, err
+	}
+
+	event.Success = true
+	s.recordLogin(ctx, event)
+	now := event.CreatedAt
+	if err := s.userRepo.SetLastLogin(tenant.WithoutScope(ctx), user.ID, now); err != nil {
+		logger.Warn(ctx, "Login: last login update failed", map[string]any{"user_id": user.ID, "error": err.Error()})
+	} else {
+		user.LastLoginAt = &now
+		invalidateUserCache(ctx, s.userCache, s.userListCache, s.orgRepo, "Login", user.ID)
 	}
 
 	logger.Info(ctx, "UserService.Login success", map[string]any{"user_id": user.ID})
@@ -235,8 +536,64 @@ func (s *userService) Login(req *request.LoginRequest) (*response.LoginResponse,
 	}, nil
 }
 
-func (s *userService) Logout(userID uint) error {
-	ctx := context.Background()
+// recordLogin stores a login event. A failure is logged and does not affect the login.
+func (s *userService) recordLogin(ctx context.Context, event *models.LoginEvent) {
+	if err := s.loginEventRepo.Create(tenant.WithoutScope(ctx), event); err != nil {
+		logger.Warn(ctx, "Login: login event create failed", map[string]any{"email": event.Email, "success": event.Success, "error": err.Error()})
+	}
+}
+
+func (s *userService) GetLoginHistory(ctx context.Context, userID uint, page, perPage int) (*response.PaginationResponse, error) {
+	logger.Debug(ctx, "UserService.GetLoginHistory start", map[string]any{"user_id": userID, "page": page, "per_page": perPage})
+	page, perPage, offset := utilities.CalculateOffset(page, perPage)
+
+	events, total, err := s.loginEventRepo.ListByUser(tenant.WithoutScope(ctx), userID, offset, perPage)
+	if err != nil {
+		logger.Error(ctx, "GetLoginHistory: repo error", map[string]any{"user_id": userID, "error": err.Error()})
+		return nil, err
+	}
+
+	return &response.PaginationResponse{
+		Data:       events,
+		Page:       page,
+		PerPage:    perPage,
+		Total:      total,
+		TotalPages: utilities.TotalPages(int(total), perPage),
+	}, nil
+}
+
+// TouchLastSeen writes last_seen_at only when the user's marker key in Redis is
+// absent, and sets the key for LAST_SEEN_INTERVAL, so a busy user costs one
+// database write per interval. The cached user is left as is; its last_seen_at
+// may lag behind by up to the cache TTL.
+func (s *userService) TouchLastSeen(ctx context.Context, userID uint) {
+	now := time.Now()
+	if interval := s.cfg.Activity.LastSeenInterval; interval > 0 {
+		first, err := s.redisService.SetNX(ctx, utilities.LastSeenKey(userID), strconv.FormatInt(now.Unix(), 10), interval)
+		if err != nil || !first {
+			return
+		}
+	}
+	if err := s.userRepo.SetLastSeen(tenant.WithoutScope(ctx), userID, now); err != nil {
+		logger.Warn(ctx, "TouchLastSeen: repo update failed", map[string]any{"user_id": userID, "error": err.Error()})
+	}
+}
+
+func (s *userService) PurgeLoginHistory(ctx context.Context, retention time.Duration) (int64, error) {
+	cutoff := time.Now().Add(-retention)
+	logger.Debug(ctx, "UserService.PurgeLoginHistory start", map[string]any{"cutoff": cutoff})
+	purged, err := s.loginEventRepo.DeleteBefore(ctx, cutoff)
+	if err != nil {
+		logger.Error(ctx, "PurgeLoginHistory: repo delete failed", map[string]any{"cutoff": cutoff, "error": err.Error()})
+		return 0, err
+	}
+	if purged > 0 {
+		logger.Info(ctx, "UserService.PurgeLoginHistory success", map[string]any{"purged": purged, "cutoff": cutoff})
+	}
+	return purged, nil
+}
+
+func (s *userService) Logout(ctx context.Context, userID uint) error {
 	logger.Info(ctx, "UserService.Logout start", map[string]any{"user_id": userID})
 	err := s.redisService.DeleteUserSession(ctx, userID)
 	if err != nil {
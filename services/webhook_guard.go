@@ -0,0 +1,87 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"syscall"
+	"time"
+
+	"go-boilerplate/models"
+)
+
+// reservedPrefixes are non-public ranges that netip.Addr has no predicate for.
+var reservedPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),     // "this network", reaches the local host
+	netip.MustParsePrefix("100.64.0.0/10"), // carrier-grade NAT, used for cloud metadata
+	netip.MustParsePrefix("192.0.0.0/24"),  // IETF protocol assignments
+	netip.MustParsePrefix("198.18.0.0/15"), // benchmarking
+	netip.MustParsePrefix("64:ff9b::/96"),  // NAT64, embeds any IPv4 address
+}
+
+// publicAddr reports whether webhooks may be sent to addr. Private, loopback,
+// link-local, multicast and unspecified addresses are refused so that a
+// subscription cannot reach the internal network.
+func publicAddr(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	if !addr.IsValid() || addr.IsPrivate() || addr.IsLoopback() || addr.IsUnspecified() ||
+		addr.IsLinkLocalUnicast() || addr.IsLinkLocalMulticast() || addr.IsInterfaceLocalMulticast() || addr.IsMulticast() {
+		return false
+	}
+	for _, prefix := range reservedPrefixes {
+		if prefix.Contains(addr) {
+			return false
+		}
+	}
+	return true
+}
+
+// checkWebhookURL resolves the host of rawURL and returns
+// models.ErrURLNotAllowed unless every address it resolves to is public.
+func checkWebhookURL(ctx context.Context, rawURL string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return fmt.Errorf("%w: %v", models.ErrURLNotAllowed, err)
+	}
+	host := u.Hostname()
+	var addrs []netip.Addr
+	if addr, err := netip.ParseAddr(host); err == nil {
+		addrs = []netip.Addr{addr}
+	} else if addrs, err = net.DefaultResolver.LookupNetIP(ctx, "ip", host); err != nil {
+		return fmt.Errorf("%w: %s cannot be resolved", models.ErrURLNotAllowed, host)
+	}
+	for _, addr := range addrs {
+		if !publicAddr(addr) {
+			return fmt.Errorf("%w: %s resolves to a non-public address", models.ErrURLNotAllowed, host)
+		}
+	}
+	return nil
+}
+
+// publicOnlyTransport returns a transport that refuses to connect to
+// non-public addresses. The check runs on the address actually dialed, after
+// resolution, so a host that passed checkWebhookURL and was re-pointed since
+// (DNS rebinding) is still refused. Proxies are not used, since the check
+// would then apply to the proxy instead of the endpoint.
+func publicOnlyTransport(timeout time.Duration) *http.Transport {
+	dialer := &net.Dialer{
+		Timeout: timeout,
+		Control: func(network, address string, _ syscall.RawConn) error {
+			addr, err := netip.ParseAddrPort(address)
+			if err != nil {
+				return err
+			}
+			if !publicAddr(addr.Addr()) {
+				return fmt.Errorf("%w: %s is not a public address", models.ErrURLNotAllowed, addr.Addr())
+			}
+			return nil
+		},
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+	return transport
+}
@@ -0,0 +1,558 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	mathrand "math/rand/v2"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"go-boilerplate/config"
+	"go-boilerplate/logger"
+	"go-boilerplate/models"
+	"go-boilerplate/models/request"
+	"go-boilerplate/models/response"
+	repoInterfaces "go-boilerplate/repository/interfaces"
+	serviceInterfaces "go-boilerplate/services/interfaces"
+	"go-boilerplate/streams"
+	"go-boilerplate/tenant"
+	"go-boilerplate/utilities"
+
+	"gorm.io/gorm"
+)
+
+// Headers sent with every webhook delivery.
+const (
+	webhookIDHeader        = "Webhook-Id"
+	webhookEventHeader     = "Webhook-Event"
+	webhookTimestampHeader = "Webhook-Timestamp"
+	webhookSignatureHeader = "Webhook-Signature"
+)
+
+type webhookService struct {
+	webhookRepo repoInterfaces.WebhookRepository
+	orgRepo     repoInterfaces.OrganizationRepository
+	client      *http.Client
+	cfg         config.WebhookConfig
+}
+
+func NewWebhookService(webhookRepo repoInterfaces.WebhookRepository, orgRepo repoInterfaces.OrganizationRepository, cfg *config.Config) serviceInterfaces.WebhookService {
+	webhookCfg := cfg.Webhook
+	if webhookCfg.Workers < 1 {
+		webhookCfg.Workers = 1
+	}
+	if webhookCfg.BatchSize < 1 {
+		webhookCfg.BatchSize = 50
+	}
+	if webhookCfg.MaxAttempts < 1 {
+		webhookCfg.MaxAttempts = 1
+	}
+	client := &http.Client{
+		Timeout: webhookCfg.Timeout,
+		// A redirect is reported as a failed delivery rather than followed.
+		CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse },
+	}
+	if !webhookCfg.AllowPrivateNetworks {
+		client.Transport = publicOnlyTransport(webhookCfg.Timeout)
+	}
+	return &webhookService{
+		webhookRepo: webhookRepo,
+		orgRepo:     orgRepo,
+		client:      client,
+		cfg:         webhookCfg,
+	}
+}
+
+func (s *webhookService) CreateSubscription(ctx context.Context, orgID, actorID uint, req *request.CreateWebhookRequest) (*response.WebhookResponse, error) {
+	logger.Info(ctx, "WebhookService.CreateSubscription start", map[string]any{"organization_id": orgID, "actor_id": actorID})
+	if _, err := authorizeMember(ctx, s.orgRepo, orgID, actorID, models.MembershipRoleOwner, models.MembershipRoleAdmin); err != nil {
+		return nil, err
+	}
+
+	if err := s.checkURL(ctx, "CreateSubscription", req.URL); err != nil {
+		return nil, err
+	}
+
+	secret, err := newWebhookSecret()
+	if err != nil {
+		logger.Error(ctx, "CreateSubscription: secret generation failed", map[string]any{"error": err.Error()})
+		return nil, err
+	}
+	sub := &models.WebhookSubscription{
+		OrganizationID: orgID,
+		URL:            req.URL,
+		Description:    req.Description,
+		EventTypes:     req.EventTypes,
+		Secret:         secret,
+		Active:         true,
+	}
+	if err := s.webhookRepo.CreateSubscription(tenant.WithID(ctx, orgID), sub); err != nil {
+		logger.Error(ctx, "CreateSubscription: repo create failed", map[string]any{"organization_id": orgID, "error": err.Error()})
+		return nil, err
+	}
+
+	logger.Info(ctx, "WebhookService.CreateSubscription success", map[string]any{"organization_id": orgID, "subscription_id": sub.ID})
+	res := toWebhookResponse(sub)
+	res.Secret = sub.Secret
+	return res, nil
+}
+
+func (s *webhookService) ListSubscriptions(ctx context.Context, orgID, actorID uint) ([]*response.WebhookResponse, error) {
+	if _, err := authorizeMember(ctx, s.orgRepo, orgID, actorID, models.MembershipRoleOwner, models.MembershipRoleAdmin); err != nil {
+		return nil, err
+	}
+	subs, err := s.webhookRepo.ListSubscriptions(tenant.WithID(ctx, orgID), orgID)
+	if err != nil {
+		logger.Error(ctx, "WebhookService.ListSubscriptions failed", map[string]any{"organization_id": orgID, "error": err.Error()})
+		return nil, err
+	}
+	res := make([]*response.WebhookResponse, len(subs))
+	for i, sub := range subs {
+		res[i] = toWebhookResponse(sub)
+	}
+	return res, nil
+}
+
+func (s *webhookService) GetSubscription(ctx context.Context, orgID, subID, actorID uint) (*response.WebhookResponse, error) {
+	if _, err := authorizeMember(ctx, s.orgRepo, orgID, actorID, models.MembershipRoleOwner, models.MembershipRoleAdmin); err != nil {
+		return nil, err
+	}
+	sub, err := s.getSubscription(tenant.WithID(ctx, orgID), orgID, subID)
+	if err != nil {
+		return nil, err
+	}
+	return toWebhookResponse(sub), nil
+}
+
+func (s *webhookService) UpdateSubscription(ctx context.Context, orgID, subID, actorID uint, req *request.UpdateWebhookRequest) (*response.WebhookResponse, error) {
+	logger.Info(ctx, "WebhookService.UpdateSubscription start", map[string]any{"organization_id": orgID, "subscription_id": subID})
+	if _, err := authorizeMember(ctx, s.orgRepo, orgID, actorID, models.MembershipRoleOwner, models.MembershipRoleAdmin); err != nil {
+		return nil, err
+	}
+	ctx = tenant.WithID(ctx, orgID)
+	sub, err := s.getSubscription(ctx, orgID, subID)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.URL != nil {
+		if err := s.checkURL(ctx, "UpdateSubscription", *req.URL); err != nil {
+			return nil, err
+		}
+		sub.URL = *req.URL
+	}
+	if req.Description != nil {
+		sub.Description = *req.Description
+	}
+	if req.EventTypes != nil {
+		sub.EventTypes = *req.EventTypes
+	}
+	if req.Active != nil {
+		sub.Active = *req.Active
+	}
+	if req.RotateSecret {
+		if sub.Secret, err = newWebhookSecret(); err != nil {
+			logger.Error(ctx, "UpdateSubscription: secret generation failed", map[string]any{"error": err.Error()})
+			return nil, err
+		}
+	}
+
+	if err := s.webhookRepo.UpdateSubscription(ctx, sub); err != nil {
+		logger.Error(ctx, "UpdateSubscription: repo update failed", map[string]any{"subscription_id": subID, "error": err.Error()})
+		return nil, err
+	}
+
+	logger.Info(ctx, "WebhookService.UpdateSubscription success", map[string]any{"subscription_id": subID, "secret_rotated": req.RotateSecret})
+	res := toWebhookResponse(sub)
+	if req.RotateSecret {
+		res.Secret = sub.Secret
+	}
+	return res, nil
+}
+
+func (s *webhookService) DeleteSubscription(ctx context.Context, orgID, subID, actorID uint) error {
+	logger.Info(ctx, "WebhookService.DeleteSubscription start", map[string]any{"organization_id": orgID, "subscription_id": subID})
+	if _, err := authorizeMember(ctx, s.orgRepo, orgID, actorID, models.MembershipRoleOwner, models.MembershipRoleAdmin); err != nil {
+		return err
+	}
+	if err := s.webhookRepo.DeleteSubscription(tenant.WithID(ctx, orgID), orgID, subID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			logger.Warn(ctx, "DeleteSubscription: not found", map[string]any{"organization_id": orgID, "subscription_id": subID})
+			return models.ErrNotFound
+		}
+		logger.Error(ctx, "DeleteSubscription: repo delete failed", map[string]any{"subscription_id": subID, "error": err.Error()})
+		return err
+	}
+	logger.Info(ctx, "WebhookService.DeleteSubscription success", map[string]any{"subscription_id": subID})
+	return nil
+}
+
+func (s *webhookService) ListDeliveries(ctx context.Context, orgID, subID, actorID uint, page, perPage int, status string) (*response.PaginationResponse, error) {
+	if _, err := authorizeMember(ctx, s.orgRepo, orgID, actorID, models.MembershipRoleOwner, models.MembershipRoleAdmin); err != nil {
+		return nil, err
+	}
+	ctx = tenant.WithID(ctx, orgID)
+	if _, err := s.getSubscription(ctx, orgID, subID); err != nil {
+		return nil, err
+	}
+
+	page, perPage, offset := utilities.CalculateOffset(page, perPage)
+	deliveries, total, err := s.webhookRepo.ListDeliveries(ctx, subID, status, offset, perPage)
+	if err != nil {
+		logger.Error(ctx, "WebhookService.ListDeliveries failed", map[string]any{"subscription_id": subID, "error": err.Error()})
+		return nil, err
+	}
+
+	return &response.PaginationResponse{
+		Data:       deliveries,
+		Page:       page,
+		PerPage:    perPage,
+		Total:      total,
+		TotalPages: utilities.TotalPages(int(total), perPage),
+	}, nil
+}
+
+func (s *webhookService) Redeliver(ctx context.Context, orgID, subID, deliveryID, actorID uint) (*models.WebhookDelivery, error) {
+	logger.Info(ctx, "WebhookService.Redeliver start", map[string]any{"subscription_id": subID, "delivery_id": deliveryID})
+	if _, err := authorizeMember(ctx, s.orgRepo, orgID, actorID, models.MembershipRoleOwner, models.MembershipRoleAdmin); err != nil {
+		return nil, err
+	}
+	ctx = tenant.WithID(ctx, orgID)
+	if _, err := s.getSubscription(ctx, orgID, subID); err != nil {
+		return nil, err
+	}
+
+	delivery, err := s.webhookRepo.GetDelivery(ctx, subID, deliveryID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			logger.Warn(ctx, "Redeliver: not found", map[string]any{"subscription_id": subID, "delivery_id": deliveryID})
+			return nil, models.ErrNotFound
+		}
+		logger.Error(ctx, "Redeliver: repo get failed", map[string]any{"delivery_id": deliveryID, "error": err.Error()})
+		return nil, err
+	}
+
+	// The outcome of the last attempt stays in the log until the next one.
+	now := time.Now()
+	delivery.Status = models.WebhookDeliveryPending
+	delivery.Attempts = 0
+	delivery.NextAttemptAt = &now
+	delivery.DeliveredAt = nil
+	if err := s.webhookRepo.UpdateDelivery(ctx, delivery); err != nil {
+		logger.Error(ctx, "Redeliver: repo update failed", map[string]any{"delivery_id": deliveryID, "error": err.Error()})
+		return nil, err
+	}
+
+	logger.Info(ctx, "WebhookService.Redeliver success", map[string]any{"delivery_id": deliveryID})
+	return delivery, nil
+}
+
+// webhookBody is the JSON body of a delivery.
+type webhookBody struct {
+	ID             string          `json:"id"`
+	Type           string          `json:"type"`
+	OccurredAt     time.Time       `json:"occurred_at"`
+	OrganizationID uint            `json:"organization_id"`
+	Data           json.RawMessage `json:"data"`
+}
+
+// HandleEvent fans a user event out to the subscriptions of every organization
+// the user belongs to. Deliveries are keyed by subscription and event, so an
+// event read again from the stream is not queued twice.
+func (s *webhookService) HandleEvent(ctx context.Context, msg streams.Message) error {
+	if msg.AggregateType != models.AggregateUser {
+		return nil
+	}
+	userID, err := strconv.ParseUint(msg.AggregateID, 10, 32)
+	if err != nil {
+		logger.Warn(ctx, "HandleEvent: invalid aggregate ID", map[string]any{"event_id": msg.EventID, "aggregate_id": msg.AggregateID})
+		return nil
+	}
+
+	ctx = tenant.WithoutScope(ctx)
+	orgIDs, err := s.orgRepo.ListOrganizationIDs(ctx, uint(userID))
+	if err != nil {
+		logger.Error(ctx, "HandleEvent: membership lookup failed", map[string]any{"user_id": userID, "error": err.Error()})
+		return err
+	}
+	subs, err := s.webhookRepo.ListActiveSubscriptions(ctx, orgIDs)
+	if err != nil {
+		logger.Error(ctx, "HandleEvent: subscription lookup failed", map[string]any{"user_id": userID, "error": err.Error()})
+		return err
+	}
+
+	now := time.Now()
+	var deliveries []*models.WebhookDelivery
+	for _, sub := range subs {
+		if !sub.Matches(msg.Type) {
+			continue
+		}
+		body, err := json.Marshal(webhookBody{
+			ID:             msg.EventID,
+			Type:           msg.Type,
+			OccurredAt:     msg.OccurredAt,
+			OrganizationID: sub.OrganizationID,
+			Data:           msg.Payload,
+		})
+		if err != nil {
+			logger.Warn(ctx, "HandleEvent: invalid payload", map[string]any{"event_id": msg.EventID, "error": err.Error()})
+			return nil
+		}
+		deliveries = append(deliveries, &models.WebhookDelivery{
+			SubscriptionID: sub.ID,
+			OrganizationID: sub.OrganizationID,
+			EventID:        msg.EventID,
+			EventType:      msg.Type,
+			Body:           string(body),
+			TraceID:        msg.TraceID,
+			Status:         models.WebhookDeliveryPending,
+			NextAttemptAt:  &now,
+		})
+	}
+	if err := s.webhookRepo.CreateDeliveries(ctx, deliveries); err != nil {
+		logger.Error(ctx, "HandleEvent: repo create failed", map[string]any{"event_id": msg.EventID, "error": err.Error()})
+		return err
+	}
+
+	if len(deliveries) > 0 {
+		logger.Info(ctx, "WebhookService.HandleEvent success", map[string]any{"event_id": msg.EventID, "type": msg.Type, "deliveries": len(deliveries)})
+	}
+	return nil
+}
+
+// DeliverDue claims due deliveries in batches and sends each batch with a pool
+// of cfg.Workers workers, until fewer than a full batch is due.
+func (s *webhookService) DeliverDue(ctx context.Context) (int, error) {
+	// A claim outlives the request timeout, so a delivery is only picked up
+	// again if the worker sending it has died.
+	lease := 2*s.cfg.Timeout + time.Minute
+	total := 0
+	for ctx.Err() == nil {
+		deliveries, err := s.webhookRepo.ClaimDue(ctx, s.cfg.BatchSize, lease)
+		if err != nil {
+			logger.Error(ctx, "WebhookService.DeliverDue: claim failed", map[string]any{"error": err.Error()})
+			return total, err
+		}
+
+		jobs := make(chan *models.WebhookDelivery)
+		var wg sync.WaitGroup
+		for range min(s.cfg.Workers, len(deliveries)) {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for delivery := range jobs {
+					s.attempt(ctx, delivery)
+				}
+			}()
+		}
+		for _, delivery := range deliveries {
+			jobs <- delivery
+		}
+		close(jobs)
+		wg.Wait()
+
+		total += len(deliveries)
+		if len(deliveries) < s.cfg.BatchSize {
+			break
+		}
+	}
+	return total, nil
+}
+
+// attempt sends a delivery once and records the outcome, scheduling a retry
+// or moving the delivery to the dead state after cfg.MaxAttempts failures.
+func (s *webhookService) attempt(ctx context.Context, delivery *models.WebhookDelivery) {
+	// Continue the trace of the request that caused the event.
+	if delivery.TraceID != "" {
+		ctx = logger.WithTrace(ctx, delivery.TraceID)
+	}
+	ctx, _, _ = logger.StartSpan(ctx)
+
+	sub := delivery.Subscription
+	switch {
+	case sub == nil || sub.DeletedAt.Valid:
+		s.finish(ctx, delivery, models.WebhookDeliveryDead, "subscription deleted")
+		return
+	case !sub.Active:
+		s.finish(ctx, delivery, models.WebhookDeliveryDead, "subscription disabled")
+		return
+	}
+
+	start := time.Now()
+	statusCode, err := s.send(ctx, sub, delivery)
+	delivery.Attempts++
+	delivery.LastAttemptAt = &start
+	delivery.LastDurationMs = time.Since(start).Milliseconds()
+	delivery.LastStatusCode = statusCode
+
+	if err == nil {
+		delivery.Status = models.WebhookDeliverySucceeded
+		delivery.DeliveredAt = &start
+		delivery.NextAttemptAt = nil
+		delivery.LastError = ""
+		logger.Info(ctx, "Webhook delivered", map[string]any{"delivery_id": delivery.ID, "subscription_id": sub.ID, "status_code": statusCode, "duration_ms": delivery.LastDurationMs})
+	} else if delivery.Attempts >= s.cfg.MaxAttempts {
+		delivery.Status = models.WebhookDeliveryDead
+		delivery.NextAttemptAt = nil
+		delivery.LastError = err.Error()
+		logger.Error(ctx, "Webhook delivery dead-lettered", map[string]any{"delivery_id": delivery.ID, "subscription_id": sub.ID, "attempts": delivery.Attempts, "error": err.Error()})
+	} else {
+		next := time.Now().Add(webhookBackoff(s.cfg.RetryBase, s.cfg.RetryMax, delivery.Attempts))
+		delivery.NextAttemptAt = &next
+		delivery.LastError = err.Error()
+		logger.Warn(ctx, "Webhook delivery failed, will retry", map[string]any{"delivery_id": delivery.ID, "subscription_id": sub.ID, "attempts": delivery.Attempts, "next_attempt_at": next, "error": err.Error()})
+	}
+
+	if err := s.webhookRepo.UpdateDelivery(ctx, delivery); err != nil {
+		// The claim expires and the delivery is sent again.
+		logger.Error(ctx, "attempt: repo update failed", map[string]any{"delivery_id": delivery.ID, "error": err.Error()})
+	}
+}
+
+// finish ends a delivery without sending it.
+func (s *webhookService) finish(ctx context.Context, delivery *models.WebhookDelivery, status, reason string) {
+	delivery.Status = status
+	delivery.NextAttemptAt = nil
+	delivery.LastError = reason
+	logger.Warn(ctx, "Webhook delivery dropped", map[string]any{"delivery_id": delivery.ID, "reason": reason})
+	if err := s.webhookRepo.UpdateDelivery(ctx, delivery); err != nil {
+		logger.Error(ctx, "finish: repo update failed", map[string]any{"delivery_id": delivery.ID, "error": err.Error()})
+	}
+}
+
+// send POSTs the delivery's body and returns the response status. Anything
+// other than a 2xx response is an error.
+func (s *webhookService) send(ctx context.Context, sub *models.WebhookSubscription, delivery *models.WebhookDelivery) (int, error) {
+	body := []byte(delivery.Body)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, sub.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	timestamp := time.Now().Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "go-boilerplate-webhooks/1.0")
+	req.Header.Set(webhookIDHeader, delivery.EventID)
+	req.Header.Set(webhookEventHeader, delivery.EventType)
+	req.Header.Set(webhookTimestampHeader, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(webhookSignatureHeader, signWebhook(sub.Secret, timestamp, body))
+	req = logger.InjectIntoRequest(ctx, req)
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	// Only the status code is recorded; the response is the endpoint's to
+	// choose and could reflect anything it can reach.
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("endpoint responded with status %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
+
+func (s *webhookService) PurgeDeliveries(ctx context.Context, retention time.Duration) (int64, error) {
+	cutoff := time.Now().Add(-retention)
+	logger.Debug(ctx, "WebhookService.PurgeDeliveries start", map[string]any{"cutoff": cutoff})
+	purged, err := s.webhookRepo.DeleteFinishedDeliveries(ctx, cutoff)
+	if err != nil {
+		logger.Error(ctx, "PurgeDeliveries: repo delete failed", map[string]any{"cutoff": cutoff, "error": err.Error()})
+		return 0, err
+	}
+	if purged > 0 {
+		logger.Info(ctx, "WebhookService.PurgeDeliveries success", map[string]any{"purged": purged, "cutoff": cutoff})
+	}
+	return purged, nil
+}
+
+// checkURL rejects endpoints on non-public addresses unless
+// cfg.AllowPrivateNetworks is set. Deliveries are checked again when they
+// connect (see publicOnlyTransport).
+func (s *webhookService) checkURL(ctx context.Context, op, rawURL string) error {
+	if s.cfg.AllowPrivateNetworks {
+		return nil
+	}
+	if err := checkWebhookURL(ctx, rawURL); err != nil {
+		logger.Warn(ctx, op+": URL not allowed", map[string]any{"error": err.Error()})
+		return err
+	}
+	return nil
+}
+
+// getSubscription loads a subscription of the organization, mapping a missing one to models.ErrNotFound.
+func (s *webhookService) getSubscription(ctx context.Context, orgID, subID uint) (*models.WebhookSubscription, error) {
+	sub, err := s.webhookRepo.GetSubscription(ctx, orgID, subID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			logger.Warn(ctx, "getSubscription: not found", map[string]any{"organization_id": orgID, "subscription_id": subID})
+			return nil, models.ErrNotFound
+		}
+		logger.Error(ctx, "getSubscription: repo get failed", map[string]any{"subscription_id": subID, "error": err.Error()})
+		return nil, err
+	}
+	return sub, nil
+}
+
+// signWebhook returns the Webhook-Signature value: the hex HMAC-SHA256, keyed
+// with the subscription secret, of the timestamp, a dot and the body.
+func signWebhook(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte{'.'})
+	mac.Write(body)
+	return "v1=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// webhookBackoff returns the delay before the next attempt after the given
+// number of failed attempts: base doubled for each further failure, capped at
+// maxDelay, of which a random half is taken off so that retries spread out.
+func webhookBackoff(base, maxDelay time.Duration, attempts int) time.Duration {
+	delay := base
+	for i := 1; i < attempts && delay < maxDelay; i++ {
+		delay *= 2
+	}
+	if delay > maxDelay {
+		delay = maxDelay
+	}
+	if delay <= 0 {
+		return 0
+	}
+	half := delay / 2
+	return half + mathrand.N(delay-half+1)
+}
+
+func newWebhookSecret() (string, error) {
+	var b [32]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return "", err
+	}
+	return "whsec_" + hex.EncodeToString(b[:]), nil
+}
+
+func toWebhookResponse(sub *models.WebhookSubscription) *response.WebhookResponse {
+	eventTypes := sub.EventTypes
+	if eventTypes == nil {
+		eventTypes = []string{}
+	}
+	return &response.WebhookResponse{
+		ID:             sub.ID,
+		OrganizationID: sub.OrganizationID,
+		URL:            sub.URL,
+		Description:    sub.Description,
+		EventTypes:     eventTypes,
+		Active:         sub.Active,
+		CreatedAt:      sub.CreatedAt,
+		UpdatedAt:      sub.UpdatedAt,
+	}
+}
@@ -0,0 +1,149 @@
+package services
+
+import (
+	"archive/zip"
+	"bufio"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// xlsxMaxRows is the per-sheet row limit of the XLSX format (including the header row).
+const xlsxMaxRows = 1048576
+
+// xlsxWriter streams a minimal XLSX workbook. Rows are written straight into the
+// zip entry for the current sheet, and a new sheet is started whenever the
+// format's row limit is reached, so memory use stays constant. Strings are
+// written inline, which avoids having to build a shared strings table.
+type xlsxWriter struct {
+	zw     *zip.Writer
+	sheet  *bufio.Writer
+	header []string
+	sheets int
+	rows   int
+}
+
+func newXLSXWriter(w io.Writer, header []string) *xlsxWriter {
+	return &xlsxWriter{zw: zip.NewWriter(w), header: header}
+}
+
+// WriteRow appends a row. Integers are written as numbers and everything else as text.
+func (x *xlsxWriter) WriteRow(values []any) error {
+	if x.sheet == nil || x.rows >= xlsxMaxRows {
+		if err := x.startSheet(); err != nil {
+			return err
+		}
+	}
+	return x.writeRow(values)
+}
+
+func (x *xlsxWriter) startSheet() error {
+	if err := x.endSheet(); err != nil {
+		return err
+	}
+
+	x.sheets++
+	x.rows = 0
+	entry, err := x.zw.Create(fmt.Sprintf("xl/worksheets/sheet%d.xml", x.sheets))
+	if err != nil {
+		return err
+	}
+	x.sheet = bufio.NewWriter(entry)
+	if _, err := x.sheet.WriteString(xml.Header + `<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`); err != nil {
+		return err
+	}
+
+	header := make([]any, len(x.header))
+	for i, h := range x.header {
+		header[i] = h
+	}
+	return x.writeRow(header)
+}
+
+func (x *xlsxWriter) endSheet() error {
+	if x.sheet == nil {
+		return nil
+	}
+	if _, err := x.sheet.WriteString(`</sheetData></worksheet>`); err != nil {
+		return err
+	}
+	err := x.sheet.Flush()
+	x.sheet = nil
+	return err
+}
+
+func (x *xlsxWriter) writeRow(values []any) error {
+	x.rows++
+	fmt.Fprintf(x.sheet, `<row r="%d">`, x.rows)
+	for i, value := range values {
+		ref := xlsxColumnName(i) + strconv.Itoa(x.rows)
+		switch v := value.(type) {
+		case int, int64, uint, uint64:
+			fmt.Fprintf(x.sheet, `<c r="%s"><v>%d</v></c>`, ref, v)
+		default:
+			fmt.Fprintf(x.sheet, `<c r="%s" t="inlineStr"><is><t xml:space="preserve">`, ref)
+			if err := xml.EscapeText(x.sheet, []byte(fmt.Sprint(v))); err != nil {
+				return err
+			}
+			x.sheet.WriteString(`</t></is></c>`)
+		}
+	}
+	_, err := x.sheet.WriteString(`</row>`)
+	return err
+}
+
+// Close finishes the last sheet and writes the workbook parts that list the sheets.
+func (x *xlsxWriter) Close() error {
+	if x.sheet == nil {
+		if err := x.startSheet(); err != nil {
+			return err
+		}
+	}
+	if err := x.endSheet(); err != nil {
+		return err
+	}
+
+	var contentTypes, workbookSheets, workbookRels strings.Builder
+	for i := 1; i <= x.sheets; i++ {
+		fmt.Fprintf(&contentTypes, `<Override PartName="/xl/worksheets/sheet%d.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>`, i)
+		fmt.Fprintf(&workbookSheets, `<sheet name="Sheet%d" sheetId="%d" r:id="rId%d"/>`, i, i, i)
+		fmt.Fprintf(&workbookRels, `<Relationship Id="rId%d" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet%d.xml"/>`, i, i)
+	}
+
+	parts := []struct{ name, body string }{
+		{"[Content_Types].xml", `<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">` +
+			`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>` +
+			`<Default Extension="xml" ContentType="application/xml"/>` +
+			`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>` +
+			contentTypes.String() + `</Types>`},
+		{"_rels/.rels", `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+			`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>` +
+			`</Relationships>`},
+		{"xl/workbook.xml", `<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">` +
+			`<sheets>` + workbookSheets.String() + `</sheets></workbook>`},
+		{"xl/_rels/workbook.xml.rels", `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+			workbookRels.String() + `</Relationships>`},
+	}
+	for _, part := range parts {
+		entry, err := x.zw.Create(part.name)
+		if err != nil {
+			return err
+		}
+		if _, err := io.WriteString(entry, xml.Header+part.body); err != nil {
+			return err
+		}
+	}
+	return x.zw.Close()
+}
+
+// xlsxColumnName converts a 0-based column index to a spreadsheet column name (A, B, ..., AA).
+func xlsxColumnName(i int) string {
+	name := ""
+	for i >= 0 {
+		name = string(rune('A'+i%26)) + name
+		i = i/26 - 1
+	}
+	return name
+}
@@ -0,0 +1,50 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"go-boilerplate/config"
+)
+
+var (
+	// ErrNotFound is returned when an object does not exist.
+	ErrNotFound = errors.New("storage: object not found")
+	// ErrInvalidKey is returned for keys that are not clean relative paths.
+	ErrInvalidKey = errors.New("storage: invalid key")
+)
+
+// ObjectInfo describes a stored object.
+type ObjectInfo struct {
+	Key         string
+	Size        int64
+	ContentType string
+	ModifiedAt  time.Time
+}
+
+// BlobStore is a minimal object store abstraction. Keys are slash-separated
+// relative paths such as "ab/cd/abcdef.png".
+type BlobStore interface {
+	// Put stores size bytes read from r under key, replacing any existing object.
+	Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error
+	// Get opens an object for reading. The caller must close the reader.
+	Get(ctx context.Context, key string) (io.ReadCloser, *ObjectInfo, error)
+	Stat(ctx context.Context, key string) (*ObjectInfo, error)
+	// Delete removes an object. Deleting a missing object is not an error.
+	Delete(ctx context.Context, key string) error
+}
+
+// NewBlobStore builds the backend selected by STORAGE_BACKEND.
+func NewBlobStore(cfg *config.Config) (BlobStore, error) {
+	switch cfg.Storage.Backend {
+	case "", "local":
+		return NewLocalStore(cfg.Storage.LocalRoot)
+	case "s3":
+		return NewS3Store(cfg.Storage.S3)
+	default:
+		return nil, fmt.Errorf("storage: unknown backend %q", cfg.Storage.Backend)
+	}
+}
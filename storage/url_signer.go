@@ -0,0 +1,39 @@
+package storage
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+	"time"
+)
+
+// URLSigner produces and checks HMAC signatures for expiring download URLs.
+type URLSigner struct {
+	secret []byte
+}
+
+func NewURLSigner(secret string) *URLSigner {
+	return &URLSigner{secret: []byte(secret)}
+}
+
+// Sign returns the expiry (unix seconds) and hex signature for key.
+func (s *URLSigner) Sign(key string, ttl time.Duration) (int64, string) {
+	expires := time.Now().Add(ttl).Unix()
+	return expires, s.signature(key, expires)
+}
+
+// Verify reports whether signature is valid for key and has not expired.
+func (s *URLSigner) Verify(key string, expires int64, signature string) bool {
+	if time.Now().Unix() > expires {
+		return false
+	}
+	expected := s.signature(key, expires)
+	return hmac.Equal([]byte(expected), []byte(signature))
+}
+
+func (s *URLSigner) signature(key string, expires int64) string {
+	mac := hmac.New(sha256.New, s.secret)
+	mac.Write([]byte(key + "\n" + strconv.FormatInt(expires, 10)))
+	return hex.EncodeToString(mac.Sum(nil))
+}
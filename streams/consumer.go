@@ -0,0 +1,231 @@
+package streams
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go-boilerplate/logger"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Handler processes one message. Returning nil acknowledges it; returning an
+// error leaves it pending, to be delivered again after ConsumerConfig.ClaimIdle.
+type Handler func(ctx context.Context, msg Message) error
+
+// Reader is the subset of RedisService a Consumer needs.
+type Reader interface {
+	XGroupCreateMkStream(ctx context.Context, stream, group, start string) error
+	XReadGroup(ctx context.Context, args *redis.XReadGroupArgs) ([]redis.XStream, error)
+	XAck(ctx context.Context, stream, group string, ids ...string) (int64, error)
+	XAutoClaim(ctx context.Context, args *redis.XAutoClaimArgs) ([]redis.XMessage, string, error)
+}
+
+// ConsumerConfig configures a Consumer.
+type ConsumerConfig struct {
+	Stream string
+	// Group is the consumer group. Every group receives every message once;
+	// consumers within a group share the messages.
+	Group string
+	// Consumer names this consumer within the group. It should be stable
+	// across restarts so that its pending messages are resumed.
+	Consumer string
+	// Count is the maximum number of messages read per call. Defaults to 10.
+	Count int64
+	// Block is how long a read waits for new messages. Defaults to 5s.
+	Block time.Duration
+	// ClaimIdle is how long a message may stay unacknowledged, with this or
+	// another consumer of the group, before it is claimed and retried. Defaults to 1m.
+	ClaimIdle time.Duration
+}
+
+// Consumer reads a stream as a member of a consumer group.
+//
+// Messages are handled one at a time in stream order. Consumers of the same
+// group run in parallel, and retried messages are handled after newer ones,
+// so a handler cannot rely on seeing the events of an aggregate in order; a
+// version in the payload lets it skip stale events.
+type Consumer struct {
+	client Reader
+	cfg    ConsumerConfig
+}
+
+func NewConsumer(client Reader, cfg ConsumerConfig) *Consumer {
+	if cfg.Count <= 0 {
+		cfg.Count = 10
+	}
+	if cfg.Block <= 0 {
+		cfg.Block = 5 * time.Second
+	}
+	if cfg.ClaimIdle <= 0 {
+		cfg.ClaimIdle = time.Minute
+	}
+	return &Consumer{client: client, cfg: cfg}
+}
+
+// Run creates the consumer group if needed and handles messages until ctx is
+// cancelled. It first resumes this consumer's own pending messages, then reads
+// new ones, claiming messages other consumers left unacknowledged for too long.
+func (c *Consumer) Run(ctx context.Context, handle Handler) error {
+	if err := c.ensureGroup(ctx); err != nil {
+		return err
+	}
+	logger.Info(ctx, "Stream consumer started", map[string]any{"stream": c.cfg.Stream, "group": c.cfg.Group, "consumer": c.cfg.Consumer})
+
+	// "0" re-reads messages delivered to this consumer but not acknowledged.
+	if err := c.drain(ctx, "0", handle); err != nil {
+		return err
+	}
+
+	lastClaim := time.Now()
+	for {
+		if ctx.Err() != nil {
+			logger.Info(ctx, "Stream consumer stopped", map[string]any{"stream": c.cfg.Stream, "consumer": c.cfg.Consumer})
+			return nil
+		}
+
+		if time.Since(lastClaim) >= c.cfg.ClaimIdle {
+			if err := c.claimStale(ctx, handle); err != nil && ctx.Err() == nil {
+				logger.Warn(ctx, "Consumer.Run: claim failed", map[string]any{"stream": c.cfg.Stream, "error": err.Error()})
+			}
+			lastClaim = time.Now()
+		}
+
+		streams, err := c.read(ctx, ">")
+		if err != nil {
+			if ctx.Err() != nil {
+				continue
+			}
+			logger.Warn(ctx, "Consumer.Run: read failed", map[string]any{"stream": c.cfg.Stream, "error": err.Error()})
+			sleep(ctx, time.Second)
+			continue
+		}
+		c.handleAll(ctx, streams, handle)
+	}
+}
+
+// drain handles the messages returned for start until none are left.
+func (c *Consumer) drain(ctx context.Context, start string, handle Handler) error {
+	for {
+		streams, err := c.read(ctx, start)
+		if err != nil {
+			return err
+		}
+		if c.handleAll(ctx, streams, handle) == 0 {
+			return nil
+		}
+		// Pending entries are listed from the ID after the last one seen.
+		last := streams[0].Messages[len(streams[0].Messages)-1].ID
+		start = "(" + last
+	}
+}
+
+func (c *Consumer) read(ctx context.Context, start string) ([]redis.XStream, error) {
+	block := c.cfg.Block
+	if start != ">" {
+		// History reads return immediately.
+		block = -1
+	}
+	streams, err := c.client.XReadGroup(ctx, &redis.XReadGroupArgs{
+		Group:    c.cfg.Group,
+		Consumer: c.cfg.Consumer,
+		Streams:  []string{c.cfg.Stream, start},
+		Count:    c.cfg.Count,
+		Block:    block,
+	})
+	if errors.Is(err, redis.Nil) {
+		return nil, nil
+	}
+	return streams, err
+}
+
+// claimStale takes over messages left pending by any consumer for longer than ClaimIdle.
+func (c *Consumer) claimStale(ctx context.Context, handle Handler) error {
+	start := "0-0"
+	for {
+		messages, next, err := c.client.XAutoClaim(ctx, &redis.XAutoClaimArgs{
+			Stream:   c.cfg.Stream,
+			Group:    c.cfg.Group,
+			Consumer: c.cfg.Consumer,
+			MinIdle:  c.cfg.ClaimIdle,
+			Start:    start,
+			Count:    c.cfg.Count,
+		})
+		if err != nil {
+			return err
+		}
+		if len(messages) > 0 {
+			logger.Info(ctx, "Consumer.claimStale: claimed messages", map[string]any{"stream": c.cfg.Stream, "count": len(messages)})
+			c.handleAll(ctx, []redis.XStream{{Stream: c.cfg.Stream, Messages: messages}}, handle)
+		}
+		if next == "0-0" || next == "" {
+			return nil
+		}
+		start = next
+	}
+}
+
+// handleAll passes each message to handle and acknowledges the ones it
+// accepts. It returns the number of messages seen.
+func (c *Consumer) handleAll(ctx context.Context, streams []redis.XStream, handle Handler) int {
+	seen := 0
+	for _, stream := range streams {
+		for _, entry := range stream.Messages {
+			seen++
+			if ctx.Err() != nil {
+				return seen
+			}
+			c.handleOne(ctx, stream.Stream, entry, handle)
+		}
+	}
+	return seen
+}
+
+func (c *Consumer) handleOne(ctx context.Context, stream string, entry redis.XMessage, handle Handler) {
+	msg, err := parseMessage(stream, entry)
+	if err != nil {
+		// Retrying cannot fix a malformed entry, so it is acknowledged and dropped.
+		logger.Error(ctx, "Consumer: dropping malformed message", map[string]any{"stream": stream, "id": entry.ID, "error": err.Error()})
+		c.ack(ctx, stream, entry.ID)
+		return
+	}
+
+	// Continue the producer's trace.
+	msgCtx := ctx
+	if msg.TraceID != "" {
+		msgCtx = logger.WithTrace(ctx, msg.TraceID)
+	}
+	msgCtx, _, _ = logger.StartSpan(msgCtx)
+
+	if err := handle(msgCtx, msg); err != nil {
+		logger.Warn(msgCtx, "Consumer: handler failed, message stays pending", map[string]any{"stream": stream, "id": entry.ID, "type": msg.Type, "error": err.Error()})
+		return
+	}
+	c.ack(msgCtx, stream, entry.ID)
+}
+
+func (c *Consumer) ack(ctx context.Context, stream, id string) {
+	if _, err := c.client.XAck(ctx, stream, c.cfg.Group, id); err != nil {
+		logger.Warn(ctx, "Consumer: ack failed", map[string]any{"stream": stream, "id": id, "error": err.Error()})
+	}
+}
+
+// ensureGroup creates the consumer group, and the stream if necessary. A new
+// group starts with the stream's first entry.
+func (c *Consumer) ensureGroup(ctx context.Context) error {
+	err := c.client.XGroupCreateMkStream(ctx, c.cfg.Stream, c.cfg.Group, "0")
+	if err != nil && !redis.HasErrorPrefix(err, "BUSYGROUP") {
+		return err
+	}
+	return nil
+}
+
+func sleep(ctx context.Context, d time.Duration) {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+	case <-t.C:
+	}
+}
@@ -0,0 +1,115 @@
+// Package streams publishes domain events to Redis Streams and consumes them
+// with consumer groups. Each event is a stream entry with string fields; see
+// Message for the layout.
+package streams
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Message is a domain event as carried on a stream.
+type Message struct {
+	// ID is the stream entry ID, assigned by Redis on publish.
+	ID string
+	// Stream is the stream the message was read from.
+	Stream string
+	// EventID identifies the event. Delivery is at-least-once, so consumers
+	// should use it to discard duplicates.
+	EventID       string
+	Type          string
+	AggregateType string
+	AggregateID   string
+	// TenantID is empty for events not made within an organization.
+	TenantID   string
+	Payload    json.RawMessage
+	TraceID    string
+	OccurredAt time.Time
+}
+
+func (m *Message) values() map[string]any {
+	return map[string]any{
+		"event_id":       m.EventID,
+		"type":           m.Type,
+		"aggregate_type": m.AggregateType,
+		"aggregate_id":   m.AggregateID,
+		"tenant_id":      m.TenantID,
+		"payload":        string(m.Payload),
+		"trace_id":       m.TraceID,
+		"occurred_at":    m.OccurredAt.UTC().Format(time.RFC3339Nano),
+	}
+}
+
+// parseMessage converts a stream entry back into a Message.
+func parseMessage(stream string, entry redis.XMessage) (Message, error) {
+	field := func(name string) string {
+		s, _ := entry.Values[name].(string)
+		return s
+	}
+	msg := Message{
+		ID:            entry.ID,
+		Stream:        stream,
+		EventID:       field("event_id"),
+		Type:          field("type"),
+		AggregateType: field("aggregate_type"),
+		AggregateID:   field("aggregate_id"),
+		TenantID:      field("tenant_id"),
+		Payload:       json.RawMessage(field("payload")),
+		TraceID:       field("trace_id"),
+	}
+	if msg.EventID == "" || msg.Type == "" {
+		return msg, fmt.Errorf("streams: entry %s is not an event", entry.ID)
+	}
+	if occurredAt := field("occurred_at"); occurredAt != "" {
+		t, err := time.Parse(time.RFC3339Nano, occurredAt)
+		if err != nil {
+			return msg, fmt.Errorf("streams: entry %s: invalid occurred_at: %w", entry.ID, err)
+		}
+		msg.OccurredAt = t
+	}
+	return msg, nil
+}
+
+// Appender is the subset of RedisService a Publisher needs.
+type Appender interface {
+	XAdd(ctx context.Context, args *redis.XAddArgs) (string, error)
+}
+
+// Publisher appends messages to streams.
+type Publisher struct {
+	client Appender
+	maxLen int64
+}
+
+// NewPublisher returns a Publisher. A positive maxLen trims each stream to
+// about that many entries on publish; older entries may then be lost to
+// consumers that fall too far behind.
+func NewPublisher(client Appender, maxLen int64) *Publisher {
+	return &Publisher{client: client, maxLen: maxLen}
+}
+
+// Publish appends msg to stream and returns the new entry ID.
+func (p *Publisher) Publish(ctx context.Context, stream string, msg *Message) (string, error) {
+	args := &redis.XAddArgs{Stream: stream, Values: msg.values()}
+	if p.maxLen > 0 {
+		args.MaxLen = p.maxLen
+		args.Approx = true
+	}
+	id, err := p.client.XAdd(ctx, args)
+	if err != nil {
+		return "", err
+	}
+	msg.ID = id
+	msg.Stream = stream
+	return id, nil
+}
+
+// FormatID formats a numeric event or aggregate ID for a Message.
+func FormatID(id uint) string {
+	return strconv.FormatUint(uint64(id), 10)
+}
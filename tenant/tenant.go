@@ -0,0 +1,76 @@
+// Package tenant carries the organization a request acts for through its context.
+// Repositories read it to scope queries and cache keys to that organization.
+package tenant
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// ErrRequired is returned by tenant-scoped queries that run without a tenant
+// while tenancy is required.
+var ErrRequired = errors.New("tenant required")
+
+type ctxKey int
+
+const (
+	idKey ctxKey = iota
+	unscopedKey
+)
+
+// WithID returns a context scoped to the given organization.
+func WithID(ctx context.Context, id uint) context.Context {
+	return context.WithValue(ctx, idKey, id)
+}
+
+// ID returns the organization the context is scoped to, if any.
+func ID(ctx context.Context) (uint, bool) {
+	id, ok := ctx.Value(idKey).(uint)
+	return id, ok && id != 0
+}
+
+// WithoutScope returns a context that deliberately crosses tenants, e.g. for
+// background jobs or global lookups such as login by email. Any tenant set on
+// ctx is cleared, and queries are not scoped even while tenancy is required.
+func WithoutScope(ctx context.Context) context.Context {
+	ctx = context.WithValue(ctx, idKey, uint(0))
+	return context.WithValue(ctx, unscopedKey, true)
+}
+
+// IsUnscoped reports whether ctx was marked with WithoutScope and no tenant was set since.
+func IsUnscoped(ctx context.Context) bool {
+	if _, ok := ID(ctx); ok {
+		return false
+	}
+	unscoped, _ := ctx.Value(unscopedKey).(bool)
+	return unscoped
+}
+
+// Detach returns a background context with ctx's tenant scope, for work that
+// outlives the request that started it.
+func Detach(ctx context.Context) context.Context {
+	detached := context.Background()
+	if id, ok := ID(ctx); ok {
+		return WithID(detached, id)
+	}
+	if IsUnscoped(ctx) {
+		return WithoutScope(detached)
+	}
+	return detached
+}
+
+// Key prefixes a cache key with the context's tenant, so that entries cached
+// for one organization are never served to another. Without a tenant the key
+// is returned unchanged.
+func Key(ctx context.Context, key string) string {
+	if id, ok := ID(ctx); ok {
+		return KeyFor(id, key)
+	}
+	return key
+}
+
+// KeyFor prefixes a cache key with the given tenant.
+func KeyFor(id uint, key string) string {
+	return fmt.Sprintf("tenant:%d:%s", id, key)
+}
@@ -46,10 +46,12 @@ echo -e "\n   Getting same user again (should be from cache):"
 curl -s "$BASE_URL/api/v1/users/1" | jq .
 
 if [ "$TOKEN" != "null" ] && [ "$TOKEN" != "" ]; then
-    echo -e "\n7. Update User (Protected):"
+    echo -e "\n7. Update User (Protected, If-Match):"
+    ETAG=$(curl -s -D - -o /dev/null "$BASE_URL/api/v1/users/1" | grep -i '^etag:' | cut -d' ' -f2 | tr -d '\r')
     curl -s -X PUT "$BASE_URL/api/v1/users/1" \
       -H "Content-Type: application/json" \
       -H "Authorization: Bearer $TOKEN" \
+      -H "If-Match: $ETAG" \
       -d '{
         "name": "Updated Test User"
       }' | jq .
@@ -69,5 +71,6 @@ echo "  GET  /api/v1/auth/me            - Get current user (Protected)"
 echo "  POST /api/v1/auth/logout        - Logout user (Protected)"
 echo "  GET  /api/v1/users              - Get all users"
 echo "  GET  /api/v1/users/:id          - Get user by ID (cached)"
-echo "  PUT  /api/v1/users/:id          - Update user (Protected)"
-echo "  DELETE /api/v1/users/:id        - Delete user (Protected)"
+echo "  PUT  /api/v1/users/:id          - Update user (Protected, If-Match)"
+echo "  PATCH /api/v1/users/:id         - Partially update user (Protected, If-Match)"
+echo "  DELETE /api/v1/users/:id        - Delete user (Protected, If-Match)"
@@ -1,13 +1,139 @@
 package utilities
 
-import "fmt"
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+
+	"go-boilerplate/models/request"
+	"go-boilerplate/tenant"
+)
 
 // Cache keys constants (kept minimal and generic)
+//
+// Keys that are used together carry a Redis Cluster hash tag, the part in
+// braces, so that they map to the same slot: all keys of a user share
+// "{<id>}", an upload and its lock share "{<upload id>}", and the user list
+// pages share "{users:list}" with their tag set, which the invalidation
+// script deletes in one call, and a lock shares "{<name>}" with its fencing
+// counter.
 const (
-	UserCachePrefix = "user:"
+	UserCachePrefix       = "user:"
+	UserSessionPrefix     = "user_session:"
+	ImportJobCachePrefix  = "import_job:"
+	TusUploadCachePrefix  = "tus_upload:"
+	TusUploadLockPrefix   = "tus_upload_lock:"
+	MembershipCachePrefix = "member:"
+	LastSeenPrefix        = "last_seen:"
+	UserListCachePrefix   = "{users:list}:"
+	LockPrefix            = "lock:"
+	RateLimitPrefix       = "ratelimit:"
+	IdempotencyPrefix     = "idempotency:"
 )
 
-// UserCacheKey builds the cache key for a user entity by ID.
-func UserCacheKey(userID uint) string {
-	return fmt.Sprintf("%s%d", UserCachePrefix, userID)
+// UserListTag names the Redis set that tracks every cached page of the user
+// list, in all tenant namespaces, so that a user change can drop them all.
+const UserListTag = "tag:{users:list}"
+
+// hashTag wraps s in braces so that Redis Cluster hashes only s.
+func hashTag(s string) string {
+	return "{" + s + "}"
+}
+
+// userTag is the hash tag shared by all keys of a user.
+func userTag(userID uint) string {
+	return hashTag(fmt.Sprint(userID))
+}
+
+// UserCacheKey builds the cache key for a user entity by ID, namespaced by the
+// tenant in ctx so that a user cached for one organization is not served to another.
+func UserCacheKey(ctx context.Context, userID uint) string {
+	return tenant.Key(ctx, UserCachePrefix+userTag(userID))
+}
+
+// UserCacheKeys lists a user's cache key in every namespace it may be cached
+// in: the global one and one per organization the user belongs to.
+func UserCacheKeys(userID uint, tenantIDs []uint) []string {
+	key := UserCachePrefix + userTag(userID)
+	keys := []string{key}
+	for _, id := range tenantIDs {
+		keys = append(keys, tenant.KeyFor(id, key))
+	}
+	return keys
+}
+
+// UserListCacheKey builds the cache key for a page of the user list. The
+// query is hashed so that filters of any length give a short key.
+func UserListCacheKey(ctx context.Context, page, perPage int, filter *request.UserFilter) string {
+	query, _ := json.Marshal(struct {
+		Page    int                 `json:"page"`
+		PerPage int                 `json:"per_page"`
+		Filter  *request.UserFilter `json:"filter"`
+	}{page, perPage, filter})
+	sum := sha256.Sum256(query)
+	return tenant.Key(ctx, UserListCachePrefix+hex.EncodeToString(sum[:16]))
+}
+
+// UserSettingsCacheKey builds the cache key for a user's settings, next to the
+// user entry. Settings belong to the user in every organization, so the key
+// is not namespaced.
+func UserSettingsCacheKey(userID uint) string {
+	return UserCachePrefix + userTag(userID) + ":settings"
+}
+
+// UserSessionKey builds the key holding a user's active session token.
+func UserSessionKey(userID uint) string {
+	return UserSessionPrefix + userTag(userID)
+}
+
+// MembershipCacheKey builds the key caching whether a user belongs to an organization.
+func MembershipCacheKey(tenantID, userID uint) string {
+	return tenant.KeyFor(tenantID, MembershipCachePrefix+userTag(userID))
+}
+
+// ImportJobCacheKey builds the key holding the status of an asynchronous user
+// import, namespaced by the tenant in ctx.
+func ImportJobCacheKey(ctx context.Context, jobID string) string {
+	return tenant.Key(ctx, ImportJobCachePrefix+jobID)
+}
+
+// TusUploadCacheKey builds the key holding the state of a resumable upload.
+func TusUploadCacheKey(id string) string {
+	return TusUploadCachePrefix + hashTag(id)
+}
+
+// TusUploadLockKey builds the key that serializes writes to a resumable upload.
+func TusUploadLockKey(id string) string {
+	return TusUploadLockPrefix + hashTag(id)
+}
+
+// LastSeenKey builds the key that marks a user's last_seen_at as recently written.
+func LastSeenKey(userID uint) string {
+	return LastSeenPrefix + userTag(userID)
+}
+
+// LockKey builds the key of a distributed lock. It shares its hash tag with
+// LockFenceKey so that both can be updated in one script.
+func LockKey(name string) string {
+	return LockPrefix + hashTag(name)
+}
+
+// LockFenceKey builds the key of the counter that hands out a lock's
+// fencing tokens. It never expires, so tokens keep growing.
+func LockFenceKey(name string) string {
+	return LockPrefix + hashTag(name) + ":fence"
+}
+
+// RateLimitKey builds the key holding the rate limit state for key, which
+// names the policy and the client it applies to.
+func RateLimitKey(key string) string {
+	return RateLimitPrefix + key
+}
+
+// IdempotencyKey builds the key recording the request made with an
+// Idempotency-Key; scope names the client that sent it.
+func IdempotencyKey(scope, key string) string {
+	return IdempotencyPrefix + scope + ":" + key
 }
@@ -0,0 +1,59 @@
+package utilities
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// VersionETag builds a strong ETag for a versioned entity, e.g. "12-3".
+func VersionETag(id, version uint) string {
+	return fmt.Sprintf(`"%d-%d"`, id, version)
+}
+
+// ParseVersionETag extracts the version from an ETag built by VersionETag for the given id.
+// Weak validators are rejected because If-Match requires strong comparison.
+func ParseVersionETag(id uint, etag string) (uint, bool) {
+	etag = strings.TrimSpace(etag)
+	if strings.HasPrefix(etag, "W/") || len(etag) < 2 || etag[0] != '"' || etag[len(etag)-1] != '"' {
+		return 0, false
+	}
+	idPart, versionPart, found := strings.Cut(etag[1:len(etag)-1], "-")
+	if !found || idPart != strconv.FormatUint(uint64(id), 10) {
+		return 0, false
+	}
+	version, err := strconv.ParseUint(versionPart, 10, 32)
+	if err != nil || version == 0 {
+		return 0, false
+	}
+	return uint(version), true
+}
+
+// ParseVersionETags parses an If-Match header value into the versions of the
+// entity id that it accepts. "*" accepts any version and yields nil. Entries
+// that are weak or belong to another entity can never match and are skipped;
+// ok is false if no entry is left.
+func ParseVersionETags(id uint, header string) (versions []uint, ok bool) {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" {
+			return nil, true
+		}
+		if version, ok := ParseVersionETag(id, candidate); ok {
+			versions = append(versions, version)
+		}
+	}
+	return versions, len(versions) > 0
+}
+
+// ETagMatchesAny reports whether an If-None-Match style header value matches etag.
+// It accepts "*" and comma-separated lists and uses weak comparison.
+func ETagMatchesAny(header, etag string) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == strings.TrimPrefix(etag, "W/") {
+			return true
+		}
+	}
+	return false
+}
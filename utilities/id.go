@@ -0,0 +1,13 @@
+package utilities
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+)
+
+// NewRandomID returns a random 128-bit identifier encoded as hex.
+func NewRandomID() string {
+	var b [16]byte
+	_, _ = rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
@@ -1,16 +1,42 @@
 package utilities
 
 import (
+	"strconv"
+
 	"go-boilerplate/models"
 	"go-boilerplate/models/response"
 )
 
 func ToUserResponse(user *models.User) *response.UserResponse {
-	return &response.UserResponse{
-		ID:        user.ID,
-		Name:      user.Name,
-		Email:     user.Email,
-		CreatedAt: user.CreatedAt,
-		UpdatedAt: user.UpdatedAt,
+	res := &response.UserResponse{
+		ID:                 user.ID,
+		Name:               user.Name,
+		Email:              user.Email,
+		PendingEmail:       user.PendingEmail,
+		Role:               user.Role,
+		Version:            user.Version,
+		CreatedAt:          user.CreatedAt,
+		UpdatedAt:          user.UpdatedAt,
+		ErasureScheduledAt: user.ErasureScheduledAt,
+		LastLoginAt:        user.LastLoginAt,
+		LastSeenAt:         user.LastSeenAt,
+	}
+	if user.Avatar != nil && len(user.Avatar.Sizes) > 0 {
+		res.AvatarURLs = make(map[string]string, len(user.Avatar.Sizes))
+		for _, size := range user.Avatar.Sizes {
+			res.AvatarURLs[strconv.Itoa(size)] = AvatarPath(user.Avatar.Key(size))
+		}
+		res.AvatarURL = AvatarPath(user.Avatar.Key(user.Avatar.Sizes[len(user.Avatar.Sizes)-1]))
+	}
+	if user.DeletedAt.Valid {
+		deletedAt := user.DeletedAt.Time
+		res.DeletedAt = &deletedAt
 	}
+	return res
+}
+
+// AvatarPath returns the public path that serves an avatar thumbnail. Avatar
+// keys are immutable, so the path stays valid for as long as the avatar is set.
+func AvatarPath(key string) string {
+	return "/api/v1/" + key
 }
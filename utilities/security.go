@@ -1,6 +1,12 @@
 package utilities
 
-import "golang.org/x/crypto/bcrypt"
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+
+	"golang.org/x/crypto/bcrypt"
+)
 
 // HashPassword returns the bcrypt hash of the password.
 func HashPassword(password string) (string, error) {
@@ -12,3 +18,15 @@ func HashPassword(password string) (string, error) {
 func CheckPassword(hashedPassword, password string) bool {
 	return bcrypt.CompareHashAndPassword([]byte(hashedPassword), []byte(password)) == nil
 }
+
+// HashPII returns a keyed hash of personal data such as an email address, so
+// that records which must be kept can tell values apart and be searched for a
+// known value without holding the value itself. Empty values stay empty.
+func HashPII(key, value string) string {
+	if value == "" {
+		return ""
+	}
+	mac := hmac.New(sha256.New, []byte(key))
+	mac.Write([]byte(value))
+	return "hmac-sha256:" + hex.EncodeToString(mac.Sum(nil))
+}
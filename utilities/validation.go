@@ -1,13 +1,21 @@
 package utilities
 
 import (
+	"regexp"
+
 	"github.com/go-playground/validator/v10"
 )
 
 var validate *validator.Validate
 
+// slugPattern matches lowercase words joined by single dashes, e.g. "acme-corp".
+var slugPattern = regexp.MustCompile(`^[a-z0-9]+(-[a-z0-9]+)*$`)
+
 func init() {
 	validate = validator.New()
+	_ = validate.RegisterValidation("slug", func(fl validator.FieldLevel) bool {
+		return slugPattern.MatchString(fl.Field().String())
+	})
 }
 
 func ValidateStruct(s interface{}) error {